@@ -0,0 +1,237 @@
+package main
+
+// Chassis power control and status subcommands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+)
+
+var powerActions = map[string]uint8{
+	"on":    ipmi.ChassisPowerUp,
+	"off":   ipmi.ChassisPowerDown,
+	"cycle": ipmi.ChassisPowerCycle,
+	"reset": ipmi.ChassisHardReset,
+	"diag":  ipmi.ChassisDiagInterrupt,
+	"soft":  ipmi.ChassisSoftShutdown,
+}
+
+var bootDevices = map[string]uint8{
+	"none":   ipmi.BootDeviceNone,
+	"pxe":    ipmi.BootDevicePXE,
+	"disk":   ipmi.BootDeviceDisk,
+	"safe":   ipmi.BootDeviceDiskSafe,
+	"diag":   ipmi.BootDeviceDiag,
+	"cdrom":  ipmi.BootDeviceCD,
+	"bios":   ipmi.BootDeviceBIOS,
+	"floppy": ipmi.BootDeviceFloppy,
+}
+
+var powerRestorePolicies = []string{"always-off", "previous", "always-on", "unknown"}
+
+var identifyStates = []string{"off", "temporary", "indefinite", "reserved"}
+
+// cmdPower reports the chassis power state, or performs a power control action
+func cmdPower(args []string) (hostAction, error) {
+	action := "status"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	ctrl, ok := powerActions[action]
+	if !ok && action != "status" {
+		return nil, fmt.Errorf("unknown power action %q, expected status, on, off, cycle, reset, diag or soft", action)
+	}
+
+	return func(client *ipmi.Client, w io.Writer) error {
+		if action == "status" {
+			status, err := client.GetChassisStatus()
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(w, "Chassis power is %s\n", onOff(status.PowerOn))
+			return nil
+		}
+
+		if err := client.ChassisControl(ctrl); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "Chassis power control: %s\n", action)
+
+		return nil
+	}, nil
+}
+
+// cmdChassis reports the chassis status, or controls the chassis identify indicator
+func cmdChassis(args []string) (hostAction, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("expected chassis command: status or identify")
+	}
+
+	switch args[0] {
+	case "status":
+		return func(client *ipmi.Client, w io.Writer) error {
+			status, err := client.GetChassisStatus()
+			if err != nil {
+				return err
+			}
+
+			printChassisStatus(w, status)
+			return nil
+		}, nil
+
+	case "identify":
+		fs := flag.NewFlagSet("chassis identify", flag.ExitOnError)
+		force := fs.Bool("force", false, "Turn identify indicator on indefinitely")
+		fs.Parse(args[1:])
+
+		seconds := uint64(15)
+		if fs.NArg() > 0 {
+			var err error
+			if seconds, err = strconv.ParseUint(fs.Arg(0), 10, 8); err != nil {
+				return nil, fmt.Errorf("invalid identify interval: %s", fs.Arg(0))
+			}
+		}
+
+		return func(client *ipmi.Client, w io.Writer) error {
+			if err := client.ChassisIdentify(uint8(seconds), *force); err != nil {
+				return err
+			}
+
+			switch {
+			case *force:
+				fmt.Fprintln(w, "Chassis identify indicator on indefinitely")
+			case seconds == 0:
+				fmt.Fprintln(w, "Chassis identify indicator off")
+			default:
+				fmt.Fprintf(w, "Chassis identify indicator on for %d seconds\n", seconds)
+			}
+
+			return nil
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown chassis command %q, expected status or identify", args[0])
+}
+
+// cmdBootdev overrides the boot device, or reports the current boot flags if no device is given
+func cmdBootdev(args []string) (hostAction, error) {
+	fs := flag.NewFlagSet("bootdev", flag.ExitOnError)
+	persistent := fs.Bool("persistent", false, "Apply to all future boots, rather than the next boot only")
+	efi := fs.Bool("efi", false, "Boot in EFI mode, rather than legacy mode")
+	clearCMOS := fs.Bool("clear-cmos", false, "Clear CMOS on next boot")
+	fs.Parse(args)
+
+	// Allow flags to follow the device name
+	var name string
+	if fs.NArg() > 0 {
+		name = fs.Arg(0)
+		fs.Parse(fs.Args()[1:])
+	}
+
+	device, ok := bootDevices[name]
+	if !ok && name != "" {
+		return nil, fmt.Errorf("unknown boot device %q, expected none, pxe, disk, safe, diag, cdrom, bios or floppy", name)
+	}
+
+	return func(client *ipmi.Client, w io.Writer) error {
+		if name == "" {
+			flags, err := client.GetBootFlags()
+			if err != nil {
+				return err
+			}
+
+			printBootFlags(w, flags)
+			return nil
+		}
+
+		flags := &ipmi.BootFlags{
+			Valid:      true,
+			Persistent: *persistent,
+			EFI:        *efi,
+			Device:     device,
+			ClearCMOS:  *clearCMOS,
+		}
+
+		if err := client.SetBootFlags(flags); err != nil {
+			return err
+		}
+
+		printBootFlags(w, flags)
+
+		return nil
+	}, nil
+}
+
+func printBootFlags(w io.Writer, f *ipmi.BootFlags) {
+	if !f.Valid || f.Device == ipmi.BootDeviceNone {
+		fmt.Fprintln(w, "No boot device override")
+		return
+	}
+
+	name := fmt.Sprintf("0x%02x", f.Device)
+	for n, d := range bootDevices {
+		if d == f.Device {
+			name = n
+		}
+	}
+
+	mode, scope := "legacy", "next boot only"
+	if f.EFI {
+		mode = "EFI"
+	}
+	if f.Persistent {
+		scope = "persistent"
+	}
+
+	fmt.Fprintf(w, "Boot device override: %s (%s, %s)\n", name, mode, scope)
+}
+
+func printChassisStatus(w io.Writer, s *ipmi.ChassisStatus) {
+	lastEvent := "none"
+	switch {
+	case s.LastPowerOnByCommand:
+		lastEvent = "command"
+	case s.LastPowerFault:
+		lastEvent = "fault"
+	case s.LastPowerInterlock:
+		lastEvent = "interlock"
+	case s.LastPowerOverload:
+		lastEvent = "overload"
+	case s.LastACFailed:
+		lastEvent = "ac-failed"
+	}
+
+	fmt.Fprintf(w, "%-21s: %s\n", "System Power", onOff(s.PowerOn))
+	fmt.Fprintf(w, "%-21s: %v\n", "Power Overload", s.PowerOverload)
+	fmt.Fprintf(w, "%-21s: %v\n", "Power Interlock", s.PowerInterlock)
+	fmt.Fprintf(w, "%-21s: %v\n", "Main Power Fault", s.PowerFault)
+	fmt.Fprintf(w, "%-21s: %v\n", "Power Control Fault", s.PowerControlFault)
+	fmt.Fprintf(w, "%-21s: %s\n", "Power Restore Policy", powerRestorePolicies[s.PowerRestorePolicy&0x03])
+	fmt.Fprintf(w, "%-21s: %s\n", "Last Power Event", lastEvent)
+	fmt.Fprintf(w, "%-21s: %v\n", "Chassis Intrusion", s.Intrusion)
+	fmt.Fprintf(w, "%-21s: %v\n", "Front-Panel Lockout", s.FrontPanelLockout)
+	fmt.Fprintf(w, "%-21s: %v\n", "Drive Fault", s.DriveFault)
+	fmt.Fprintf(w, "%-21s: %v\n", "Cooling/Fan Fault", s.CoolingFault)
+
+	if s.IdentifySupported {
+		fmt.Fprintf(w, "%-21s: %s\n", "Identify State", identifyStates[s.IdentifyState&0x03])
+	}
+
+	if s.FrontPanelButtons != nil {
+		fmt.Fprintf(w, "%-21s: 0x%02x\n", "Front Panel Buttons", *s.FrontPanelButtons)
+	}
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
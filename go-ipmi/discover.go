@@ -0,0 +1,217 @@
+package main
+
+// BMC discovery by RMCP Presence Ping
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+)
+
+// Largest range swept, to guard against typos such as /8
+const discoverMaxPrefix = 16
+
+// discovered is the result of probing a host. Hosts that do not answer the ping have a nil pong.
+type discovered struct {
+	host    string
+	pong    *ipmi.Pong
+	caps    *ipmi.AuthCapabilitiesResponse
+	capsErr error
+}
+
+// cmdDiscover pings the hosts of CIDR ranges concurrently, and reports the authentication
+// capabilities of those answering, flagging insecure settings
+func cmdDiscover(args []string) error {
+	fs := flag.NewFlagSet("discover", flag.ExitOnError)
+	port := fs.Int("port", 623, "RMCP port")
+	concurrency := fs.Int("concurrency", 64, "Maximum number of hosts probed concurrently")
+	probeTimeout := fs.Duration("probe-timeout", 500*time.Millisecond, "Time to wait for a Presence Pong, without retransmitting")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 || *concurrency < 1 || *probeTimeout <= 0 {
+		return fmt.Errorf("usage: discover [-port n] [-concurrency n] [-probe-timeout d] <CIDR range or host>...")
+	}
+
+	var hosts []string
+	for _, arg := range fs.Args() {
+		h, err := expandHosts(arg)
+		if err != nil {
+			return err
+		}
+		hosts = append(hosts, h...)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]discovered, len(hosts))
+		sem     = make(chan struct{}, *concurrency)
+	)
+
+	for i, host := range hosts {
+		results[i].host = net.JoinHostPort(host, strconv.Itoa(*port))
+
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(d *discovered) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			probe(d, *probeTimeout)
+		}(&results[i])
+	}
+
+	wg.Wait()
+
+	printDiscovered(results)
+
+	return nil
+}
+
+// expandHosts returns the host addresses of a CIDR range, excluding the network and broadcast
+// addresses, or arg itself if it is not a range
+func expandHosts(arg string) ([]string, error) {
+	if !strings.Contains(arg, "/") {
+		return []string{arg}, nil
+	}
+
+	_, ipnet, err := net.ParseCIDR(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	ones, bits := ipnet.Mask.Size()
+	if bits != 32 {
+		return nil, fmt.Errorf("%s: only IPv4 ranges are supported", arg)
+	} else if ones < discoverMaxPrefix {
+		return nil, fmt.Errorf("%s: range larger than /%d", arg, discoverMaxPrefix)
+	}
+
+	first := binary.BigEndian.Uint32(ipnet.IP.To4())
+	last := first | (1<<(bits-ones) - 1)
+	if ones < 31 {
+		first, last = first+1, last-1
+	}
+
+	hosts := make([]string, 0, last-first+1)
+	for n := uint64(first); n <= uint64(last); n++ {
+		hosts = append(hosts, net.IP(binary.BigEndian.AppendUint32(nil, uint32(n))).String())
+	}
+
+	return hosts, nil
+}
+
+// probe pings a host, and requests its channel authentication capabilities if it supports IPMI.
+// Most addresses of a range are silent, so the ping is sent once with a short timeout, and only
+// hosts answering it are queried with the -timeout and -retries of sessions.
+func probe(d *discovered, pingTimeout time.Duration) {
+	client, err := ipmi.Dial(d.host)
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	if err := client.SetTimeout(pingTimeout, 0); err != nil {
+		return
+	}
+
+	if d.pong, err = client.Ping(); err != nil || !d.pong.IPMI {
+		return
+	}
+
+	if d.capsErr = client.SetTimeout(*timeout, *retries); d.capsErr != nil {
+		return
+	}
+
+	d.caps, d.capsErr = client.GetChannelAuthCapabilities(ipmi.CurrentChannel, ipmi.PrivLevelAdmin)
+}
+
+func printDiscovered(results []discovered) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tASF\tIPMI\tAUTH TYPES\tLOGINS\tWARNINGS")
+
+	var found int
+
+	for _, d := range results {
+		if d.pong == nil {
+			continue
+		}
+		found++
+
+		asf := "-"
+		if d.pong.ASFVersion != 0 {
+			asf = fmt.Sprintf("%d.0", d.pong.ASFVersion)
+		}
+
+		switch {
+		case !d.pong.IPMI:
+			fmt.Fprintf(w, "%s\t%s\tno\t-\t-\t-\n", d.host, asf)
+		case d.capsErr != nil:
+			fmt.Fprintf(w, "%s\t%s\tyes\t-\t-\t%v\n", d.host, asf, d.capsErr)
+		default:
+			version, logins, warnings := describeAuthCaps(d.caps)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", d.host, asf, version, authTypeNames(d.caps.AuthTypes),
+				logins, warnings)
+		}
+	}
+
+	w.Flush()
+
+	fmt.Printf("\n%d of %d hosts responded\n", found, len(results))
+}
+
+// describeAuthCaps summarises channel authentication capabilities, returning the IPMI version, the
+// kinds of login enabled, and any insecure settings
+func describeAuthCaps(caps *ipmi.AuthCapabilitiesResponse) (string, string, string) {
+	version := "1.5"
+	if caps.ExtendedData && caps.ExtCapabilities&ipmi.ExtCapIPMIv20 != 0 {
+		version = "2.0"
+	}
+
+	var logins, warnings []string
+
+	if caps.AnonymousLogin {
+		logins = append(logins, "anonymous")
+		warnings = append(warnings, "anonymous login enabled")
+	}
+	if caps.NullUsers {
+		logins = append(logins, "null-user")
+		warnings = append(warnings, "null user enabled")
+	}
+	if caps.NonNullUsers {
+		logins = append(logins, "named")
+	}
+
+	if caps.AuthTypes&(1<<ipmi.AuthTypeNone) != 0 {
+		warnings = append(warnings, "auth type none enabled")
+	}
+	if caps.PerMsgAuthDisabled {
+		warnings = append(warnings, "per-message auth disabled")
+	}
+	if caps.UserAuthDisabled {
+		warnings = append(warnings, "user-level auth disabled")
+	}
+	if version == "1.5" {
+		warnings = append(warnings, "IPMI v1.5 only")
+	}
+
+	return version, orDash(strings.Join(logins, ",")), orDash(strings.Join(warnings, ", "))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
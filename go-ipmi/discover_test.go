@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+func TestExpandHosts(t *testing.T) {
+	tests := []struct {
+		arg   string
+		n     int      // Number of hosts
+		hosts []string // First and last hosts, if any
+		err   bool
+	}{
+		{"bmc01.example.com", 1, []string{"bmc01.example.com", "bmc01.example.com"}, false},
+		{"192.0.2.7", 1, []string{"192.0.2.7", "192.0.2.7"}, false},
+		{"192.0.2.0/24", 254, []string{"192.0.2.1", "192.0.2.254"}, false},
+		{"192.0.2.77/29", 6, []string{"192.0.2.73", "192.0.2.78"}, false},
+		{"192.0.2.6/31", 2, []string{"192.0.2.6", "192.0.2.7"}, false}, // Point-to-point, RFC 3021
+		{"192.0.2.7/32", 1, []string{"192.0.2.7", "192.0.2.7"}, false},
+		{"10.1.0.0/16", 65534, []string{"10.1.0.1", "10.1.255.254"}, false},
+		{"10.0.0.0/15", 0, nil, true},
+		{"0.0.0.0/0", 0, nil, true},
+		{"2001:db8::/120", 0, nil, true},
+		{"192.0.2.0/33", 0, nil, true},
+	}
+
+	for _, tt := range tests {
+		hosts, err := expandHosts(tt.arg)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: expected error, got %d hosts", tt.arg, len(hosts))
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: %v", tt.arg, err)
+			continue
+		}
+
+		if len(hosts) != tt.n {
+			t.Errorf("%s: expected %d hosts, got %d", tt.arg, tt.n, len(hosts))
+		} else if ends := []string{hosts[0], hosts[len(hosts)-1]}; !reflect.DeepEqual(ends, tt.hosts) {
+			t.Errorf("%s: expected hosts %v to %v, got %v to %v", tt.arg, tt.hosts[0], tt.hosts[1], ends[0], ends[1])
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	pc, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim := ipmisim.New(ipmisim.DefaultProfile())
+	go sim.Serve(pc)
+	defer sim.Close()
+
+	d := &discovered{host: pc.LocalAddr().String()}
+	probe(d, 100*time.Millisecond)
+
+	if d.pong == nil || !d.pong.IPMI || d.caps == nil || d.capsErr != nil {
+		t.Errorf("simulator: pong %+v, capabilities %+v, error %v", d.pong, d.caps, d.capsErr)
+	}
+
+	// Silent hosts are given up on after a single ping
+	silent, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+
+	start := time.Now()
+	d = &discovered{host: silent.LocalAddr().String()}
+	probe(d, 100*time.Millisecond)
+
+	if elapsed := time.Since(start); d.pong != nil || elapsed > 500*time.Millisecond {
+		t.Errorf("silent host: pong %+v after %v", d.pong, elapsed)
+	}
+}
@@ -0,0 +1,419 @@
+package main
+
+// Prometheus exporter mode, serving sensor values, sensor states, chassis power state and SEL
+// entry count of the BMCs listed in a JSON configuration file, e.g.:
+//
+//	{
+//	  "username": "monitor",
+//	  "password": "secret",
+//	  "priv": 2,
+//	  "targets": [
+//	    {"name": "node01", "host": "10.0.0.1:623"},
+//	    {"name": "node02", "host": "10.0.0.2:623", "username": "admin", "password": "other"}
+//	  ]
+//	}
+//
+// Sessions are kept open between scrapes, and are only re-established if a scrape fails.
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+)
+
+// Maximum number of sensors read concurrently per target
+const sensorConcurrency = 8
+
+// exporterConfig is the format of the exporter configuration file. Credentials of individual
+// targets default to those at the top level.
+type exporterConfig struct {
+	Username  string         `json:"username"`
+	Password  string         `json:"password"`
+	PrivLevel uint8          `json:"priv"`
+	Targets   []targetConfig `json:"targets"`
+}
+
+type targetConfig struct {
+	Name      string `json:"name"`
+	Host      string `json:"host"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	PrivLevel uint8  `json:"priv"`
+}
+
+// Metric families in the order that they are exposed
+var metricFamilies = []struct {
+	name, help string
+}{
+	{"ipmi_up", "Whether the BMC could be reached and queried successfully."},
+	{"ipmi_scrape_duration_seconds", "Time taken to query the BMC."},
+	{"ipmi_sensor_value", "Reading of an analog sensor, in the units given by the unit label."},
+	{"ipmi_sensor_state", "Threshold state of a sensor: 0 = ok, 1 = non-critical, 2 = critical, 3 = non-recoverable."},
+	{"ipmi_sensor_discrete_states", "Bitmask of asserted state offsets of a discrete sensor."},
+	{"ipmi_chassis_power_state", "Whether the chassis power is on."},
+	{"ipmi_sel_entries", "Number of entries in the System Event Log."},
+	{"ipmi_sel_free_bytes", "Free space in the System Event Log, in bytes."},
+}
+
+// sample is a single metric sample, with labels as name / value pairs
+type sample struct {
+	name   string
+	labels []string
+	value  float64
+}
+
+// target is a BMC being monitored, whose session and SDR records persist between scrapes
+type target struct {
+	targetConfig
+
+	mu      sync.Mutex
+	client  *ipmi.Client
+	sdr     []ipmi.SDRRecord
+	sdrInfo ipmi.SDRRepositoryInfo
+}
+
+func cmdExporter(args []string) error {
+	fs := flag.NewFlagSet("exporter", flag.ExitOnError)
+	configFile := fs.String("config", "", "Path to JSON file listing target BMCs")
+	listen := fs.String("listen", ":9290", "Address to listen on for HTTP requests")
+	fs.Parse(args)
+
+	if *configFile == "" {
+		return fmt.Errorf("no configuration file specified")
+	}
+
+	targets, err := loadTargets(*configFile)
+	if err != nil {
+		return err
+	}
+
+	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writeMetrics(w, scrapeTargets(targets))
+	})
+
+	log.Printf("Exporting metrics for %d targets on %s", len(targets), *listen)
+
+	return http.ListenAndServe(*listen, nil)
+}
+
+func loadTargets(path string) ([]*target, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var cfg exporterConfig
+	if err := json.NewDecoder(f).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	targets := make([]*target, 0, len(cfg.Targets))
+
+	for _, tc := range cfg.Targets {
+		if tc.Host == "" {
+			return nil, fmt.Errorf("target %q has no host", tc.Name)
+		}
+
+		if tc.Name == "" {
+			tc.Name = tc.Host
+		}
+
+		if tc.Username == "" && tc.Password == "" {
+			tc.Username, tc.Password = cfg.Username, cfg.Password
+		}
+
+		if tc.PrivLevel == 0 {
+			tc.PrivLevel = cfg.PrivLevel
+		}
+
+		if tc.PrivLevel == 0 {
+			tc.PrivLevel = ipmi.PrivLevelUser
+		}
+
+		targets = append(targets, &target{targetConfig: tc})
+	}
+
+	return targets, nil
+}
+
+// scrapeTargets queries all targets concurrently
+func scrapeTargets(targets []*target) []sample {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		samples []sample
+	)
+
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t *target) {
+			defer wg.Done()
+			s := t.scrape()
+			mu.Lock()
+			samples = append(samples, s...)
+			mu.Unlock()
+		}(t)
+	}
+
+	wg.Wait()
+
+	return samples
+}
+
+// scrape queries the target, reconnecting and retrying once if the existing session has failed,
+// e.g. due to having been closed by the BMC after a period of inactivity.
+func (t *target) scrape() []sample {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	start := time.Now()
+
+	samples, err := t.collect()
+	if err != nil && t.client != nil {
+		t.reset()
+		samples, err = t.collect()
+	}
+
+	up := 1.0
+	if err != nil {
+		log.Printf("%s: %v", t.Name, err)
+		t.reset()
+		samples, up = nil, 0
+	}
+
+	labels := []string{"target", t.Name}
+
+	return append(samples,
+		sample{"ipmi_up", labels, up},
+		sample{"ipmi_scrape_duration_seconds", labels, time.Since(start).Seconds()},
+	)
+}
+
+// reset closes the target's session, so that it is re-established on the next attempt
+func (t *target) reset() {
+	if t.client != nil {
+		t.client.Close()
+		t.client = nil
+	}
+}
+
+func (t *target) connect() error {
+	client, err := ipmi.Dial(t.Host)
+	if err != nil {
+		return err
+	}
+
+	if err := client.SetTimeout(*timeout, *retries); err != nil {
+		client.Close()
+		return err
+	}
+
+	if err := client.OpenSession(t.Username, t.Password, t.PrivLevel); err != nil {
+		client.Close()
+		return err
+	}
+
+	t.client = client
+
+	return nil
+}
+
+func (t *target) collect() ([]sample, error) {
+	if t.client == nil {
+		if err := t.connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	// SDR records are only re-read if the repository has changed
+	info, err := t.client.GetSDRRepositoryInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	if t.sdr == nil || info.LastAddition != t.sdrInfo.LastAddition || info.LastErase != t.sdrInfo.LastErase {
+		if t.sdr, err = t.client.ReadSDRRepository(); err != nil {
+			t.sdr = nil
+			return nil, err
+		}
+		t.sdrInfo = *info
+	}
+
+	samples, err := t.collectSensors()
+	if err != nil {
+		return nil, err
+	}
+
+	labels := []string{"target", t.Name}
+
+	status, err := t.client.GetChassisStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	samples = append(samples, sample{"ipmi_chassis_power_state", labels, boolValue(status.PowerOn)})
+
+	sel, err := t.client.GetSELInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	samples = append(samples,
+		sample{"ipmi_sel_entries", labels, float64(sel.Entries)},
+		sample{"ipmi_sel_free_bytes", labels, float64(sel.FreeSpace)},
+	)
+
+	return samples, nil
+}
+
+// collectSensors reads all sensors concurrently over the target's session. Sensors that the BMC
+// reports as not present, or whose scanning is disabled, are skipped, as are sensors owned by
+// system software, which cannot be read. Any other error fails the scrape.
+func (t *target) collectSensors() ([]sample, error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		samples  []sample
+		firstErr error
+		sem      = make(chan struct{}, sensorConcurrency)
+	)
+
+	for _, rec := range t.sdr {
+		var key ipmi.SensorKey
+
+		switch r := rec.(type) {
+		case *ipmi.FullSensorRecord:
+			key = r.SensorKey
+		case *ipmi.CompactSensorRecord:
+			key = r.SensorKey
+		default:
+			continue
+		}
+
+		if key.OwnerID&0x01 != 0 {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(rec ipmi.SDRRecord, key ipmi.SensorKey) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			v, err := t.client.ReadSensor(rec)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if errors.Is(err, ipmi.ErrNotPresent) {
+				return
+			} else if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("read sensor 0x%02x: %w", key.SensorNumber, err)
+				}
+				return
+			}
+
+			samples = append(samples, sensorSamples(t.Name, v)...)
+		}(rec, key)
+	}
+
+	wg.Wait()
+
+	return samples, firstErr
+}
+
+func sensorSamples(target string, v *ipmi.SensorValue) []sample {
+	if v.Reading.Unavailable || v.Reading.ScanningDisabled {
+		return nil
+	}
+
+	labels := []string{
+		"target", target,
+		"id", strconv.Itoa(int(v.SensorNumber)),
+		"owner", fmt.Sprintf("0x%02x", v.OwnerID),
+		"lun", strconv.Itoa(int(v.OwnerLUN)),
+		"name", v.Name,
+		"type", ipmi.SensorTypeName(v.SensorType),
+	}
+
+	var samples []sample
+
+	if v.Analog {
+		samples = append(samples, sample{"ipmi_sensor_value", append(labels, "unit", v.Units), v.Value})
+	}
+
+	if v.Threshold() {
+		severity := map[string]float64{"ok": 0, "nc": 1, "cr": 2, "nr": 3}[v.ThresholdStatus()]
+		samples = append(samples, sample{"ipmi_sensor_state", labels, severity})
+	} else {
+		samples = append(samples, sample{"ipmi_sensor_discrete_states", labels, float64(v.Reading.States)})
+	}
+
+	return samples
+}
+
+// writeMetrics writes samples in the Prometheus text exposition format
+func writeMetrics(w io.Writer, samples []sample) {
+	byName := make(map[string][]sample)
+	for _, s := range samples {
+		byName[s.name] = append(byName[s.name], s)
+	}
+
+	for _, f := range metricFamilies {
+		family := byName[f.name]
+		if len(family) == 0 {
+			continue
+		}
+
+		// Order samples by label values, for stable output
+		sort.SliceStable(family, func(i, j int) bool {
+			return strings.Join(family[i].labels, "\x00") < strings.Join(family[j].labels, "\x00")
+		})
+
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", f.name, f.help, f.name)
+
+		for _, s := range family {
+			fmt.Fprintf(w, "%s%s %s\n", s.name, formatLabels(s.labels), strconv.FormatFloat(s.value, 'g', -1, 64))
+		}
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1])))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	samples := []sample{
+		{"ipmi_up", []string{"target", "node02"}, 0},
+		{"ipmi_sensor_value", []string{"target", "node01", "name", `CPU "Temp"`, "unit", `degrees\C`}, 45},
+		{"ipmi_up", []string{"target", "node01"}, 1},
+		{"ipmi_sensor_value", []string{"target", "node01", "name", "Fan\n1", "unit", "RPM"}, 4200.5},
+		{"ipmi_sel_entries", []string{"target", "node01"}, 12},
+	}
+
+	var buf bytes.Buffer
+	writeMetrics(&buf, samples)
+
+	want := `# HELP ipmi_up Whether the BMC could be reached and queried successfully.
+# TYPE ipmi_up gauge
+ipmi_up{target="node01"} 1
+ipmi_up{target="node02"} 0
+# HELP ipmi_sensor_value Reading of an analog sensor, in the units given by the unit label.
+# TYPE ipmi_sensor_value gauge
+ipmi_sensor_value{target="node01",name="CPU \"Temp\"",unit="degrees\\C"} 45
+ipmi_sensor_value{target="node01",name="Fan\n1",unit="RPM"} 4200.5
+# HELP ipmi_sel_entries Number of entries in the System Event Log.
+# TYPE ipmi_sel_entries gauge
+ipmi_sel_entries{target="node01"} 12
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected metrics:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Each family has a single HELP and TYPE line, regardless of the number of samples or targets
+	for _, f := range metricFamilies {
+		for _, prefix := range []string{"# HELP ", "# TYPE "} {
+			if n := strings.Count(buf.String(), prefix+f.name+" "); n > 1 {
+				t.Errorf("%s%s emitted %d times", prefix, f.name, n)
+			}
+		}
+	}
+}
+
+func TestSensorSamples(t *testing.T) {
+	v := &ipmi.SensorValue{
+		SensorKey:        ipmi.SensorKey{OwnerID: 0x2c, OwnerLUN: 0x01, SensorNumber: 0x30},
+		Name:             "Inlet Temp",
+		SensorType:       0x01,
+		EventReadingType: ipmi.EventReadingTypeThreshold,
+		Reading:          &ipmi.SensorReading{States: ipmi.ThresholdUpperNonCritical},
+		Analog:           true,
+		Value:            41,
+		Units:            "degrees C",
+	}
+
+	labels := `{target="node01",id="48",owner="0x2c",lun="1",name="Inlet Temp",type="Temperature"`
+
+	var buf bytes.Buffer
+	writeMetrics(&buf, sensorSamples("node01", v))
+
+	for _, line := range []string{
+		"ipmi_sensor_value" + labels + `,unit="degrees C"} 41`,
+		"ipmi_sensor_state" + labels + "} 1",
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("missing sample %s in:\n%s", line, buf.String())
+		}
+	}
+}
+
+// simTransport answers requests in-process with a simulated BMC's system interface, overriding the
+// SDR repository timestamps and counting Get SDR requests
+type simTransport struct {
+	sim        *ipmisim.Simulator
+	sensorCode ipmi.CompletionCode // Completion code of Get Sensor Reading, if not zero
+
+	mu                      sync.Mutex
+	lastAddition, lastErase uint32
+	getSDR                  int
+}
+
+func (s *simTransport) SendRecv(req ipmi.Request) ([]byte, error) {
+	resp := s.sim.HandleSystemInterface(req.NetworkFunction, req.Command, req.Data)
+	if s.sensorCode != 0 && req.NetworkFunction == ipmi.NetFnSensorEvent && req.Command == ipmi.CmdGetSensorReading {
+		resp = []byte{uint8(s.sensorCode)}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.NetworkFunction == ipmi.NetFnStorage {
+		switch req.Command {
+		case ipmi.CmdGetSDR:
+			s.getSDR++
+		case ipmi.CmdGetSDRRepositoryInfo:
+			if len(resp) >= 14 {
+				binary.LittleEndian.PutUint32(resp[6:], s.lastAddition)
+				binary.LittleEndian.PutUint32(resp[10:], s.lastErase)
+			}
+		}
+	}
+
+	if resp[0] != uint8(ipmi.CommandCompleted) {
+		return nil, &ipmi.CommandError{
+			NetFn:   req.NetworkFunction,
+			Command: req.Command,
+			Code:    ipmi.CompletionCode(resp[0]),
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *simTransport) SetTimeout(time.Duration, int) error { return nil }
+
+func (s *simTransport) Close() error { return nil }
+
+func TestExporterSDRCache(t *testing.T) {
+	st := &simTransport{sim: ipmisim.New(ipmisim.DefaultProfile()), lastAddition: 1000, lastErase: 500}
+	tgt := &target{targetConfig: targetConfig{Name: "sim"}, client: ipmi.NewClient(st)}
+
+	tests := []struct {
+		name         string
+		update       func()
+		expectReread bool
+	}{
+		{"initial scrape", func() {}, true},
+		{"unchanged repository", func() {}, false},
+		{"record added", func() { st.lastAddition++ }, true},
+		{"repository erased", func() { st.lastErase++ }, true},
+		{"unchanged after erase", func() {}, false},
+	}
+
+	for _, tt := range tests {
+		st.mu.Lock()
+		tt.update()
+		st.getSDR = 0
+		st.mu.Unlock()
+
+		samples := tgt.scrape()
+
+		if up := findSample(samples, "ipmi_up"); up == nil || up.value != 1 {
+			t.Fatalf("%s: target not up", tt.name)
+		}
+
+		if findSample(samples, "ipmi_sensor_value") == nil {
+			t.Errorf("%s: no sensor values", tt.name)
+		}
+
+		st.mu.Lock()
+		reread := st.getSDR > 0
+		st.mu.Unlock()
+
+		if reread != tt.expectReread {
+			t.Errorf("%s: SDR repository re-read %v, expected %v", tt.name, reread, tt.expectReread)
+		}
+	}
+}
+
+func TestExporterSensorErrors(t *testing.T) {
+	tests := []struct {
+		code    ipmi.CompletionCode
+		up      float64
+		sensors bool
+	}{
+		{ipmi.CommandCompleted, 1, true},
+		{ipmi.ErrNotPresent, 1, false},
+		{ipmi.ErrInsufficientPriv, 0, false},
+		{ipmi.ErrNodeBusy, 0, false},
+	}
+
+	for _, tt := range tests {
+		st := &simTransport{sim: ipmisim.New(ipmisim.DefaultProfile()), sensorCode: tt.code}
+		tgt := &target{targetConfig: targetConfig{Name: "sim", Host: "127.0.0.1:0"}, client: ipmi.NewClient(st)}
+
+		samples := tgt.scrape()
+
+		if up := findSample(samples, "ipmi_up"); up == nil || up.value != tt.up {
+			t.Errorf("%v: expected ipmi_up %v, got %+v", tt.code, tt.up, up)
+		}
+
+		if sensors := findSample(samples, "ipmi_sensor_value") != nil; sensors != tt.sensors {
+			t.Errorf("%v: sensor values %v, expected %v", tt.code, sensors, tt.sensors)
+		}
+	}
+}
+
+func findSample(samples []sample, name string) *sample {
+	for i := range samples {
+		if samples[i].name == name {
+			return &samples[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+// FRU inventory subcommand
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+)
+
+// BMC slave address, shifted as in FRU device locator records
+const bmcAccessAddress = 0x20 >> 1
+
+// fruDevice is a logical FRU device on the BMC
+type fruDevice struct {
+	ID   uint8
+	Name string
+	FRU  *ipmi.FRU `json:",omitempty"`
+	Err  string    `json:",omitempty"`
+}
+
+// cmdFRU prints the FRU inventory of the BMC's own FRU device and the logical FRU devices in the
+// SDR repository, or of a single FRU device
+func cmdFRU(args []string) (hostAction, error) {
+	fs := flag.NewFlagSet("fru", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print FRU inventory as JSON")
+	fs.Parse(args)
+
+	var id uint64
+	if fs.NArg() > 0 {
+		var err error
+		if id, err = strconv.ParseUint(fs.Arg(0), 0, 8); err != nil {
+			return nil, fmt.Errorf("invalid FRU device ID: %s", fs.Arg(0))
+		}
+	}
+
+	return func(client *ipmi.Client, w io.Writer) error {
+		return readFRUDevices(client, w, uint8(id), fs.NArg() == 0, *asJSON)
+	}, nil
+}
+
+// readFRUDevices prints the FRU inventory of FRU device id, and if all is set, of the logical FRU
+// devices in the SDR repository
+func readFRUDevices(client *ipmi.Client, w io.Writer, id uint8, all, asJSON bool) error {
+	devices := []*fruDevice{{ID: 0, Name: "Builtin FRU Device"}}
+	if id != 0 {
+		devices = []*fruDevice{{ID: id, Name: fmt.Sprintf("FRU Device %d", id)}}
+	}
+
+	if all {
+		records, err := client.ReadSDRRepository()
+		if err != nil {
+			return err
+		}
+
+		// Only logical FRU devices on the BMC can be read without bridging
+		for _, rec := range records {
+			if r, ok := rec.(*ipmi.FRUDeviceLocatorRecord); ok && r.Logical && r.FRUDeviceID != 0 &&
+				r.AccessAddress == bmcAccessAddress && r.Channel == 0 {
+				devices = append(devices, &fruDevice{ID: r.FRUDeviceID, Name: r.IDString})
+			}
+		}
+	}
+
+	// Errors are reported per device, unless there is only one
+	for _, dev := range devices {
+		var err error
+		if dev.FRU, err = client.ReadFRU(dev.ID); err != nil {
+			if len(devices) == 1 {
+				return fmt.Errorf("read FRU device %d: %w", dev.ID, err)
+			}
+			dev.Err = err.Error()
+		}
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(devices)
+	}
+
+	for i, dev := range devices {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		printFRU(w, dev)
+	}
+
+	return nil
+}
+
+// printFRU prints the populated fields of a FRU device's info areas
+func printFRU(w io.Writer, dev *fruDevice) {
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(w, " %-22s: %s\n", name, value)
+		}
+	}
+
+	fmt.Fprintf(w, "FRU Device Description : %s (ID %d)\n", dev.Name, dev.ID)
+
+	if dev.Err != "" {
+		field("Error", dev.Err)
+		return
+	}
+
+	if c := dev.FRU.Chassis; c != nil {
+		field("Chassis Type", ipmi.FRUChassisTypeName(c.Type))
+		field("Chassis Part Number", c.PartNumber)
+		field("Chassis Serial", c.SerialNumber)
+		for _, s := range c.Custom {
+			field("Chassis Extra", s)
+		}
+	}
+
+	if b := dev.FRU.Board; b != nil {
+		if !b.MfgDate.IsZero() {
+			field("Board Mfg Date", b.MfgDate.Format("2006-01-02 15:04 MST"))
+		}
+		field("Board Mfg", b.Manufacturer)
+		field("Board Product", b.ProductName)
+		field("Board Serial", b.SerialNumber)
+		field("Board Part Number", b.PartNumber)
+		for _, s := range b.Custom {
+			field("Board Extra", s)
+		}
+	}
+
+	if p := dev.FRU.Product; p != nil {
+		field("Product Manufacturer", p.Manufacturer)
+		field("Product Name", p.Name)
+		field("Product Part Number", p.PartNumber)
+		field("Product Version", p.Version)
+		field("Product Serial", p.SerialNumber)
+		field("Product Asset Tag", p.AssetTag)
+		for _, s := range p.Custom {
+			field("Product Extra", s)
+		}
+	}
+
+	for _, ps := range dev.FRU.PowerSupplies {
+		field("Power Supply Capacity", fmt.Sprintf("%d W", ps.Capacity))
+		field("Power Supply Peak", fmt.Sprintf("%d W, hold-up %d s", ps.PeakCapacity, ps.HoldUpTime))
+		field("Power Supply Input", fmt.Sprintf("%.2f - %.2f V, %d - %d Hz", ps.InputVoltage[0][0],
+			ps.InputVoltage[0][1], ps.InputFrequency[0], ps.InputFrequency[1]))
+		field("Power Supply Hot Swap", onOff(ps.HotSwap))
+	}
+
+	for _, out := range dev.FRU.DCOutputs {
+		field(fmt.Sprintf("DC Output %d", out.Output), fmt.Sprintf("%.2f V (%+.2f / %+.2f V), %.3f - %.3f A",
+			out.Nominal, out.MaxNegative, out.MaxPositive, out.MinCurrent, out.MaxCurrent))
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+// BMC device information subcommand
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+)
+
+// deviceInfo is the BMC identity and capabilities, as printed by the info subcommand
+type deviceInfo struct {
+	Host             string `json:",omitempty"` // Host of the -host flag, if specified
+	DeviceID         uint8
+	DeviceRevision   uint8
+	FirmwareRevision string
+	AuxFirmware      string `json:",omitempty"`
+	IPMIVersion      string
+	ManufacturerID   uint32
+	Manufacturer     string
+	ProductID        uint16
+	UpdateInProgress bool
+	ProvidesSDRs     bool
+	SupportedDevices []string
+	SelfTest         string
+	DeviceGUID       string `json:",omitempty"`
+	SystemGUID       string `json:",omitempty"`
+}
+
+// cmdInfo prints the BMC's device ID, firmware and IPMI versions, capabilities, self test results
+// and GUIDs
+func cmdInfo(args []string) (hostAction, error) {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print device information as JSON")
+	fs.Parse(args)
+
+	return func(client *ipmi.Client, w io.Writer) error {
+		return printDeviceInfo(client, w, *asJSON)
+	}, nil
+}
+
+// printDeviceInfo queries and prints the BMC's device information
+func printDeviceInfo(client *ipmi.Client, w io.Writer, asJSON bool) error {
+	dev, err := client.GetDeviceID()
+	if err != nil {
+		return err
+	}
+
+	selfTest, err := client.GetSelfTestResults()
+	if err != nil {
+		return err
+	}
+
+	info := &deviceInfo{
+		Host:             *host,
+		DeviceID:         dev.DeviceID,
+		DeviceRevision:   dev.DeviceRevision,
+		FirmwareRevision: dev.FirmwareRevision(),
+		IPMIVersion:      fmt.Sprintf("%x.%x", dev.IPMIVersion>>4, dev.IPMIVersion&0x0f),
+		ManufacturerID:   dev.ManufacturerID,
+		Manufacturer:     dev.Manufacturer(),
+		ProductID:        dev.ProductID,
+		UpdateInProgress: dev.UpdateInProgress,
+		ProvidesSDRs:     dev.ProvidesSDRs,
+		SupportedDevices: dev.SupportedDevices(),
+		SelfTest:         selfTest.String(),
+	}
+
+	if len(dev.AuxFirmware) > 0 {
+		info.AuxFirmware = fmt.Sprintf("% x", dev.AuxFirmware)
+	}
+
+	// GUIDs are optional, and not implemented by all BMCs
+	if guid, err := client.GetDeviceGUID(); err == nil {
+		info.DeviceGUID = guid.String()
+	}
+	if guid, err := client.GetSystemGUID(); err == nil {
+		info.SystemGUID = guid.String()
+	}
+
+	if asJSON {
+		return json.NewEncoder(w).Encode(info)
+	}
+
+	field := func(name string, value any) {
+		fmt.Fprintf(w, "%-26s: %v\n", name, value)
+	}
+
+	field("Device ID", info.DeviceID)
+	field("Device Revision", info.DeviceRevision)
+	field("Firmware Revision", info.FirmwareRevision)
+	if info.AuxFirmware != "" {
+		field("Aux Firmware Revision", info.AuxFirmware)
+	}
+	field("IPMI Version", info.IPMIVersion)
+	field("Manufacturer ID", info.ManufacturerID)
+	field("Manufacturer Name", info.Manufacturer)
+	field("Product ID", fmt.Sprintf("%d (%#04x)", info.ProductID, info.ProductID))
+	field("Device Available", yesNo(!info.UpdateInProgress))
+	field("Provides Device SDRs", yesNo(info.ProvidesSDRs))
+	field("Additional Device Support", strings.Join(info.SupportedDevices, ", "))
+	field("Self Test", info.SelfTest)
+	if info.DeviceGUID != "" {
+		field("Device GUID", info.DeviceGUID)
+	}
+	if info.SystemGUID != "" {
+		field("System GUID", info.SystemGUID)
+	}
+
+	return nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,214 @@
+package main
+
+// Running a command across an inventory of hosts in parallel
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+)
+
+// Environment variable holding the password, if -password is not specified, so as not to expose it
+// in process listings
+const passwordEnv = "IPMI_PASSWORD"
+
+// inventoryHost is a host of the inventory, with its credentials
+type inventoryHost struct {
+	host     string
+	username string
+	password string
+}
+
+// hostResult is the outcome of running a command on a host, printed as a JSON line
+type hostResult struct {
+	Host           string    `json:"host"`
+	Command        string    `json:"command"`
+	OK             bool      `json:"ok"`
+	Output         string    `json:"output,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	CompletionCode *uint8    `json:"completion_code,omitempty"` // Of a command rejected by the BMC
+	Start          time.Time `json:"start"`
+	Duration       float64   `json:"duration_seconds"`
+}
+
+// runInventory runs a command on each host of the inventory, at most -parallel at a time, printing
+// the result of each as a JSON line as it completes. The command's arguments are parsed once, and
+// its action run against each host with the host's credentials and the global flag settings.
+func runInventory(name string, args []string) error {
+	cmd, ok := hostCommands[name]
+	if !ok {
+		return fmt.Errorf("command %s cannot be run on an inventory", name)
+	} else if *device != "" || *host != "" {
+		return fmt.Errorf("-inventory cannot be combined with -host or -device")
+	} else if *parallel < 1 {
+		return fmt.Errorf("-parallel must be at least 1")
+	} else if err := checkTarget(); err != nil {
+		return err
+	}
+
+	action, err := cmd(args)
+	if err != nil {
+		return err
+	}
+
+	r := os.Stdin
+	if *inventory != "-" {
+		f, err := os.Open(*inventory)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	hosts, err := readInventory(r)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		failed  int
+		command = strings.Join(append([]string{name}, args...), " ")
+		enc     = json.NewEncoder(os.Stdout)
+		sem     = make(chan struct{}, *parallel)
+	)
+
+	for _, h := range hosts {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(h inventoryHost) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			res := runHost(action, h)
+			res.Command = command
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if !res.OK {
+				failed++
+			}
+			enc.Encode(res)
+		}(h)
+	}
+
+	wg.Wait()
+
+	if failed > 0 {
+		return fmt.Errorf("command failed on %d of %d hosts", failed, len(hosts))
+	}
+
+	return nil
+}
+
+// runHost connects to a single host and runs action, returning its output and error
+func runHost(action hostAction, h inventoryHost) hostResult {
+	var out bytes.Buffer
+
+	res := hostResult{Host: h.host, Start: time.Now()}
+	err := func() error {
+		client, err := connectHost(h.host, h.username, h.password)
+		if err != nil {
+			return fmt.Errorf("connect: %w", err)
+		}
+		defer client.Close()
+
+		return action(client, &out)
+	}()
+	res.Duration = time.Since(res.Start).Seconds()
+	res.Output = out.String()
+
+	if err != nil {
+		res.Error = err.Error()
+
+		var cmdErr *ipmi.CommandError
+		if errors.As(err, &cmdErr) {
+			code := uint8(cmdErr.Code)
+			res.CompletionCode = &code
+		}
+	} else {
+		res.OK = true
+	}
+
+	return res
+}
+
+// readInventory parses an inventory of one host per line, each optionally followed by a username
+// and password, which default to the -user and -password flags. A password of env:NAME is read
+// from the environment variable NAME, and file:PATH from the first line of a file. Blank lines and
+// lines starting with # are ignored.
+func readInventory(r io.Reader) ([]inventoryHost, error) {
+	var hosts []inventoryHost
+
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		} else if len(fields) > 3 {
+			return nil, fmt.Errorf("inventory line %d: expected host [user [password]]", line)
+		}
+
+		h := inventoryHost{host: fields[0], username: *username, password: *password}
+
+		if len(fields) > 1 {
+			h.username = fields[1]
+		}
+
+		if len(fields) > 2 {
+			pw, err := resolvePassword(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("inventory line %d: %w", line, err)
+			}
+			h.password = pw
+		}
+
+		hosts = append(hosts, h)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(hosts) == 0 {
+		return nil, errors.New("inventory lists no hosts")
+	}
+
+	return hosts, nil
+}
+
+// resolvePassword returns an inventory password, reading env:NAME and file:PATH references
+func resolvePassword(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, "env:"):
+		pw, ok := os.LookupEnv(s[4:])
+		if !ok {
+			return "", fmt.Errorf("environment variable %s not set", s[4:])
+		}
+		return pw, nil
+	case strings.HasPrefix(s, "file:"):
+		b, err := os.ReadFile(s[5:])
+		if err != nil {
+			return "", err
+		}
+		pw, _, _ := strings.Cut(string(b), "\n")
+		return strings.TrimSuffix(pw, "\r"), nil
+	}
+
+	return s, nil
+}
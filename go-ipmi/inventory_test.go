@@ -0,0 +1,154 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+func TestReadInventory(t *testing.T) {
+	defer func(u, p string) { *username, *password = u, p }(*username, *password)
+	*username, *password = "admin", "flagpw"
+
+	tests := []struct {
+		input string
+		hosts []inventoryHost
+		err   string
+	}{
+		{
+			"bmc01\n\n# comment\n  bmc02  operator\nbmc03 root secret\n",
+			[]inventoryHost{
+				{"bmc01", "admin", "flagpw"},
+				{"bmc02", "operator", "flagpw"},
+				{"bmc03", "root", "secret"},
+			},
+			"",
+		},
+		{"bmc01 root\r\n192.0.2.7:1623\tuser pw\r\n", []inventoryHost{
+			{"bmc01", "root", "flagpw"},
+			{"192.0.2.7:1623", "user", "pw"},
+		}, ""},
+		{"bmc01\nbmc02 root secret extra\n", nil, "inventory line 2"},
+		{"bmc01 root env:GO_IPMI_TEST_UNSET\n", nil, "GO_IPMI_TEST_UNSET not set"},
+		{"# no hosts\n\n", nil, "no hosts"},
+		{"", nil, "no hosts"},
+	}
+
+	for _, tt := range tests {
+		hosts, err := readInventory(strings.NewReader(tt.input))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: expected error %q, got %v", tt.input, tt.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%q: %v", tt.input, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(hosts, tt.hosts) {
+			t.Errorf("%q: expected %+v, got %+v", tt.input, tt.hosts, hosts)
+		}
+	}
+}
+
+func TestResolvePassword(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"pw":     "filepw\nignored\n",
+		"crlf":   "crlfpw\r\n",
+		"nonl":   "nonlpw",
+		"empty":  "",
+		"spaces": " spaced pw \n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("GO_IPMI_TEST_PASSWORD", "envpw")
+	t.Setenv("GO_IPMI_TEST_EMPTY", "")
+
+	tests := []struct {
+		arg  string
+		want string
+		err  bool
+	}{
+		{"secret", "secret", false},
+		{"", "", false},
+		{"ENV:GO_IPMI_TEST_PASSWORD", "ENV:GO_IPMI_TEST_PASSWORD", false}, // Prefixes are case sensitive
+		{"env:GO_IPMI_TEST_PASSWORD", "envpw", false},
+		{"env:GO_IPMI_TEST_EMPTY", "", false},
+		{"env:GO_IPMI_TEST_UNSET", "", true},
+		{"file:" + filepath.Join(dir, "pw"), "filepw", false},
+		{"file:" + filepath.Join(dir, "crlf"), "crlfpw", false},
+		{"file:" + filepath.Join(dir, "nonl"), "nonlpw", false},
+		{"file:" + filepath.Join(dir, "empty"), "", false},
+		{"file:" + filepath.Join(dir, "spaces"), " spaced pw ", false},
+		{"file:" + filepath.Join(dir, "missing"), "", true},
+	}
+
+	for _, tt := range tests {
+		pw, err := resolvePassword(tt.arg)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: expected error, got %q", tt.arg, pw)
+			}
+		} else if err != nil || pw != tt.want {
+			t.Errorf("%s: expected %q, got %q, error %v", tt.arg, tt.want, pw, err)
+		}
+	}
+}
+
+func TestRunHost(t *testing.T) {
+	pc, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim := ipmisim.New(ipmisim.DefaultProfile())
+	go sim.Serve(pc)
+	defer sim.Close()
+
+	defer func(p uint) { *priv = p }(*priv)
+	*priv = ipmi.PrivLevelUser
+
+	addr := pc.LocalAddr().String()
+	tests := []struct {
+		args []string
+		h    inventoryHost
+		ok   bool
+		out  string
+		code ipmi.CompletionCode // Of the error, or CommandCompleted if none
+	}{
+		{[]string{"status"}, inventoryHost{addr, "user", "user"}, true, "Chassis power is on\n", ipmi.CommandCompleted},
+		{[]string{"status"}, inventoryHost{addr, "user", "wrong"}, false, "", ipmi.CommandCompleted},
+		{[]string{"off"}, inventoryHost{addr, "user", "user"}, false, "", ipmi.ErrInsufficientPriv},
+	}
+
+	for _, tt := range tests {
+		action, err := cmdPower(tt.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res := runHost(action, tt.h)
+		if res.OK != tt.ok || res.Output != tt.out || res.Host != addr || (res.Error == "") != tt.ok {
+			t.Errorf("%v as %s: expected ok %v output %q, got %+v", tt.args, tt.h.password, tt.ok, tt.out, res)
+		}
+
+		code := ipmi.CommandCompleted
+		if res.CompletionCode != nil {
+			code = ipmi.CompletionCode(*res.CompletionCode)
+		}
+		if code != tt.code {
+			t.Errorf("%v as %s: expected completion code %v, got %v", tt.args, tt.h.password, tt.code, code)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package ipmi
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrAuthCode = errors.New("invalid auth code in response")
+
+// AuthCode calculates the 16-byte auth code for an IPMI v1.5 session message per section 22.17.1.
+// msg is the IPMI message, starting from the responder address up to and including the final
+// checksum.
+func AuthCode(authType uint8, key []byte, sessionID, sequence uint32, msg []byte) [16]byte {
+	// IPMI v1.5 passwords are limited to 16 bytes
+	var password [16]byte
+	copy(password[:], key)
+
+	switch authType {
+	case AuthTypePassword:
+		return password
+	case AuthTypeMD2, AuthTypeMD5:
+		buf := new(bytes.Buffer)
+		buf.Write(password[:])
+		binary.Write(buf, binary.LittleEndian, sessionID)
+		buf.Write(msg)
+		binary.Write(buf, binary.LittleEndian, sequence)
+		buf.Write(password[:])
+
+		if authType == AuthTypeMD2 {
+			return md2Sum(buf.Bytes())
+		}
+		return md5.Sum(buf.Bytes())
+	}
+
+	return [16]byte{}
+}
+
+// verifyAuthCode checks the auth code of a message received from the BMC
+func (f ipmiV15Format) verifyAuthCode(m *message) error {
+	if m.SessionHeader.AuthType == AuthTypeNone {
+		// BMC may omit auth code on session messages only if per-message authentication is disabled
+		if f.l.sequence != 0 && f.l.authType != AuthTypeNone && !f.l.perMsgAuthDisabled {
+			return ErrAuthCode
+		}
+		return nil
+	}
+
+	if m.SessionHeader.AuthType != f.l.authType {
+		return ErrAuthCode
+	}
+
+	expected := AuthCode(m.SessionHeader.AuthType, f.l.password[:], m.SessionID, m.Sequence, m.payload)
+	if subtle.ConstantTimeCompare(expected[:], m.authCode[:]) != 1 {
+		return ErrAuthCode
+	}
+
+	return nil
+}
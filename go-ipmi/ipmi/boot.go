@@ -0,0 +1,235 @@
+package ipmi
+
+// System boot options per section 28.12 and 28.13
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Boot option parameter selectors per table 28-14
+const (
+	BootParamSetInProgress = 0x00
+	BootParamInfoAck       = 0x04
+	BootParamFlags         = 0x05
+)
+
+// Set In Progress parameter values
+const (
+	bootSetComplete   = 0x00
+	bootSetInProgress = 0x01
+)
+
+// Completion codes specific to Get and Set System Boot Options
+var (
+	ErrBootParamNotSupported = commandCode(0x80, NetFnChassis, CmdSetSystemBootOptions, CmdGetSystemBootOptions)
+	ErrBootSetInProgress     = commandCode(0x81, NetFnChassis, CmdSetSystemBootOptions)
+	ErrBootParamReadOnly     = commandCode(0x82, NetFnChassis, CmdSetSystemBootOptions)
+)
+
+// Boot device selectors in boot flags
+const (
+	BootDeviceNone         = 0x00 // No override
+	BootDevicePXE          = 0x01
+	BootDeviceDisk         = 0x02
+	BootDeviceDiskSafe     = 0x03 // Default hard drive, request safe mode
+	BootDeviceDiag         = 0x04 // Default diagnostic partition
+	BootDeviceCD           = 0x05
+	BootDeviceBIOS         = 0x06 // BIOS setup
+	BootDeviceRemoteFloppy = 0x07 // Remotely connected floppy or primary removable media
+	BootDeviceRemoteCD     = 0x08
+	BootDeviceRemoteMedia  = 0x09 // Primary remote media
+	BootDeviceRemoteDisk   = 0x0b
+	BootDeviceFloppy       = 0x0f // Floppy or primary removable media
+)
+
+// BootFlags is the boot flags parameter per table 28-14, parameter 5
+type BootFlags struct {
+	Valid              bool // Flags apply to the next boot
+	Persistent         bool // Flags apply to all future boots, rather than the next boot only
+	EFI                bool // Boot in EFI mode, rather than legacy PC compatible mode
+	Device             uint8
+	ClearCMOS          bool
+	LockKeyboard       bool
+	ScreenBlank        bool
+	LockResetButton    bool
+	LockPowerButton    bool
+	Verbosity          uint8 // Firmware verbosity, 0 = default, 1 = quiet, 2 = verbose
+	ProgressTraps      bool  // Force progress event traps
+	PasswordBypass     bool  // Bypass user password
+	LockSleepButton    bool
+	ConsoleRedirection uint8 // 0 = BIOS setting, 1 = suppress, 2 = enable
+	DeviceInstance     uint8
+}
+
+func (f *BootFlags) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 5)
+
+	b[0] = bit(f.Valid, 0x80) | bit(f.Persistent, 0x40) | bit(f.EFI, 0x20)
+	b[1] = bit(f.ClearCMOS, 0x80) | bit(f.LockKeyboard, 0x40) | (f.Device&0x0f)<<2 |
+		bit(f.ScreenBlank, 0x02) | bit(f.LockResetButton, 0x01)
+	b[2] = bit(f.LockPowerButton, 0x80) | (f.Verbosity&0x03)<<5 | bit(f.ProgressTraps, 0x10) |
+		bit(f.PasswordBypass, 0x08) | bit(f.LockSleepButton, 0x04) | f.ConsoleRedirection&0x03
+	b[4] = f.DeviceInstance & 0x1f
+
+	return b, nil
+}
+
+func (f *BootFlags) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	b0, b1, b2 := d.uint8(), d.uint8(), d.uint8()
+	d.uint8()
+	b4 := d.uint8()
+
+	*f = BootFlags{
+		Valid:              b0&0x80 != 0,
+		Persistent:         b0&0x40 != 0,
+		EFI:                b0&0x20 != 0,
+		ClearCMOS:          b1&0x80 != 0,
+		LockKeyboard:       b1&0x40 != 0,
+		Device:             (b1 >> 2) & 0x0f,
+		ScreenBlank:        b1&0x02 != 0,
+		LockResetButton:    b1&0x01 != 0,
+		LockPowerButton:    b2&0x80 != 0,
+		Verbosity:          (b2 >> 5) & 0x03,
+		ProgressTraps:      b2&0x10 != 0,
+		PasswordBypass:     b2&0x08 != 0,
+		LockSleepButton:    b2&0x04 != 0,
+		ConsoleRedirection: b2 & 0x03,
+		DeviceInstance:     b4 & 0x1f,
+	}
+
+	return d.err
+}
+
+// SetSystemBootOptionsRequest per section 28.12
+type SetSystemBootOptionsRequest struct {
+	Param uint8
+	Data  []byte
+}
+
+func (r *SetSystemBootOptionsRequest) MarshalBinary() ([]byte, error) {
+	return append([]byte{r.Param & 0x7f}, r.Data...), nil
+}
+
+func (r *SetSystemBootOptionsRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.Param = d.uint8() & 0x7f
+	r.Data = d.rest()
+
+	return d.err
+}
+
+// GetSystemBootOptionsRequest per section 28.13
+type GetSystemBootOptionsRequest struct {
+	Param uint8
+	Set   uint8
+	Block uint8
+}
+
+func (r *GetSystemBootOptionsRequest) MarshalBinary() ([]byte, error) {
+	return []byte{r.Param & 0x7f, r.Set, r.Block}, nil
+}
+
+func (r *GetSystemBootOptionsRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.Param = d.uint8() & 0x7f
+	r.Set = d.uint8()
+	r.Block = d.uint8()
+
+	return d.err
+}
+
+// GetSystemBootOptionsResponse per section 28.13
+type GetSystemBootOptionsResponse struct {
+	Version uint8 // Parameter version
+	Invalid bool  // Parameter marked invalid or locked
+	Param   uint8
+	Data    []byte
+}
+
+func (r *GetSystemBootOptionsResponse) MarshalBinary() ([]byte, error) {
+	return append([]byte{r.Version, bit(r.Invalid, 0x80) | r.Param&0x7f}, r.Data...), nil
+}
+
+func (r *GetSystemBootOptionsResponse) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.Version = d.uint8()
+	param := d.uint8()
+	r.Invalid = param&0x80 != 0
+	r.Param = param & 0x7f
+	r.Data = d.rest()
+
+	return d.err
+}
+
+// setSystemBootOption sets a boot option parameter
+func (c *conn) setSystemBootOption(param uint8, value []byte) error {
+	return c.send(NetFnChassis, CmdSetSystemBootOptions, &SetSystemBootOptionsRequest{param, value}, nil)
+}
+
+// getSystemBootOption returns the data of a boot option parameter
+func (c *conn) getSystemBootOption(param, set, block uint8) ([]byte, error) {
+	req := &GetSystemBootOptionsRequest{param, set, block}
+	resp := &GetSystemBootOptionsResponse{}
+
+	if err := c.send(NetFnChassis, CmdGetSystemBootOptions, req, resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Param != param&0x7f {
+		return nil, fmt.Errorf("unexpected boot option parameter in response: %#x", resp.Param)
+	}
+
+	return resp.Data, nil
+}
+
+// setBootFlags writes the boot flags, holding the set in progress lock so that the update does not
+// interleave with that of another session. BMCs that do not support the lock are written to
+// regardless.
+func (c *conn) setBootFlags(f *BootFlags) (err error) {
+	err = c.setSystemBootOption(BootParamSetInProgress, []byte{bootSetInProgress})
+	if errors.Is(err, ErrBootSetInProgress) {
+		return fmt.Errorf("boot options locked by another session: %w", err)
+	} else if err == nil {
+		defer func() {
+			if cerr := c.setSystemBootOption(BootParamSetInProgress, []byte{bootSetComplete}); err == nil {
+				err = cerr
+			}
+		}()
+	} else if !errors.Is(err, ErrBootParamNotSupported) {
+		return err
+	}
+
+	b, err := f.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	if err := c.setSystemBootOption(BootParamFlags, b); err != nil {
+		return err
+	}
+
+	// Clear the BIOS acknowledgement, so that the BIOS acts on the new flags
+	err = c.setSystemBootOption(BootParamInfoAck, []byte{0x01, 0x01})
+	if errors.Is(err, ErrBootParamNotSupported) {
+		err = nil
+	}
+
+	return err
+}
+
+// getBootFlags reads the boot flags
+func (c *conn) getBootFlags() (*BootFlags, error) {
+	data, err := c.getSystemBootOption(BootParamFlags, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &BootFlags{}
+	if err := f.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -0,0 +1,76 @@
+package ipmi_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+func TestSetBootDevice(t *testing.T) {
+	_, addr := newTestSimulator(t, ipmisim.DefaultProfile())
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("operator", "operator", ipmi.PrivLevelOperator); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, persistent := range []bool{false, true} {
+		if err := c.SetBootDevice(ipmi.BootDevicePXE, persistent, true); err != nil {
+			t.Fatal(err)
+		}
+
+		flags, err := c.GetBootFlags()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !flags.Valid || flags.Persistent != persistent || !flags.EFI || flags.Device != ipmi.BootDevicePXE {
+			t.Errorf("unexpected boot flags: %+v", flags)
+		}
+
+		// Flags for the next boot only are cleared by a reset
+		if err := c.ChassisControl(ipmi.ChassisHardReset); err != nil {
+			t.Fatal(err)
+		}
+
+		if flags, err = c.GetBootFlags(); err != nil {
+			t.Fatal(err)
+		}
+
+		if flags.Valid != persistent {
+			t.Errorf("persistent %v: boot flags valid %v after reset", persistent, flags.Valid)
+		}
+	}
+
+	// Lock is released after setting the flags
+	data, err := c.GetSystemBootOption(ipmi.BootParamSetInProgress, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data) != 1 || data[0] != ipmi.BootSetComplete {
+		t.Errorf("set in progress lock not released: % x", data)
+	}
+}
+
+func TestSetBootDeviceLocked(t *testing.T) {
+	_, addr := newTestSimulator(t, ipmisim.DefaultProfile())
+
+	c1, c2 := dialSimulator(t, addr), dialSimulator(t, addr)
+
+	for _, c := range []*ipmi.Client{c1, c2} {
+		if err := c.OpenSession("admin", "admin", ipmi.PrivLevelAdmin); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := c1.SetSystemBootOption(ipmi.BootParamSetInProgress, []byte{ipmi.BootSetInProgress}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c2.SetBootDevice(ipmi.BootDeviceDisk, false, false); !errors.Is(err, ipmi.ErrBootSetInProgress) {
+		t.Errorf("expected set in progress error, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package ipmi
+
+import (
+	"testing"
+)
+
+func TestBootFlagsEncoding(t *testing.T) {
+	flags := BootFlags{
+		Valid:              true,
+		EFI:                true,
+		Device:             BootDeviceBIOS,
+		LockResetButton:    true,
+		Verbosity:          2,
+		ConsoleRedirection: 1,
+		DeviceInstance:     3,
+	}
+
+	b, err := flags.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b[0] != 0xa0 || b[1] != 0x19 || b[2] != 0x41 || b[4] != 0x03 {
+		t.Errorf("unexpected encoding: % x", b)
+	}
+
+	var decoded BootFlags
+	if err := decoded.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != flags {
+		t.Errorf("decoded %+v, expected %+v", decoded, flags)
+	}
+}
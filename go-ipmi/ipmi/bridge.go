@@ -0,0 +1,263 @@
+package ipmi
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Channel numbers of the IPMB channels behind the BMC. Channel assignments other than the primary
+// IPMB are implementation specific, however the secondary IPMB is commonly channel 6, e.g. for
+// Intel Node Manager.
+const (
+	ChannelIPMB          = 0x00
+	ChannelSecondaryIPMB = 0x06
+)
+
+// Completion codes specific to Send Message
+var (
+	ErrInvalidSessionHandle = commandCode(0x80, NetFnApp, CmdSendMessage)
+	ErrLostArbitration      = commandCode(0x81, NetFnApp, CmdSendMessage)
+	ErrBusError             = commandCode(0x82, NetFnApp, CmdSendMessage)
+	ErrNAKOnWrite           = commandCode(0x83, NetFnApp, CmdSendMessage) // No controller acknowledged the target address
+)
+
+// Completion code specific to Get Message
+var ErrMessageQueueEmpty = commandCode(0x80, NetFnApp, CmdGetMessage)
+
+const (
+	// Send Message tracking request, for the BMC to return the bridged response to the requester
+	sendMessageTrack = 0x40
+
+	// LUN of the receive message queue, to which the BMC delivers bridged responses that are not
+	// tracked, for retrieval with Get Message
+	lunSMS = 0x02
+
+	// Interval between Get Message requests while awaiting a bridged response in-band
+	getMessageInterval = 10 * time.Millisecond
+)
+
+// Target is a satellite controller behind the BMC, such as a node manager or the controller of a
+// blade, which is reached by bridging requests over IPMB with Send Message. Setting TransitAddr
+// dual bridges requests via a transit controller, e.g. the management controller of a blade
+// chassis, which in turn forwards them to the target on its own channel.
+type Target struct {
+	Channel        uint8 // Channel of the target, as seen from the BMC or the transit controller
+	Addr           uint8 // IPMB slave address of the target
+	LUN            uint8
+	TransitChannel uint8 // Channel of the transit controller, as seen from the BMC
+	TransitAddr    uint8 // IPMB slave address of the transit controller, or zero for single bridging
+}
+
+// Bridge returns a client sending commands to a controller behind the BMC. The client shares the
+// transport and session of c, and closing either closes both. Targets are always relative to the
+// BMC, even if c is itself bridged.
+func (c *Client) Bridge(target Target) *Client {
+	t := c.conn.Transport
+	if b, ok := t.(*bridge); ok {
+		t = b.Transport
+	}
+
+	return &Client{conn: &conn{newBridge(t, target)}, l: c.l}
+}
+
+// bridge is a Transport to a target controller, encapsulating requests in Send Message requests to
+// the BMC. Over LAN, the BMC tracks the bridged request, and either embeds the response in the Send
+// Message response, or sends it to the remote console in a separate packet per section 6.12.4.
+// In-band, responses are delivered to the receive message queue instead, and are polled for with
+// Get Message.
+type bridge struct {
+	Transport
+	target Target
+	inband bool
+
+	mu      sync.Mutex // Serialises in-band requests, whose responses share the receive message queue
+	seqMu   sync.Mutex
+	rqSeq   uint8
+	timeout time.Duration
+	retries int
+}
+
+func newBridge(t Transport, target Target) *bridge {
+	_, inband := t.(*inbandConnection)
+
+	return &bridge{
+		Transport: t,
+		target:    target,
+		inband:    inband,
+		timeout:   DefaultTimeout,
+		retries:   DefaultRetries,
+	}
+}
+
+// SetTimeout implements Transport, setting the timeouts of the underlying transport, and the total
+// time to wait for a bridged response in-band
+func (b *bridge) SetTimeout(timeout time.Duration, retries int) error {
+	b.seqMu.Lock()
+	b.timeout = timeout
+	b.retries = retries
+	b.seqMu.Unlock()
+
+	return b.Transport.SetTimeout(timeout, retries)
+}
+
+// SendRecv implements Transport. Errors from the BMC or transit controller are returned as a
+// *CommandError for Send Message, e.g. ErrNAKOnWrite if the target is absent.
+func (b *bridge) SendRecv(req Request) ([]byte, error) {
+	if b.inband {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+	}
+
+	b.seqMu.Lock()
+	b.rqSeq = (b.rqSeq + 1) & 0x3f
+	rqSeq := b.rqSeq
+	wait := b.timeout * time.Duration(b.retries+1)
+	b.seqMu.Unlock()
+
+	t := b.target
+
+	// Response to the request encapsulated by the BMC, i.e. the request to the target, or to the
+	// transit controller when dual bridging
+	tracked := req
+	if t.TransitAddr != 0 {
+		tracked = Request{NetworkFunction: NetFnApp, Command: CmdSendMessage}
+	}
+
+	// Over LAN, the response may arrive in a separate packet, which is matched by a sequence
+	// number allocated by the LAN connection, so as not to collide with its own requests
+	var pending *pendingResponse
+
+	if l, ok := b.Transport.(*lanConnection); ok {
+		var err error
+		if pending, err = l.expect(tracked.NetworkFunction, tracked.Command); err != nil {
+			return nil, err
+		}
+		defer pending.cancel()
+
+		rqSeq = pending.rqSeq
+	}
+
+	// Requests to the BMC are tracked over LAN. In-band, responses are routed to the receive
+	// message queue by the requester LUN.
+	flags, rqLUN := uint8(sendMessageTrack), uint8(0)
+	if b.inband {
+		flags, rqLUN = 0, lunSMS
+	}
+
+	// The LUN of a request, e.g. of a sensor owned by another LUN, overrides that of the target
+	lun := t.LUN
+	if req.LUN != 0 {
+		lun = req.LUN
+	}
+
+	levels := []Request{req}
+
+	if t.TransitAddr == 0 {
+		levels = append(levels, sendMessage(req, t.Channel, flags, t.Addr, lun, bmcSlaveAddr, rqLUN, rqSeq))
+	} else {
+		inner := sendMessage(req, t.Channel, sendMessageTrack, t.Addr, lun, t.TransitAddr, 0, rqSeq)
+		levels = append(levels, inner,
+			sendMessage(inner, t.TransitChannel, flags, t.TransitAddr, 0, bmcSlaveAddr, rqLUN, rqSeq))
+	}
+
+	data, err := b.Transport.SendRecv(levels[len(levels)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	// Unwrap the response of each level in turn, from the BMC inwards
+	for i := len(levels) - 2; i >= 0; i-- {
+		outer := i == len(levels)-2
+
+		switch {
+		case len(data) > 1:
+			data, err = bridgedResponse(levels[i], rqSeq, data[1:])
+		case outer && pending != nil:
+			// The separate response has already been matched and verified by the LAN receive loop
+			if data, err = pending.wait(wait); err != nil {
+				err = fmt.Errorf("get bridged response: %w", err)
+			} else {
+				data, err = checkCompletionCode(levels[i], data)
+			}
+		case outer && b.inband:
+			var msg []byte
+			if msg, err = b.getMessage(levels[i], rqSeq, wait); err == nil {
+				data, err = bridgedResponse(levels[i], rqSeq, msg)
+			}
+		default:
+			err = fmt.Errorf("no bridged response to NetFn 0x%02x command 0x%02x: %w",
+				levels[i].NetworkFunction, levels[i].Command, ErrShortData)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// sendMessage encapsulates req in a Send Message request, addressed to the controller at addr on
+// channel. The encapsulated request is sent from rqAddr, and answered to the requester LUN rqLUN.
+func sendMessage(req Request, channel, flags, addr, lun, rqAddr, rqLUN, rqSeq uint8) Request {
+	msg := EncodeMessage(MessageHeader{
+		RsAddr:     addr,
+		NetFnRsLUN: req.NetworkFunction<<2 | lun&0x03,
+		RqAddr:     rqAddr,
+		RqSeq:      rqSeq<<2 | rqLUN&0x03,
+		Command:    req.Command,
+	}, req.Data)
+
+	return Request{
+		NetworkFunction: NetFnApp,
+		Command:         CmdSendMessage,
+		Data:            append([]byte{flags | channel&0x0f}, msg...),
+	}
+}
+
+// bridgedResponse verifies that msg is the response to a bridged request, returning its data
+func bridgedResponse(req Request, rqSeq uint8, msg []byte) ([]byte, error) {
+	hdr, data, err := DecodeMessage(msg)
+	if err != nil {
+		return nil, fmt.Errorf("decode bridged response: %w", err)
+	}
+
+	if hdr.NetFnRsLUN>>2 != req.NetworkFunction|1 || hdr.Command != req.Command || hdr.RqSeq>>2 != rqSeq {
+		return nil, fmt.Errorf("unexpected bridged response NetFn 0x%02x command 0x%02x seq %d: %w",
+			hdr.NetFnRsLUN>>2, hdr.Command, hdr.RqSeq>>2, ErrInvalidData)
+	}
+
+	return checkCompletionCode(req, append([]byte(nil), data...))
+}
+
+// getMessage polls the receive message queue for the response to a bridged request, discarding
+// other messages, such as late responses to abandoned requests. Returns the response message with
+// the responder address restored.
+func (b *bridge) getMessage(req Request, rqSeq uint8, wait time.Duration) ([]byte, error) {
+	deadline := time.Now().Add(wait)
+
+	for {
+		data, err := b.Transport.SendRecv(Request{NetworkFunction: NetFnApp, Command: CmdGetMessage})
+
+		switch {
+		case err == nil && len(data) >= 2+ipmiHeaderSize:
+			// Queued messages start after the channel number, without the responder address
+			msg := append([]byte{bmcSlaveAddr}, data[2:]...)
+			if hdr, _, err := DecodeMessage(msg); err == nil && hdr.Command == req.Command &&
+				hdr.RqSeq>>2 == rqSeq {
+				return msg, nil
+			}
+			continue
+		case err != nil && !errors.Is(err, ErrMessageQueueEmpty):
+			return nil, fmt.Errorf("get bridged response: %w", err)
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("no bridged response within %v: %w", wait, ErrTimeout)
+		}
+
+		time.Sleep(getMessageInterval)
+	}
+}
@@ -0,0 +1,134 @@
+package ipmi_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+// Node manager of the default profile
+var testNodeManager = ipmi.Target{Channel: ipmi.ChannelSecondaryIPMB, Addr: 0x2c}
+
+// newBladeProfile returns the default profile with a blade chassis on the primary IPMB, which has a
+// single blade on its own channel 2
+func newBladeProfile() *ipmisim.Profile {
+	profile := ipmisim.DefaultProfile()
+	profile.Satellites = append(profile.Satellites, ipmisim.Satellite{
+		Channel: ipmi.ChannelIPMB, Addr: 0x82, Device: ipmisim.Device{DeviceID: 0x82},
+		Satellites: []ipmisim.Satellite{
+			{Channel: 2, Addr: 0x72, Device: ipmisim.Device{DeviceID: 0x72, ProductID: 0x1234}},
+		},
+	})
+
+	return profile
+}
+
+// testBridge checks single and dual bridged requests to the satellites of newBladeProfile
+func testBridge(t *testing.T, c *ipmi.Client) {
+	t.Helper()
+
+	if id, err := c.Bridge(testNodeManager).GetDeviceID(); err != nil || id.DeviceID != 0x50 || id.ProductID != 0x0b {
+		t.Errorf("node manager device ID %+v, error %v", id, err)
+	}
+
+	blade := c.Bridge(ipmi.Target{Channel: 2, Addr: 0x72, TransitChannel: ipmi.ChannelIPMB, TransitAddr: 0x82})
+	if id, err := blade.GetDeviceID(); err != nil || id.DeviceID != 0x72 || id.ProductID != 0x1234 {
+		t.Errorf("blade device ID %+v, error %v", id, err)
+	}
+
+	if result, err := blade.GetSelfTestResults(); err != nil || !result.Passed() {
+		t.Errorf("blade self test result %v, error %v", result, err)
+	}
+
+	// Errors of the target are returned for the bridged command
+	var cmdErr *ipmi.CommandError
+	if _, err := blade.GetChassisStatus(); !errors.As(err, &cmdErr) || cmdErr.Code != ipmi.ErrInvalidCommand ||
+		cmdErr.Command != ipmi.CmdGetChassisStatus {
+		t.Errorf("expected invalid command, got %v", err)
+	}
+
+	// Errors of the BMC and transit controller are returned for Send Message
+	for _, target := range []ipmi.Target{
+		{Channel: ipmi.ChannelSecondaryIPMB, Addr: 0x2e},
+		{Channel: 2, Addr: 0x74, TransitChannel: ipmi.ChannelIPMB, TransitAddr: 0x82},
+	} {
+		if _, err := c.Bridge(target).GetDeviceID(); !errors.As(err, &cmdErr) || cmdErr.Code != ipmi.ErrNAKOnWrite.Code ||
+			cmdErr.Command != ipmi.CmdSendMessage {
+			t.Errorf("target %+v: expected NAK on write, got %v", target, err)
+		}
+	}
+
+	// The BMC itself is still addressed by the original client
+	if id, err := c.GetDeviceID(); err != nil || id.DeviceID != ipmisim.DefaultProfile().Device.DeviceID {
+		t.Errorf("BMC device ID %+v, error %v", id, err)
+	}
+}
+
+func TestBridge(t *testing.T) {
+	_, addr := newTestSimulator(t, newBladeProfile())
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("user", "user", ipmi.PrivLevelUser); err != nil {
+		t.Fatal(err)
+	}
+
+	testBridge(t, c)
+
+	// Bridging a bridged client is relative to the BMC
+	if id, err := c.Bridge(ipmi.Target{Addr: 0x82}).Bridge(testNodeManager).GetDeviceID(); err != nil || id.DeviceID != 0x50 {
+		t.Errorf("node manager device ID %+v, error %v", id, err)
+	}
+}
+
+func TestBridgeSeparateResponse(t *testing.T) {
+	for _, ipmiV20 := range []bool{true, false} {
+		profile := newBladeProfile()
+		profile.IPMIv20 = ipmiV20
+		profile.SeparateBridgedResponses = true
+
+		_, addr := newTestSimulator(t, profile)
+		c := dialSimulator(t, addr)
+
+		if err := c.OpenSession("user", "user", ipmi.PrivLevelUser); err != nil {
+			t.Fatal(err)
+		}
+
+		testBridge(t, c)
+
+		// Requests to the BMC are unaffected by the bridged response awaited
+		if id, err := c.GetDeviceID(); err != nil || id.DeviceID != ipmisim.DefaultProfile().Device.DeviceID {
+			t.Errorf("IPMI v2.0 %v: device ID %+v, error %v", ipmiV20, id, err)
+		}
+	}
+}
+
+func TestBridgeInband(t *testing.T) {
+	sim := ipmisim.New(newBladeProfile())
+	c := ipmi.NewFakeInbandClient(t, sim.HandleSystemInterface)
+
+	testBridge(t, c)
+
+	// Late responses to abandoned requests are discarded from the receive message queue. An
+	// untracked request to the SMS LUN has its response queued, as if it had been abandoned.
+	msg := ipmi.EncodeMessage(ipmi.MessageHeader{
+		RsAddr:     0x2c,
+		NetFnRsLUN: ipmi.NetFnApp << 2,
+		RqAddr:     0x20, // BMC slave address
+		RqSeq:      0x3f<<2 | 0x02,
+		Command:    ipmi.CmdGetDeviceID,
+	}, nil)
+
+	if resp := sim.HandleSystemInterface(ipmi.NetFnApp, ipmi.CmdSendMessage, append([]byte{ipmi.ChannelSecondaryIPMB}, msg...)); resp[0] != 0 {
+		t.Fatalf("send message completion code %#x", resp[0])
+	}
+
+	if id, err := c.Bridge(testNodeManager).GetDeviceID(); err != nil || id.DeviceID != 0x50 {
+		t.Errorf("node manager device ID %+v, error %v", id, err)
+	}
+
+	if resp := sim.HandleSystemInterface(ipmi.NetFnApp, ipmi.CmdGetMessage, nil); resp[0] != uint8(ipmi.ErrMessageQueueEmpty.Code) {
+		t.Errorf("messages left in receive message queue: % x", resp)
+	}
+}
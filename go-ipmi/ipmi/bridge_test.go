@@ -0,0 +1,16 @@
+package ipmi
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSendMessage(t *testing.T) {
+	req := sendMessage(Request{NetworkFunction: NetFnApp, Command: CmdGetDeviceID}, ChannelSecondaryIPMB, sendMessageTrack,
+		0x2c, 0, bmcSlaveAddr, 0, 1)
+
+	want := []byte{0x46, 0x2c, 0x18, 0xbc, 0x20, 0x04, 0x01, 0xdb}
+	if req.NetworkFunction != NetFnApp || req.Command != CmdSendMessage || !bytes.Equal(req.Data, want) {
+		t.Errorf("got %v, want data % x", req, want)
+	}
+}
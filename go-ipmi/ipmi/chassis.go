@@ -0,0 +1,144 @@
+package ipmi
+
+// Chassis device commands per section 28
+
+import "fmt"
+
+// Chassis control actions per section 28.3
+const (
+	ChassisPowerDown      = 0x00
+	ChassisPowerUp        = 0x01
+	ChassisPowerCycle     = 0x02
+	ChassisHardReset      = 0x03
+	ChassisDiagInterrupt  = 0x04 // Pulse diagnostic interrupt (NMI)
+	ChassisSoftShutdown   = 0x05 // Initiate soft shutdown via ACPI overtemperature emulation
+	chassisControlInvalid = 0x06
+)
+
+// Identify states in ChassisStatus
+const (
+	IdentifyOff        = 0x00
+	IdentifyTemporary  = 0x01
+	IdentifyIndefinite = 0x02
+)
+
+// Power restore policies per section 28.2
+const (
+	PowerRestorePolicyOff      = 0x00 // Chassis stays powered off after AC returns
+	PowerRestorePolicyPrevious = 0x01 // Power is restored to the state it was in when AC was lost
+	PowerRestorePolicyOn       = 0x02 // Chassis always powers up after AC returns
+	PowerRestorePolicyUnknown  = 0x03
+)
+
+// ChassisStatus is the decoded response to Get Chassis Status per section 28.2
+type ChassisStatus struct {
+	PowerOn            bool
+	PowerOverload      bool
+	PowerInterlock     bool
+	PowerFault         bool
+	PowerControlFault  bool
+	PowerRestorePolicy uint8
+
+	// Last power event
+	LastACFailed         bool
+	LastPowerOverload    bool
+	LastPowerInterlock   bool
+	LastPowerFault       bool
+	LastPowerOnByCommand bool
+
+	// Miscellaneous chassis state
+	Intrusion         bool
+	FrontPanelLockout bool
+	DriveFault        bool
+	CoolingFault      bool
+	IdentifyState     uint8 // IdentifyOff, IdentifyTemporary or IdentifyIndefinite
+	IdentifySupported bool
+
+	// Front panel button capabilities, if provided
+	FrontPanelButtons *uint8
+}
+
+func (s *ChassisStatus) MarshalBinary() ([]byte, error) {
+	power := bit(s.PowerOn, 0x01) | bit(s.PowerOverload, 0x02) | bit(s.PowerInterlock, 0x04) |
+		bit(s.PowerFault, 0x08) | bit(s.PowerControlFault, 0x10) | (s.PowerRestorePolicy&0x03)<<5
+	last := bit(s.LastACFailed, 0x01) | bit(s.LastPowerOverload, 0x02) | bit(s.LastPowerInterlock, 0x04) |
+		bit(s.LastPowerFault, 0x08) | bit(s.LastPowerOnByCommand, 0x10)
+	misc := bit(s.Intrusion, 0x01) | bit(s.FrontPanelLockout, 0x02) | bit(s.DriveFault, 0x04) |
+		bit(s.CoolingFault, 0x08) | (s.IdentifyState&0x03)<<4 | bit(s.IdentifySupported, 0x40)
+
+	b := []byte{power, last, misc}
+	if s.FrontPanelButtons != nil {
+		b = append(b, *s.FrontPanelButtons)
+	}
+
+	return b, nil
+}
+
+func (s *ChassisStatus) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	power, last, misc := d.uint8(), d.uint8(), d.uint8()
+
+	*s = ChassisStatus{
+		PowerOn:            power&0x01 != 0,
+		PowerOverload:      power&0x02 != 0,
+		PowerInterlock:     power&0x04 != 0,
+		PowerFault:         power&0x08 != 0,
+		PowerControlFault:  power&0x10 != 0,
+		PowerRestorePolicy: (power >> 5) & 0x03,
+
+		LastACFailed:         last&0x01 != 0,
+		LastPowerOverload:    last&0x02 != 0,
+		LastPowerInterlock:   last&0x04 != 0,
+		LastPowerFault:       last&0x08 != 0,
+		LastPowerOnByCommand: last&0x10 != 0,
+
+		Intrusion:         misc&0x01 != 0,
+		FrontPanelLockout: misc&0x02 != 0,
+		DriveFault:        misc&0x04 != 0,
+		CoolingFault:      misc&0x08 != 0,
+		IdentifyState:     (misc >> 4) & 0x03,
+		IdentifySupported: misc&0x40 != 0,
+	}
+
+	// Front panel button capabilities are optional
+	if d.more() {
+		buttons := d.uint8()
+		s.FrontPanelButtons = &buttons
+	}
+
+	return d.err
+}
+
+// getChassisStatus returns the chassis power and miscellaneous state
+func (c *conn) getChassisStatus() (*ChassisStatus, error) {
+	resp := &ChassisStatus{}
+
+	if err := c.send(NetFnChassis, CmdGetChassisStatus, nil, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// chassisControl powers the chassis up or down, power cycles or resets it, pulses a diagnostic
+// interrupt or initiates a soft shutdown.
+func (c *conn) chassisControl(action uint8) error {
+	if action >= chassisControlInvalid {
+		return fmt.Errorf("invalid chassis control action: %#x", action)
+	}
+
+	return c.send(NetFnChassis, CmdChassisControl, rawRequest{action}, nil)
+}
+
+// chassisIdentify turns the chassis identify indicator on for the specified number of seconds, or
+// off if zero. If force is set, the indicator is turned on indefinitely.
+func (c *conn) chassisIdentify(seconds uint8, force bool) error {
+	req := rawRequest{seconds}
+
+	// Force byte is optional, and may not be accepted by BMCs that do not support it
+	if force {
+		req = append(req, 0x01)
+	}
+
+	return c.send(NetFnChassis, CmdChassisIdentify, req, nil)
+}
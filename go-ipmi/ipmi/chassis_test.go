@@ -0,0 +1,96 @@
+package ipmi_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+func TestChassisControl(t *testing.T) {
+	_, addr := newTestSimulator(t, ipmisim.DefaultProfile())
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("operator", "operator", ipmi.PrivLevelOperator); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		action  uint8
+		err     error
+		powerOn bool
+	}{
+		{ipmi.ChassisPowerDown, nil, false},
+		{ipmi.ChassisPowerCycle, ipmi.ErrNotSupportedInState, false},
+		{ipmi.ChassisPowerUp, nil, true},
+		{ipmi.ChassisHardReset, nil, true},
+		{ipmi.ChassisSoftShutdown, nil, false},
+	}
+
+	for _, step := range steps {
+		if err := c.ChassisControl(step.action); !errors.Is(err, step.err) {
+			t.Fatalf("action %d: expected error %v, got %v", step.action, step.err, err)
+		}
+
+		status, err := c.GetChassisStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if status.PowerOn != step.powerOn {
+			t.Errorf("action %d: power on %v", step.action, status.PowerOn)
+		}
+	}
+
+	if err := c.ChassisControl(ipmi.ChassisControlInvalid); err == nil {
+		t.Error("invalid chassis control action accepted")
+	}
+}
+
+func TestChassisIdentify(t *testing.T) {
+	_, addr := newTestSimulator(t, ipmisim.DefaultProfile())
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("operator", "operator", ipmi.PrivLevelOperator); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		seconds uint8
+		force   bool
+		state   uint8
+	}{
+		{30, false, ipmi.IdentifyTemporary},
+		{0, true, ipmi.IdentifyIndefinite},
+		{0, false, ipmi.IdentifyOff},
+	}
+
+	for _, tt := range tests {
+		if err := c.ChassisIdentify(tt.seconds, tt.force); err != nil {
+			t.Fatal(err)
+		}
+
+		status, err := c.GetChassisStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !status.IdentifySupported || status.IdentifyState != tt.state {
+			t.Errorf("identify %d seconds, force %v: state %d", tt.seconds, tt.force, status.IdentifyState)
+		}
+	}
+}
+
+func TestChassisControlPrivLevel(t *testing.T) {
+	_, addr := newTestSimulator(t, ipmisim.DefaultProfile())
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("user", "user", ipmi.PrivLevelUser); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.ChassisControl(ipmi.ChassisPowerDown); !errors.Is(err, ipmi.ErrInsufficientPriv) {
+		t.Errorf("expected insufficient privilege, got %v", err)
+	}
+}
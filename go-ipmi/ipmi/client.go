@@ -0,0 +1,433 @@
+// Package ipmi implements an IPMI client for communicating with BMCs over LAN, supporting both
+// IPMI v1.5 sessions and IPMI v2.0 RMCP+ sessions, or in-band via the Linux OpenIPMI driver.
+//
+// Based on https://www-ssl.intel.com/content/www/us/en/servers/ipmi/ipmi-intelligent-platform-mgt-interface-spec-2nd-gen-v2-0-spec-update.html
+package ipmi
+
+import (
+	"encoding"
+	"errors"
+	"time"
+)
+
+// ErrLANOnly is returned by session and payload methods of clients connected in-band
+var ErrLANOnly = errors.New("only supported over LAN")
+
+// Client is a connection to a BMC
+type Client struct {
+	conn *conn
+	l    *lanConnection // LAN transport, or nil if connected in-band
+}
+
+// Dial connects to the BMC at the specified host and port. No session is established until
+// OpenSession is called, however sessionless commands such as GetChannelAuthCapabilities may be
+// sent.
+func Dial(host string) (*Client, error) {
+	l, err := newLanConnection(host)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(l), nil
+}
+
+// OpenDevice connects in-band to the local BMC via an OpenIPMI character device, e.g.
+// DefaultDevice. No session or credentials are required.
+func OpenDevice(path string) (*Client, error) {
+	dev, err := openIPMIDevice(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ib, err := newInbandConnection(dev)
+	if err != nil {
+		dev.close()
+		return nil, err
+	}
+
+	return NewClient(ib), nil
+}
+
+// NewClient returns a client sending commands over a Transport. Sessions and payloads are only
+// available on the LAN transports of Dial.
+func NewClient(t Transport) *Client {
+	l, _ := t.(*lanConnection)
+	return &Client{conn: &conn{t}, l: l}
+}
+
+// lan returns the LAN transport of the client, or ErrLANOnly if connected in-band
+func (c *Client) lan() (*lanConnection, error) {
+	if c.l == nil {
+		return nil, ErrLANOnly
+	}
+	return c.l, nil
+}
+
+// OpenSession authenticates with the BMC and establishes a session at the requested privilege
+// level, using IPMI v2.0 RMCP+ if the BMC supports it.
+func (c *Client) OpenSession(username, password string, priv uint8) error {
+	l, err := c.lan()
+	if err != nil {
+		return err
+	}
+	return l.openSession(username, password, priv)
+}
+
+// Close closes the active session, if any, and the underlying connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// SetTimeout sets the time to wait for a response before retransmitting a request, and the maximum
+// number of retransmissions. Over LAN, the timeout is doubled after each unanswered attempt. In-band,
+// these set the timing parameters of the driver, and the total time to wait for a response.
+func (c *Client) SetTimeout(timeout time.Duration, retries int) error {
+	return c.conn.SetTimeout(timeout, retries)
+}
+
+// SessionID returns the session ID assigned by the BMC, or zero if no session is active
+func (c *Client) SessionID() uint32 {
+	if c.l == nil {
+		return 0
+	}
+
+	c.l.mu.Lock()
+	defer c.l.mu.Unlock()
+	return c.l.sessionID
+}
+
+// Version returns the IPMI version of the session, i.e. IPMIVersion15 or IPMIVersion20, or zero if
+// connected in-band
+func (c *Client) Version() uint8 {
+	if c.l == nil {
+		return 0
+	}
+
+	c.l.mu.Lock()
+	defer c.l.mu.Unlock()
+	return c.l.version
+}
+
+// PrivLevel returns the current privilege level of the session. Clients connected in-band have
+// administrator privilege.
+func (c *Client) PrivLevel() uint8 {
+	if c.l == nil {
+		return PrivLevelAdmin
+	}
+
+	c.l.mu.Lock()
+	defer c.l.mu.Unlock()
+	return c.l.priv
+}
+
+// Send sends a request to the BMC and decodes the response data following the completion code
+// into resp, which may be nil to discard it. Requests may be sent concurrently from multiple
+// goroutines over the same session.
+func (c *Client) Send(req Request, resp encoding.BinaryUnmarshaler) error {
+	data, err := c.conn.SendRecv(req)
+	if err != nil {
+		return err
+	}
+
+	return unmarshalResponse(data, resp)
+}
+
+// GetDeviceID returns the BMC's identity, firmware revision, IPMI version and capabilities
+func (c *Client) GetDeviceID() (*DeviceID, error) {
+	return c.conn.getDeviceID()
+}
+
+// GetSelfTestResults returns the results of the BMC's most recent self test
+func (c *Client) GetSelfTestResults() (*SelfTestResult, error) {
+	return c.conn.getSelfTestResults()
+}
+
+// GetDeviceGUID returns the GUID of the BMC itself
+func (c *Client) GetDeviceGUID() (GUID, error) {
+	return c.conn.getGUID(CmdGetDeviceGUID)
+}
+
+// GetSystemGUID returns the GUID of the managed system, which is also used to authenticate RMCP+
+// sessions
+func (c *Client) GetSystemGUID() (GUID, error) {
+	return c.conn.getGUID(CmdGetSystemGUID)
+}
+
+// Ping sends an RMCP Presence Ping, which management controllers answer without a session, e.g. to
+// discover BMCs before dialing them with credentials
+func (c *Client) Ping() (*Pong, error) {
+	l, err := c.lan()
+	if err != nil {
+		return nil, err
+	}
+	return l.ping()
+}
+
+// GetChannelAuthCapabilities returns the authentication capabilities of a channel for the
+// requested privilege level, e.g. on CurrentChannel.
+func (c *Client) GetChannelAuthCapabilities(channel, priv uint8) (*AuthCapabilitiesResponse, error) {
+	l, err := c.lan()
+	if err != nil {
+		return nil, err
+	}
+	return l.getAuthCapabilities(channel, priv)
+}
+
+// GetChannelCipherSuites returns the IDs of the cipher suites supported by a channel
+func (c *Client) GetChannelCipherSuites(channel uint8) ([]uint8, error) {
+	l, err := c.lan()
+	if err != nil {
+		return nil, err
+	}
+	return l.getChannelCipherSuites(channel)
+}
+
+// SetSessionPrivLevel changes the privilege level of the active session, returning the new level
+func (c *Client) SetSessionPrivLevel(priv uint8) (uint8, error) {
+	l, err := c.lan()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := l.setSessionPrivLevel(priv); err != nil {
+		return 0, err
+	}
+	return c.PrivLevel(), nil
+}
+
+// GetChannelAccess returns the access settings and privilege limit of a channel, from either
+// ChannelAccessNonVolatile storage or the active ChannelAccessVolatile settings
+func (c *Client) GetChannelAccess(channel, store uint8) (*ChannelAccess, error) {
+	return c.conn.getChannelAccess(channel, store)
+}
+
+// SetChannelAccess sets the access settings and privilege limit of a channel. Store may be
+// ChannelAccessNonVolatile, ChannelAccessVolatile, or both to apply the settings immediately and
+// persistently.
+func (c *Client) SetChannelAccess(channel, store uint8, access *ChannelAccess) error {
+	return c.conn.setChannelAccess(channel, store, access)
+}
+
+// GetUserAccess returns the access settings and privilege limit of a user on a channel, and the
+// number of user IDs supported and enabled
+func (c *Client) GetUserAccess(channel, userID uint8) (*UserAccess, error) {
+	return c.conn.getUserAccess(channel, userID)
+}
+
+// SetUserAccess sets the callback, link authentication and IPMI messaging settings and privilege
+// limit of a user on a channel. The remaining fields of access are ignored.
+func (c *Client) SetUserAccess(channel, userID uint8, access *UserAccess) error {
+	return c.conn.setUserAccess(channel, userID, access)
+}
+
+// GetUserName returns the user name of a user ID, which is empty if the user ID is not in use
+func (c *Client) GetUserName(userID uint8) (string, error) {
+	return c.conn.getUserName(userID)
+}
+
+// SetUserName sets the user name of a user ID, of up to 16 bytes
+func (c *Client) SetUserName(userID uint8, name string) error {
+	return c.conn.setUserName(userID, name)
+}
+
+// SetUserPassword sets the password of a user ID, in the 20 byte format if size20 is set, which is
+// required for passwords longer than 16 bytes
+func (c *Client) SetUserPassword(userID uint8, password string, size20 bool) error {
+	return c.conn.setUserPassword(&SetUserPasswordRequest{userID, UserSetPassword, password, size20})
+}
+
+// TestUserPassword checks the password of a user ID, returning ErrPasswordMismatch if it is
+// incorrect, or ErrPasswordSize if the password is stored in the other format.
+func (c *Client) TestUserPassword(userID uint8, password string, size20 bool) error {
+	return c.conn.setUserPassword(&SetUserPasswordRequest{userID, UserTestPassword, password, size20})
+}
+
+// EnableUser enables or disables a user ID
+func (c *Client) EnableUser(userID uint8, enable bool) error {
+	op := uint8(UserDisable)
+	if enable {
+		op = UserEnable
+	}
+	return c.conn.setUserPassword(&SetUserPasswordRequest{UserID: userID, Operation: op})
+}
+
+// GetUserPayloadAccess returns the payload types that a user may activate on a channel
+func (c *Client) GetUserPayloadAccess(channel, userID uint8) (*UserPayloadAccess, error) {
+	return c.conn.getUserPayloadAccess(channel, userID)
+}
+
+// GetFRUInventoryAreaInfo returns the size of a FRU inventory device
+func (c *Client) GetFRUInventoryAreaInfo(deviceID uint8) (*FRUInventoryAreaInfo, error) {
+	return c.conn.getFRUInventoryAreaInfo(deviceID)
+}
+
+// ReadFRUData reads raw data from a FRU inventory device. Offset and count are in words for
+// devices accessed by words.
+func (c *Client) ReadFRUData(deviceID uint8, offset uint16, count uint8) ([]byte, error) {
+	return c.conn.readFRUData(deviceID, offset, count)
+}
+
+// ReadFRU reads and decodes a FRU inventory device. Device ID zero is the BMC's own FRU device,
+// other logical FRU devices are described by FRU device locator records in the SDR repository.
+func (c *Client) ReadFRU(deviceID uint8) (*FRU, error) {
+	return c.conn.readFRUInventory(deviceID)
+}
+
+// GetSDRRepositoryInfo returns information about the BMC's SDR repository
+func (c *Client) GetSDRRepositoryInfo() (*SDRRepositoryInfo, error) {
+	return c.conn.getSDRRepositoryInfo()
+}
+
+// ReserveSDRRepository obtains a reservation ID for reading SDR records
+func (c *Client) ReserveSDRRepository() (uint16, error) {
+	return c.conn.reserveSDRRepository()
+}
+
+// GetSDR reads and decodes a single SDR record, returning the record and the ID of the next
+// record. A record ID of 0xffff indicates the last record.
+func (c *Client) GetSDR(reservationID, recordID uint16) (SDRRecord, uint16, error) {
+	next, b, _, err := c.conn.getSDR(reservationID, recordID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r, err := decodeSDR(b)
+	return r, next, err
+}
+
+// ReadSDRRepository reads and decodes all records in the BMC's SDR repository
+func (c *Client) ReadSDRRepository() ([]SDRRecord, error) {
+	return c.conn.readSDRRepository()
+}
+
+// GetSensorReading returns the raw reading and state of a sensor of LUN lun
+func (c *Client) GetSensorReading(lun, sensorNumber uint8) (*SensorReading, error) {
+	return c.conn.getSensorReading(lun, sensorNumber)
+}
+
+// GetSensorReadingFactors returns the conversion factors of a non-linear sensor of LUN lun for a
+// raw reading
+func (c *Client) GetSensorReadingFactors(lun, sensorNumber, raw uint8) (*SensorReadingFactors, error) {
+	return c.conn.getSensorReadingFactors(lun, sensorNumber, raw)
+}
+
+// ReadSensor reads the sensor described by a full or compact sensor record, converting analog
+// readings to engineering units. Sensors owned by a satellite controller are read by bridging to
+// it, or returning ErrSensorOwner if c is itself bridged to another controller.
+func (c *Client) ReadSensor(rec SDRRecord) (*SensorValue, error) {
+	return c.conn.readSensor(rec)
+}
+
+// GetChassisStatus returns the chassis power state, last power event and miscellaneous state
+func (c *Client) GetChassisStatus() (*ChassisStatus, error) {
+	return c.conn.getChassisStatus()
+}
+
+// ChassisControl performs a chassis power or reset action, e.g. ChassisPowerCycle
+func (c *Client) ChassisControl(action uint8) error {
+	return c.conn.chassisControl(action)
+}
+
+// ChassisIdentify turns the chassis identify indicator on for the specified number of seconds, or
+// off if zero. If force is set, the indicator is turned on indefinitely.
+func (c *Client) ChassisIdentify(seconds uint8, force bool) error {
+	return c.conn.chassisIdentify(seconds, force)
+}
+
+// SetBootDevice overrides the boot device for the next boot, or all future boots if persistent is
+// set, in EFI or legacy mode.
+func (c *Client) SetBootDevice(device uint8, persistent, efi bool) error {
+	return c.conn.setBootFlags(&BootFlags{
+		Valid:      true,
+		Persistent: persistent,
+		EFI:        efi,
+		Device:     device,
+	})
+}
+
+// SetBootFlags writes the boot flags parameter of the system boot options
+func (c *Client) SetBootFlags(flags *BootFlags) error {
+	return c.conn.setBootFlags(flags)
+}
+
+// GetBootFlags reads the boot flags parameter of the system boot options
+func (c *Client) GetBootFlags() (*BootFlags, error) {
+	return c.conn.getBootFlags()
+}
+
+// SetSystemBootOption sets a raw system boot option parameter
+func (c *Client) SetSystemBootOption(param uint8, data []byte) error {
+	return c.conn.setSystemBootOption(param, data)
+}
+
+// GetSystemBootOption reads a raw system boot option parameter
+func (c *Client) GetSystemBootOption(param, set, block uint8) ([]byte, error) {
+	return c.conn.getSystemBootOption(param, set, block)
+}
+
+// GetLANConfig returns the decoded LAN configuration parameters of a channel
+func (c *Client) GetLANConfig(channel uint8) (*LANConfig, error) {
+	return c.conn.getLANConfig(channel)
+}
+
+// SetLANConfig writes LAN configuration parameters of a channel, within a single set in progress
+// interval
+func (c *Client) SetLANConfig(channel uint8, params ...LANConfigParam) error {
+	return c.conn.setLANConfig(channel, params)
+}
+
+// SetLANConfigParam sets a raw LAN configuration parameter
+func (c *Client) SetLANConfigParam(channel, param uint8, data []byte) error {
+	return c.conn.setLANConfigParam(channel, param, data)
+}
+
+// GetLANConfigParam reads a raw LAN configuration parameter
+func (c *Client) GetLANConfigParam(channel, param, set, block uint8) ([]byte, error) {
+	return c.conn.getLANConfigParam(channel, param, set, block)
+}
+
+// GetSELInfo returns information about the System Event Log, including the number of entries
+func (c *Client) GetSELInfo() (*SELInfo, error) {
+	return c.conn.getSELInfo()
+}
+
+// ReserveSEL obtains a SEL reservation ID, required for partial reads and clearing the SEL
+func (c *Client) ReserveSEL() (uint16, error) {
+	return c.conn.reserveSEL()
+}
+
+// GetSELEntry reads a single SEL record, returning the record and the ID of the next record
+func (c *Client) GetSELEntry(reservationID, recordID uint16) (*SELEntry, uint16, error) {
+	next, e, err := c.conn.getSELEntry(reservationID, recordID)
+	return e, next, err
+}
+
+// ReadSEL reads all records in the System Event Log, oldest first
+func (c *Client) ReadSEL() ([]*SELEntry, error) {
+	return c.conn.readSEL()
+}
+
+// ClearSEL erases the System Event Log, waiting for the erasure to complete
+func (c *Client) ClearSEL() error {
+	return c.conn.clearSEL()
+}
+
+// GetSELTime reads the SEL time clock, used to timestamp events
+func (c *Client) GetSELTime() (time.Time, error) {
+	return c.conn.getSELTime()
+}
+
+// SetSELTime sets the SEL time clock
+func (c *Client) SetSELTime(t time.Time) error {
+	return c.conn.setSELTime(t)
+}
+
+// ActivateSOL activates a Serial over LAN payload instance on the session, which must have been
+// opened with IPMI v2.0. The payload is deactivated by closing the returned SOL.
+func (c *Client) ActivateSOL(instance uint8) (*SOL, error) {
+	l, err := c.lan()
+	if err != nil {
+		return nil, err
+	}
+	return l.activateSOL(instance)
+}
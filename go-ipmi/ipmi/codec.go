@@ -0,0 +1,132 @@
+package ipmi
+
+// Request and response data are encoded by MarshalBinary and UnmarshalBinary methods on each type,
+// rather than by reflection, so that optional trailing fields, variable-length fields and bitfields
+// can be represented. Response data excludes the completion code, which is checked by SendRecv.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Errors decoding packets and data received from the BMC. The completion codes ErrShortPacket and
+// ErrInvalidPacket are only returned by the BMC, for requests that it could not decode.
+var (
+	ErrShortData   = errors.New("data too short")
+	ErrInvalidData = errors.New("invalid data")
+)
+
+// rawRequest is request data that is already encoded
+type rawRequest []byte
+
+func (r rawRequest) MarshalBinary() ([]byte, error) {
+	return r, nil
+}
+
+// decoder reads little-endian fields from response data. Reading beyond the end of the data
+// records ErrShortData, and subsequent reads return zero values.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+// next consumes n bytes, or returns nil if fewer remain
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if len(d.b) < n {
+		d.err = ErrShortData
+		return nil
+	}
+
+	b := d.b[:n]
+	d.b = d.b[n:]
+
+	return b
+}
+
+func (d *decoder) uint8() uint8 {
+	if b := d.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint16() uint16 {
+	if b := d.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+// uint24 reads a three byte field, such as an IANA enterprise number
+func (d *decoder) uint24() uint32 {
+	if b := d.next(3); b != nil {
+		return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+	}
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// bytes reads a fixed-length field into b
+func (d *decoder) bytes(b []byte) {
+	if src := d.next(len(b)); src != nil {
+		copy(b, src)
+	}
+}
+
+// rest consumes the remaining data, for a trailing variable-length field
+func (d *decoder) rest() []byte {
+	b := d.b
+	d.b = nil
+
+	if d.err != nil {
+		return nil
+	}
+
+	return append([]byte(nil), b...)
+}
+
+// padded reads a fixed-length string field, padded with trailing null bytes
+func (d *decoder) padded(n int) string {
+	return string(bytes.TrimRight(d.next(n), "\x00"))
+}
+
+// more reports whether optional trailing fields are present
+func (d *decoder) more() bool {
+	return d.err == nil && len(d.b) > 0
+}
+
+// appendUint24 appends a three byte field, such as an IANA enterprise number
+func appendUint24(b []byte, v uint32) []byte {
+	return append(b, uint8(v), uint8(v>>8), uint8(v>>16))
+}
+
+// appendPadded appends a fixed-length string field, padded with null bytes to size
+func appendPadded(b []byte, name, s string, size int) ([]byte, error) {
+	if len(s) > size {
+		return nil, fmt.Errorf("%s longer than %d bytes", name, size)
+	}
+
+	b = append(b, s...)
+
+	return append(b, make([]byte, size-len(s))...), nil
+}
+
+// bit returns mask if v is set, for encoding boolean bitfields
+func bit(v bool, mask uint8) uint8 {
+	if v {
+		return mask
+	}
+	return 0
+}
@@ -0,0 +1,397 @@
+package ipmi
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type binaryCodec interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// Request and response data as sent on the wire, excluding completion codes
+var codecTests = []struct {
+	name    string
+	data    []byte
+	want    binaryCodec
+	encoded []byte // Encoding of want, if different from data due to omitted optional fields
+}{
+	{
+		name: "device ID",
+		data: []byte{0x20, 0x81, 0x02, 0x61, 0x02, 0xbf, 0x57, 0x01, 0x00, 0x4a, 0x00, 0x01, 0x00, 0x00, 0x00},
+		want: &DeviceID{
+			DeviceID:          0x20,
+			DeviceRevision:    1,
+			ProvidesSDRs:      true,
+			FirmwareMajor:     2,
+			FirmwareMinor:     0x61,
+			IPMIVersion:       IPMIVersion20,
+			AdditionalSupport: 0xbf,
+			ManufacturerID:    343,
+			ProductID:         0x004a,
+			AuxFirmware:       []byte{0x01, 0x00, 0x00, 0x00},
+		},
+	},
+	{
+		name: "device ID without auxiliary firmware revision",
+		data: []byte{0x20, 0x01, 0x81, 0x23, 0x51, 0x83, 0xa2, 0x02, 0x00, 0x00, 0x01},
+		want: &DeviceID{
+			DeviceID:          0x20,
+			DeviceRevision:    1,
+			UpdateInProgress:  true,
+			FirmwareMajor:     1,
+			FirmwareMinor:     0x23,
+			IPMIVersion:       IPMIVersion15,
+			AdditionalSupport: 0x83,
+			ManufacturerID:    674,
+			ProductID:         0x0100,
+		},
+	},
+	{
+		name: "self test result",
+		data: []byte{0x57, 0x48},
+		want: &SelfTestResult{SelfTestCorrupted, SelfTestSDRRepository | SelfTestSDREmpty},
+	},
+	{
+		name: "GUID",
+		data: []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+		want: &GUID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	},
+	{
+		name: "channel access request",
+		data: []byte{0x01, 0x80},
+		want: &ChannelAccessRequest{1, ChannelAccessVolatile},
+	},
+	{
+		name: "channel access",
+		data: []byte{0x22, 0x04},
+		want: &ChannelAccess{AlertingDisabled: true, AccessMode: AccessModeAlways, PrivLimit: PrivLevelAdmin},
+	},
+	{
+		name: "set channel access request",
+		data: []byte{0x01, 0x5a, 0x43},
+		want: &SetChannelAccessRequest{1, ChannelAccessNonVolatile, ChannelAccess{
+			PerMsgAuthDisabled: true, UserAuthDisabled: true, AccessMode: AccessModeAlways, PrivLimit: PrivLevelOperator,
+		}},
+	},
+	{
+		name: "user access request",
+		data: []byte{0x0e, 0x03},
+		want: &UserAccessRequest{CurrentChannel, 3},
+	},
+	{
+		name: "user access",
+		data: []byte{0x0a, 0x43, 0x01, 0x34},
+		want: &UserAccess{
+			MaxUsers:      10,
+			EnabledUsers:  3,
+			Status:        UserStatusEnabled,
+			FixedNames:    1,
+			LinkAuth:      true,
+			IPMIMessaging: true,
+			PrivLimit:     PrivLevelAdmin,
+		},
+	},
+	{
+		name: "set user access request",
+		data: []byte{0x91, 0x04, 0x0f},
+		want: &SetUserAccessRequest{ChannelNumber: 1, UserID: 4, IPMIMessaging: true, PrivLimit: PrivLevelNoAccess},
+	},
+	{
+		name: "set user name request",
+		data: append([]byte{0x04, 'b', 'o', 'b'}, make([]byte, 13)...),
+		want: &SetUserNameRequest{4, "bob"},
+	},
+	{
+		name: "get user name response",
+		data: append([]byte{'b', 'o', 'b'}, make([]byte, 13)...),
+		want: &GetUserNameResponse{"bob"},
+	},
+	{
+		name: "set user password request 20 bytes",
+		data: append([]byte{0x84, 0x02, 's', 'e', 'c', 'r', 'e', 't'}, make([]byte, 14)...),
+		want: &SetUserPasswordRequest{4, UserSetPassword, "secret", true},
+	},
+	{
+		name: "enable user request",
+		data: []byte{0x04, 0x01},
+		want: &SetUserPasswordRequest{UserID: 4, Operation: UserEnable},
+	},
+	{
+		name: "user payload access",
+		data: []byte{0x02, 0x00, 0x01, 0x00},
+		want: &UserPayloadAccess{Standard: 1 << payloadTypeSOL, OEM: 0x01},
+	},
+	{
+		name: "auth capabilities request",
+		data: []byte{0x8e, 0x04},
+		want: &AuthCapabilitiesRequest{ExtendedData: true, ChannelNumber: 0x0e, PrivLevel: PrivLevelAdmin},
+	},
+	{
+		name: "auth capabilities response IPMI v2.0",
+		data: []byte{0x01, 0x97, 0x24, 0x03, 0x57, 0x01, 0x00, 0x00},
+		want: &AuthCapabilitiesResponse{
+			ChannelNumber:   1,
+			ExtendedData:    true,
+			AuthTypes:       1<<AuthTypeNone | 1<<AuthTypeMD2 | 1<<AuthTypeMD5 | 1<<AuthTypePassword,
+			KGNonZero:       true,
+			NonNullUsers:    true,
+			ExtCapabilities: ExtCapIPMIv15 | ExtCapIPMIv20,
+			OEMID:           0x157,
+		},
+	},
+	{
+		name: "auth capabilities response IPMI v1.5 without OEM fields",
+		data: []byte{0x01, 0x16, 0x16},
+		want: &AuthCapabilitiesResponse{
+			ChannelNumber:      1,
+			AuthTypes:          1<<AuthTypeMD2 | 1<<AuthTypeMD5 | 1<<AuthTypePassword,
+			PerMsgAuthDisabled: true,
+			NonNullUsers:       true,
+			NullUsers:          true,
+		},
+		encoded: []byte{0x01, 0x16, 0x16, 0x00, 0x00, 0x00, 0x00, 0x00},
+	},
+	{
+		name: "session challenge request",
+		data: append([]byte{0x02, 'a', 'd', 'm', 'i', 'n'}, make([]byte, 11)...),
+		want: &SessionChallengeRequest{AuthTypeMD5, "admin"},
+	},
+	{
+		name: "session challenge response",
+		data: []byte{0x78, 0x56, 0x34, 0x12, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+		want: &SessionChallengeResponse{0x12345678, [16]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}},
+	},
+	{
+		name: "activate session request",
+		data: []byte{0x02, 0x04, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0, 0x01, 0x00, 0x00, 0x80},
+		want: &ActivateSessionRequest{AuthTypeMD5, PrivLevelAdmin, [16]byte{15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0}, 0x80000001},
+	},
+	{
+		name: "activate session response",
+		data: []byte{0x02, 0x78, 0x56, 0x34, 0x12, 0x0a, 0x00, 0x00, 0x00, 0x03},
+		want: &ActivateSessionResponse{AuthTypeMD5, 0x12345678, 10, PrivLevelOperator},
+	},
+	{
+		name: "session privilege level",
+		data: []byte{0x04},
+		want: &SessionPrivLevel{PrivLevelAdmin},
+	},
+	{
+		name: "close session request",
+		data: []byte{0x78, 0x56, 0x34, 0x12},
+		want: &CloseSessionRequest{0x12345678},
+	},
+	{
+		name: "channel cipher suites request",
+		data: []byte{0x0e, 0x00, 0x82},
+		want: &ChannelCipherSuitesRequest{0x0e, payloadTypeIPMI, true, 2},
+	},
+	{
+		name: "channel cipher suites response",
+		data: []byte{0x01, 0xc0, 0x03, 0x01, 0x41, 0x81},
+		want: &ChannelCipherSuitesResponse{1, []byte{0xc0, 0x03, 0x01, 0x41, 0x81}},
+	},
+	{
+		name: "SDR repository info",
+		data: []byte{0x51, 0x06, 0x00, 0x00, 0x10, 0x00, 0x00, 0x00, 0x60, 0xff, 0xff, 0xff, 0xff, 0x02},
+		want: &SDRRepositoryInfo{0x51, 6, 0x1000, 0x60000000, 0xffffffff, 0x02},
+	},
+	{
+		name: "reserve SDR repository response",
+		data: []byte{0x34, 0x12},
+		want: &ReserveSDRRepositoryResponse{0x1234},
+	},
+	{
+		name: "get SDR request",
+		data: []byte{0x34, 0x12, 0x02, 0x00, 0x05, 0xff},
+		want: &GetSDRRequest{0x1234, 2, 5, 0xff},
+	},
+	{
+		name: "get SDR response",
+		data: []byte{0x03, 0x00, 0x02, 0x00, 0x51, 0x02, 0x2b},
+		want: &GetSDRResponse{3, []byte{0x02, 0x00, 0x51, 0x02, 0x2b}},
+	},
+	{
+		name: "get SDR response last record",
+		data: []byte{0xff, 0xff},
+		want: &GetSDRResponse{0xffff, nil},
+	},
+	{
+		name: "SEL info",
+		data: []byte{0x51, 0x04, 0x00, 0xc0, 0x3f, 0x00, 0x00, 0x00, 0x60, 0x00, 0x00, 0x00, 0x60, 0x0a},
+		want: &SELInfo{0x51, 4, 0x3fc0, 0x60000000, 0x60000000, 0x0a},
+	},
+	{
+		name: "reserve SEL response",
+		data: []byte{0x01, 0x00},
+		want: &ReserveSELResponse{1},
+	},
+	{
+		name: "get SEL entry request",
+		data: []byte{0x00, 0x00, 0x01, 0x00, 0x00, 0xff},
+		want: &GetSELEntryRequest{0, 1, 0, 0xff},
+	},
+	{
+		name: "get SEL entry response",
+		data: []byte{0x02, 0x00, 0x01, 0x00, 0x02},
+		want: &GetSELEntryResponse{2, []byte{0x01, 0x00, 0x02}},
+	},
+	{
+		name: "FRU inventory area info",
+		data: []byte{0x00, 0x01, 0x01},
+		want: &FRUInventoryAreaInfo{256, true},
+	},
+	{
+		name: "chassis status",
+		data: []byte{0x21, 0x10, 0x51, 0x0f},
+		want: &ChassisStatus{
+			PowerOn:              true,
+			PowerRestorePolicy:   PowerRestorePolicyPrevious,
+			LastPowerOnByCommand: true,
+			Intrusion:            true,
+			IdentifyState:        IdentifyTemporary,
+			IdentifySupported:    true,
+			FrontPanelButtons:    func() *uint8 { b := uint8(0x0f); return &b }(),
+		},
+	},
+	{
+		name: "chassis status without front panel buttons",
+		data: []byte{0x0c, 0x01, 0x0e},
+		want: &ChassisStatus{
+			PowerInterlock:    true,
+			PowerFault:        true,
+			LastACFailed:      true,
+			FrontPanelLockout: true,
+			DriveFault:        true,
+			CoolingFault:      true,
+		},
+	},
+	{
+		name: "boot flags",
+		data: []byte{0xa0, 0x19, 0x41, 0x00, 0x03},
+		want: &BootFlags{
+			Valid:              true,
+			EFI:                true,
+			Device:             BootDeviceBIOS,
+			LockResetButton:    true,
+			Verbosity:          2,
+			ConsoleRedirection: 1,
+			DeviceInstance:     3,
+		},
+	},
+	{
+		name: "set system boot options request",
+		data: []byte{0x05, 0x80, 0x08, 0x00, 0x00, 0x00},
+		want: &SetSystemBootOptionsRequest{BootParamFlags, []byte{0x80, 0x08, 0x00, 0x00, 0x00}},
+	},
+	{
+		name: "get system boot options request",
+		data: []byte{0x05, 0x00, 0x00},
+		want: &GetSystemBootOptionsRequest{BootParamFlags, 0, 0},
+	},
+	{
+		name: "get system boot options response",
+		data: []byte{0x01, 0x85, 0xa0, 0x19, 0x41, 0x00, 0x03},
+		want: &GetSystemBootOptionsResponse{1, true, BootParamFlags, []byte{0xa0, 0x19, 0x41, 0x00, 0x03}},
+	},
+	{
+		name: "sensor reading",
+		data: []byte{0x7f, 0xc0, 0x12, 0x03},
+		want: &SensorReading{Raw: 0x7f, States: 0x0312},
+	},
+	{
+		name:    "sensor reading without states",
+		data:    []byte{0x00, 0x20},
+		want:    &SensorReading{EventMessagesDisabled: true, ScanningDisabled: true, Unavailable: true},
+		encoded: []byte{0x00, 0x20, 0x00, 0x00},
+	},
+	{
+		name: "get sensor reading factors response",
+		data: []byte{0x80, 0xfe, 0xc0, 0x2c, 0x40, 0x00, 0xd2},
+		want: &GetSensorReadingFactorsResponse{0x80, SensorReadingFactors{M: -2, B: 300, BExp: 2, RExp: -3}},
+	},
+	{
+		name: "set LAN configuration parameters request",
+		data: []byte{0x01, 0x04, 0x02},
+		want: &SetLANConfigParamsRequest{1, IPSourceParam(IPSourceDHCP)},
+	},
+	{
+		name: "get LAN configuration parameters request",
+		data: []byte{0x01, 0x03, 0x00, 0x00},
+		want: &GetLANConfigParamsRequest{1, LANParamIPAddress, 0, 0},
+	},
+	{
+		name: "get LAN configuration parameters response",
+		data: []byte{0x11, 192, 168, 0, 10},
+		want: &GetLANConfigParamsResponse{0x11, []byte{192, 168, 0, 10}},
+	},
+	{
+		name: "clear SEL request",
+		data: []byte{0x34, 0x12, 'C', 'L', 'R', 0xaa},
+		want: &ClearSELRequest{0x1234, selClearInitiate},
+	},
+	{
+		name: "clear SEL response",
+		data: []byte{0x01},
+		want: &ClearSELResponse{selEraseCompleted},
+	},
+	{
+		name: "SEL time",
+		data: []byte{0x00, 0x00, 0x00, 0x65},
+		want: &SELTimestamp{0x65000000},
+	},
+}
+
+func TestCodec(t *testing.T) {
+	for _, tt := range codecTests {
+		got := reflect.New(reflect.TypeOf(tt.want).Elem()).Interface().(binaryCodec)
+
+		if err := got.UnmarshalBinary(tt.data); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: decoded %+v, expected %+v", tt.name, got, tt.want)
+		}
+
+		encoded := tt.encoded
+		if encoded == nil {
+			encoded = tt.data
+		}
+
+		if b, err := tt.want.MarshalBinary(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		} else if !bytes.Equal(b, encoded) {
+			t.Errorf("%s: encoded % x, expected % x", tt.name, b, encoded)
+		}
+	}
+}
+
+func TestCodecShort(t *testing.T) {
+	// All types have at least one required field
+	for _, tt := range codecTests {
+		got := reflect.New(reflect.TypeOf(tt.want).Elem()).Interface().(binaryCodec)
+
+		if err := got.UnmarshalBinary(nil); !errors.Is(err, ErrShortData) {
+			t.Errorf("%s: expected short packet error, got %v", tt.name, err)
+		}
+	}
+
+	if _, err := (&SessionChallengeRequest{Username: "a username that is too long"}).MarshalBinary(); err == nil {
+		t.Error("encoded username longer than 16 bytes")
+	}
+
+	if _, err := (&SetUserPasswordRequest{1, UserSetPassword, "twenty byte password", false}).MarshalBinary(); err == nil {
+		t.Error("encoded 20 byte password in 16 byte format")
+	}
+
+	if err := (&ClearSELRequest{}).UnmarshalBinary([]byte{0x34, 0x12, 'X', 'X', 'X', 0xaa}); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("expected invalid data error for Clear SEL request without CLR, got %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+package ipmi
+
+import (
+	"encoding/binary"
+)
+
+// Command Number Assignments (table G-1)
+const (
+	// IPM device "global" commands
+	CmdGetDeviceID        = 0x01
+	CmdGetSelfTestResults = 0x04
+	CmdGetDeviceGUID      = 0x08
+
+	// BMC device and messaging commands
+	CmdGetMessage                 = 0x33
+	CmdSendMessage                = 0x34
+	CmdGetSystemGUID              = 0x37
+	CmdGetChannelAuthCapabilities = 0x38
+	CmdGetSessionChallenge        = 0x39
+	CmdActivateSession            = 0x3a
+	CmdSetSessionPrivLevel        = 0x3b
+	CmdCloseSession               = 0x3c
+	CmdSetChannelAccess           = 0x40
+	CmdGetChannelAccess           = 0x41
+	CmdSetUserAccess              = 0x43
+	CmdGetUserAccess              = 0x44
+	CmdSetUserName                = 0x45
+	CmdGetUserName                = 0x46
+	CmdSetUserPassword            = 0x47
+	CmdActivatePayload            = 0x48
+	CmdDeactivatePayload          = 0x49
+	CmdGetUserPayloadAccess       = 0x4f
+	CmdGetChannelCipherSuites     = 0x54
+
+	// Chassis device commands
+	CmdGetChassisStatus     = 0x01
+	CmdChassisControl       = 0x02
+	CmdChassisIdentify      = 0x04
+	CmdSetSystemBootOptions = 0x08
+	CmdGetSystemBootOptions = 0x09
+
+	// Sensor device commands
+	CmdGetDeviceSDRInfo        = 0x20
+	CmdGetSensorReadingFactors = 0x23
+	CmdGetSensorReading        = 0x2d
+
+	// FRU inventory device commands
+	CmdGetFRUInventoryAreaInfo = 0x10
+	CmdReadFRUData             = 0x11
+
+	// SDR repository commands
+	CmdGetSDRRepositoryInfo = 0x20
+	CmdReserveSDRRepository = 0x22
+	CmdGetSDR               = 0x23
+
+	// SEL device commands
+	CmdGetSELInfo  = 0x40
+	CmdReserveSEL  = 0x42
+	CmdGetSELEntry = 0x43
+	CmdClearSEL    = 0x47
+	CmdGetSELTime  = 0x48
+	CmdSetSELTime  = 0x49
+
+	// LAN device commands
+	CmdSetLANConfigParams = 0x01
+	CmdGetLANConfigParams = 0x02
+)
+
+// Privilege levels
+const (
+	PrivLevelUnspecified = iota
+	PrivLevelCallback
+	PrivLevelUser
+	PrivLevelOperator
+	PrivLevelAdmin
+	PrivLevelOEM
+	PrivLevelNoAccess = 0x0f // User privilege limit denying access to a channel
+)
+
+// CurrentChannel refers to the channel that a request is received on
+const CurrentChannel = 0x0e
+
+// Request is a command sent to the BMC, with its data already encoded, typically by the
+// MarshalBinary method of a request type
+type Request struct {
+	NetworkFunction uint8
+	Command         uint8
+	Data            []byte
+	LUN             uint8 // Responder LUN, for sensors owned by LUN 1 - 3 of a controller
+}
+
+// AuthCapabilitiesRequest per section 22.13
+type AuthCapabilitiesRequest struct {
+	ExtendedData  bool // Request IPMI v2.0 extended capabilities
+	ChannelNumber uint8
+	PrivLevel     uint8
+}
+
+func (r *AuthCapabilitiesRequest) MarshalBinary() ([]byte, error) {
+	return []byte{bit(r.ExtendedData, 0x80) | r.ChannelNumber&0x0f, r.PrivLevel & 0x0f}, nil
+}
+
+func (r *AuthCapabilitiesRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	channel, priv := d.uint8(), d.uint8()
+
+	*r = AuthCapabilitiesRequest{channel&0x80 != 0, channel & 0x0f, priv & 0x0f}
+
+	return d.err
+}
+
+// Status bits in AuthCapabilitiesResponse
+const (
+	authStatusKGNonZero          = 1 << 5
+	authStatusPerMsgAuthDisabled = 1 << 4
+	authStatusUserAuthDisabled   = 1 << 3
+	authStatusNonNullUsers       = 1 << 2
+	authStatusNullUsers          = 1 << 1
+	authStatusAnonymousLogin     = 1 << 0
+)
+
+// AuthCapabilitiesResponse per section 22.13. IPMI v1.5 BMCs may omit the extended capabilities
+// and OEM fields.
+type AuthCapabilitiesResponse struct {
+	ChannelNumber      uint8
+	ExtendedData       bool  // Extended capabilities are valid
+	AuthTypes          uint8 // Supported IPMI v1.5 authentication types, as 1 << AuthType
+	KGNonZero          bool  // IPMI v2.0 key K_G is set to a non-zero value
+	PerMsgAuthDisabled bool
+	UserAuthDisabled   bool // User level authentication disabled
+	NonNullUsers       bool // Non-null user names enabled
+	NullUsers          bool // Null user names with non-null passwords enabled
+	AnonymousLogin     bool // Null user name and password enabled
+	ExtCapabilities    uint8
+	OEMID              uint32 // IANA enterprise number of OEM authentication type
+	OEMAux             uint8
+}
+
+func (r *AuthCapabilitiesResponse) MarshalBinary() ([]byte, error) {
+	b := []byte{
+		r.ChannelNumber,
+		bit(r.ExtendedData, 0x80) | r.AuthTypes&0x3f,
+		bit(r.KGNonZero, authStatusKGNonZero) | bit(r.PerMsgAuthDisabled, authStatusPerMsgAuthDisabled) |
+			bit(r.UserAuthDisabled, authStatusUserAuthDisabled) | bit(r.NonNullUsers, authStatusNonNullUsers) |
+			bit(r.NullUsers, authStatusNullUsers) | bit(r.AnonymousLogin, authStatusAnonymousLogin),
+		r.ExtCapabilities,
+	}
+
+	b = appendUint24(b, r.OEMID)
+
+	return append(b, r.OEMAux), nil
+}
+
+func (r *AuthCapabilitiesResponse) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	channel, authTypes, status := d.uint8(), d.uint8(), d.uint8()
+
+	*r = AuthCapabilitiesResponse{
+		ChannelNumber:      channel,
+		ExtendedData:       authTypes&0x80 != 0,
+		AuthTypes:          authTypes & 0x3f,
+		KGNonZero:          status&authStatusKGNonZero != 0,
+		PerMsgAuthDisabled: status&authStatusPerMsgAuthDisabled != 0,
+		UserAuthDisabled:   status&authStatusUserAuthDisabled != 0,
+		NonNullUsers:       status&authStatusNonNullUsers != 0,
+		NullUsers:          status&authStatusNullUsers != 0,
+		AnonymousLogin:     status&authStatusAnonymousLogin != 0,
+	}
+
+	if d.more() {
+		r.ExtCapabilities = d.uint8()
+	}
+
+	if d.more() {
+		r.OEMID, r.OEMAux = d.uint24(), d.uint8()
+	}
+
+	return d.err
+}
+
+// Extended capabilities in AuthCapabilitiesResponse
+const (
+	ExtCapIPMIv15 = 1 << 0
+	ExtCapIPMIv20 = 1 << 1
+)
+
+// SessionChallengeRequest per section 22.16
+type SessionChallengeRequest struct {
+	AuthType uint8
+	Username string // Up to 16 bytes
+}
+
+func (r *SessionChallengeRequest) MarshalBinary() ([]byte, error) {
+	return appendPadded([]byte{r.AuthType & 0x0f}, "username", r.Username, 16)
+}
+
+func (r *SessionChallengeRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.AuthType = d.uint8() & 0x0f
+	r.Username = d.padded(16)
+	return d.err
+}
+
+// SessionChallengeResponse per section 22.16
+type SessionChallengeResponse struct {
+	TemporarySessionID uint32
+	Challenge          [16]byte
+}
+
+func (r *SessionChallengeResponse) MarshalBinary() ([]byte, error) {
+	return append(binary.LittleEndian.AppendUint32(nil, r.TemporarySessionID), r.Challenge[:]...), nil
+}
+
+func (r *SessionChallengeResponse) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.TemporarySessionID = d.uint32()
+	d.bytes(r.Challenge[:])
+
+	return d.err
+}
+
+// ActivateSessionRequest per section 22.17
+type ActivateSessionRequest struct {
+	AuthType           uint8
+	PrivLevel          uint8
+	Challenge          [16]byte
+	InitialOutboundSeq uint32
+}
+
+func (r *ActivateSessionRequest) MarshalBinary() ([]byte, error) {
+	b := append([]byte{r.AuthType & 0x0f, r.PrivLevel & 0x0f}, r.Challenge[:]...)
+	return binary.LittleEndian.AppendUint32(b, r.InitialOutboundSeq), nil
+}
+
+func (r *ActivateSessionRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.AuthType = d.uint8() & 0x0f
+	r.PrivLevel = d.uint8() & 0x0f
+	d.bytes(r.Challenge[:])
+	r.InitialOutboundSeq = d.uint32()
+
+	return d.err
+}
+
+// ActivateSessionResponse per section 22.17
+type ActivateSessionResponse struct {
+	AuthType          uint8
+	SessionID         uint32
+	InitialInboundSeq uint32
+	MaxPrivLevel      uint8
+}
+
+func (r *ActivateSessionResponse) MarshalBinary() ([]byte, error) {
+	b := binary.LittleEndian.AppendUint32([]byte{r.AuthType & 0x0f}, r.SessionID)
+	b = binary.LittleEndian.AppendUint32(b, r.InitialInboundSeq)
+
+	return append(b, r.MaxPrivLevel&0x0f), nil
+}
+
+func (r *ActivateSessionResponse) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.AuthType = d.uint8() & 0x0f
+	r.SessionID = d.uint32()
+	r.InitialInboundSeq = d.uint32()
+	r.MaxPrivLevel = d.uint8() & 0x0f
+
+	return d.err
+}
+
+// SessionPrivLevel is the request and response of Set Session Privilege Level per section 22.18.
+// Privilege level zero requests the current level without changing it.
+type SessionPrivLevel struct {
+	PrivLevel uint8
+}
+
+func (r *SessionPrivLevel) MarshalBinary() ([]byte, error) {
+	return []byte{r.PrivLevel & 0x0f}, nil
+}
+
+func (r *SessionPrivLevel) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.PrivLevel = d.uint8() & 0x0f
+
+	return d.err
+}
+
+// CloseSessionRequest per section 22.19
+type CloseSessionRequest struct {
+	SessionID uint32
+}
+
+func (r *CloseSessionRequest) MarshalBinary() ([]byte, error) {
+	return binary.LittleEndian.AppendUint32(nil, r.SessionID), nil
+}
+
+func (r *CloseSessionRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.SessionID = d.uint32()
+
+	return d.err
+}
+
+// Authentication types
+const (
+	AuthTypeNone = iota
+	AuthTypeMD2
+	AuthTypeMD5
+	authTypeReserved
+	AuthTypePassword
+	AuthTypeOEM
+)
@@ -0,0 +1,216 @@
+package ipmi
+
+import (
+	"fmt"
+)
+
+// CompletionCode is the first byte of every response, indicating whether the command succeeded
+type CompletionCode uint8
+
+// Completion codes per section 5.2
+const (
+	CommandCompleted          = CompletionCode(0x00)
+	ErrNodeBusy               = CompletionCode(0xc0)
+	ErrInvalidCommand         = CompletionCode(0xc1)
+	ErrInvalidCommandForLUN   = CompletionCode(0xc2)
+	ErrCommandTimeout         = CompletionCode(0xc3)
+	ErrOutOfSpace             = CompletionCode(0xc4)
+	ErrReservationCanceled    = CompletionCode(0xc5)
+	ErrRequestTruncated       = CompletionCode(0xc6)
+	ErrShortPacket            = CompletionCode(0xc7)
+	ErrFieldLengthExceeded    = CompletionCode(0xc8)
+	ErrParamOutOfRange        = CompletionCode(0xc9)
+	ErrCannotReturnBytes      = CompletionCode(0xca)
+	ErrNotPresent             = CompletionCode(0xcb)
+	ErrInvalidPacket          = CompletionCode(0xcc)
+	ErrIllegalForSensorType   = CompletionCode(0xcd)
+	ErrResponseUnavailable    = CompletionCode(0xce)
+	ErrDuplicateRequest       = CompletionCode(0xcf)
+	ErrSDRUpdateMode          = CompletionCode(0xd0)
+	ErrFirmwareUpdateMode     = CompletionCode(0xd1)
+	ErrBMCInitializing        = CompletionCode(0xd2)
+	ErrDestinationUnavailable = CompletionCode(0xd3)
+	ErrInsufficientPriv       = CompletionCode(0xd4)
+	ErrNotSupportedInState    = CompletionCode(0xd5)
+	ErrSubFunctionDisabled    = CompletionCode(0xd6)
+	ErrUnspecified            = CompletionCode(0xff)
+)
+
+// Completion code definitions from table 5-2
+var completionCodes = map[CompletionCode]string{
+	CommandCompleted:          "Command completed normally",
+	ErrNodeBusy:               "Node busy",
+	ErrInvalidCommand:         "Invalid command",
+	ErrInvalidCommandForLUN:   "Command invalid for given LUN",
+	ErrCommandTimeout:         "Timeout while processing command",
+	ErrOutOfSpace:             "Out of space",
+	ErrReservationCanceled:    "Reservation canceled or invalid reservation ID",
+	ErrRequestTruncated:       "Request data truncated",
+	ErrShortPacket:            "Request data length invalid",
+	ErrFieldLengthExceeded:    "Request data field length limit exceeded",
+	ErrParamOutOfRange:        "Parameter out of range",
+	ErrCannotReturnBytes:      "Cannot return number of requested data bytes",
+	ErrNotPresent:             "Requested sensor, data, or record not present",
+	ErrInvalidPacket:          "Invalid data field in request",
+	ErrIllegalForSensorType:   "Command illegal for specified sensor or record type",
+	ErrResponseUnavailable:    "Command response could not be provided",
+	ErrDuplicateRequest:       "Cannot execute duplicated request",
+	ErrSDRUpdateMode:          "SDR repository in update mode",
+	ErrFirmwareUpdateMode:     "Device in firmware update mode",
+	ErrBMCInitializing:        "BMC initialization in progress",
+	ErrDestinationUnavailable: "Destination unavailable",
+	ErrInsufficientPriv:       "Insufficient privilege level",
+	ErrNotSupportedInState:    "Command not supported in present state",
+	ErrSubFunctionDisabled:    "Command sub-function has been disabled or is unavailable",
+	ErrUnspecified:            "Unspecified error",
+}
+
+// Command-specific completion codes (80h to BEh), by network function and command, from the
+// command definitions
+var commandCompletionCodes = map[[2]uint8]map[CompletionCode]string{
+	{NetFnApp, CmdGetSessionChallenge}: {
+		0x81: "Invalid user name",
+		0x82: "Null user name not enabled",
+	},
+	{NetFnApp, CmdActivateSession}: {
+		0x81: "No session slot available",
+		0x82: "No slot available for given user",
+		0x83: "No slot available to support user due to maximum privilege capability",
+		0x84: "Session sequence number out of range",
+		0x85: "Invalid session ID in request",
+		0x86: "Requested maximum privilege level exceeds user and/or channel privilege limit",
+	},
+	{NetFnApp, CmdSetSessionPrivLevel}: {
+		0x80: "Requested level not available for this user",
+		0x81: "Requested level exceeds channel and/or user privilege limit",
+		0x82: "Cannot disable user level authentication",
+	},
+	{NetFnApp, CmdCloseSession}: {
+		0x87: "Invalid session ID in request",
+		0x88: "Invalid session handle in request",
+	},
+	{NetFnApp, CmdGetMessage}: {
+		0x80: "Data not available (queue / buffer empty)",
+	},
+	{NetFnApp, CmdSendMessage}: {
+		0x80: "Invalid session handle",
+		0x81: "Lost arbitration",
+		0x82: "Bus error",
+		0x83: "NAK on write",
+	},
+	{NetFnApp, CmdSetChannelAccess}: {
+		0x82: "Set not supported on selected channel",
+		0x83: "Access mode not supported",
+	},
+	{NetFnApp, CmdGetChannelAccess}: {
+		0x82: "Command not supported for selected channel",
+	},
+	{NetFnApp, CmdSetUserPassword}: {
+		0x80: "Password test failed, password data does not match stored value",
+		0x81: "Password test failed, wrong password size was used",
+	},
+	{NetFnApp, CmdActivatePayload}: {
+		0x80: "Payload already active on another session",
+		0x81: "Payload type is disabled",
+		0x82: "Payload activation limit reached",
+		0x83: "Cannot activate payload with encryption",
+		0x84: "Cannot activate payload without encryption",
+	},
+	{NetFnApp, CmdDeactivatePayload}: {
+		0x80: "Payload already deactivated",
+		0x81: "Payload type is disabled",
+	},
+	{NetFnChassis, CmdSetSystemBootOptions}: {
+		0x80: "Parameter not supported",
+		0x81: "Attempt to set the 'set in progress' value when not in the 'set complete' state",
+		0x82: "Attempt to write read-only parameter",
+	},
+	{NetFnChassis, CmdGetSystemBootOptions}: {
+		0x80: "Parameter not supported",
+	},
+	{NetFnTransport, CmdSetLANConfigParams}: {
+		0x80: "Parameter not supported",
+		0x81: "Attempt to set the 'set in progress' value when not in the 'set complete' state",
+		0x82: "Attempt to write read-only parameter",
+	},
+	{NetFnTransport, CmdGetLANConfigParams}: {
+		0x80: "Parameter not supported",
+		0x83: "Attempt to read write-only parameter",
+	},
+	{NetFnStorage, CmdReadFRUData}: {
+		0x81: "FRU device busy",
+	},
+	{NetFnStorage, CmdGetSELEntry}: {
+		0x81: "Cannot execute command, SEL erase in progress",
+	},
+	{NetFnStorage, CmdClearSEL}: {
+		0x81: "Cannot execute command, SEL erase in progress",
+	},
+}
+
+// Error satisfies the error interface so that CompletionCodes may be returned as errors
+func (c CompletionCode) Error() string {
+	if s, ok := completionCodes[c]; ok {
+		return s
+	}
+	return fmt.Sprintf("Completion code: %X", uint8(c))
+}
+
+// CommandCompletionCode is a completion code in the command-specific range (80h to BEh), whose
+// meaning depends on the command. The same code means something else for other commands, so a
+// *CommandError only matches it with errors.Is if returned for one of the commands it is defined for.
+type CommandCompletionCode struct {
+	Code     CompletionCode
+	netFn    uint8
+	commands []uint8
+}
+
+// commandCode returns a command-specific completion code, defined for the given commands
+func commandCode(code CompletionCode, netFn uint8, commands ...uint8) *CommandCompletionCode {
+	return &CommandCompletionCode{code, netFn, commands}
+}
+
+func (c *CommandCompletionCode) Error() string {
+	if desc, ok := commandCompletionCodes[[2]uint8{c.netFn, c.commands[0]}][c.Code]; ok {
+		return desc
+	}
+	return c.Code.Error()
+}
+
+// CommandError is returned when the BMC completes a command with an error. It unwraps to its
+// CompletionCode, so that errors.Is matches the generic completion code constants. Command-specific
+// errors, e.g. ErrFRUDeviceBusy, are only matched for the commands they are defined for.
+type CommandError struct {
+	NetFn   uint8
+	Command uint8
+	Code    CompletionCode
+}
+
+func (e *CommandError) Error() string {
+	desc, ok := commandCompletionCodes[[2]uint8{e.NetFn, e.Command}][e.Code]
+	if !ok {
+		desc = e.Code.Error()
+	}
+
+	return fmt.Sprintf("NetFn 0x%02x command 0x%02x: %s (0x%02x)", e.NetFn, e.Command, desc, uint8(e.Code))
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Code
+}
+
+// Is matches a *CommandCompletionCode only if the code is defined for the command
+func (e *CommandError) Is(target error) bool {
+	c, ok := target.(*CommandCompletionCode)
+	if !ok || c.Code != e.Code || c.netFn != e.NetFn {
+		return false
+	}
+
+	for _, cmd := range c.commands {
+		if cmd == e.Command {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,67 @@
+package ipmi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCommandError(t *testing.T) {
+	tests := []struct {
+		err   *CommandError
+		is    error
+		isNot []error // Same code, defined for other commands
+		desc  string
+	}{
+		{&CommandError{NetFnStorage, CmdGetSDR, ErrReservationCanceled}, ErrReservationCanceled, nil, "Reservation canceled"},
+		{
+			&CommandError{NetFnStorage, CmdReadFRUData, 0x81}, ErrFRUDeviceBusy,
+			[]error{ErrSELEraseInProgress, ErrBootSetInProgress, ErrLANSetInProgress, ErrPasswordSize},
+			"FRU device busy",
+		},
+		{
+			&CommandError{NetFnStorage, CmdClearSEL, 0x81}, ErrSELEraseInProgress,
+			[]error{ErrFRUDeviceBusy, ErrBootSetInProgress, ErrLANSetInProgress, ErrPasswordSize},
+			"SEL erase in progress",
+		},
+		{
+			&CommandError{NetFnApp, CmdActivatePayload, 0x80}, ErrPayloadActive,
+			[]error{ErrPayloadDeactivated, ErrPasswordMismatch, ErrInvalidSessionHandle, ErrMessageQueueEmpty},
+			"already active on another session",
+		},
+		{
+			&CommandError{NetFnApp, CmdDeactivatePayload, 0x80}, ErrPayloadDeactivated,
+			[]error{ErrPayloadActive, ErrPasswordMismatch, ErrLANParamNotSupported},
+			"already deactivated",
+		},
+		{
+			&CommandError{NetFnTransport, CmdGetLANConfigParams, 0x80}, ErrLANParamNotSupported,
+			[]error{ErrBootParamNotSupported, ErrPayloadActive},
+			"Parameter not supported",
+		},
+		{
+			&CommandError{NetFnApp, CmdGetDeviceID, 0x80}, CompletionCode(0x80),
+			[]error{ErrPayloadActive, ErrPasswordMismatch, ErrLANParamNotSupported, ErrInvalidSessionHandle, ErrMessageQueueEmpty},
+			"Completion code: 80",
+		},
+		{&CommandError{NetFnApp, CmdGetDeviceID, ErrUnspecified}, ErrUnspecified, nil, "NetFn 0x06 command 0x01: Unspecified error (0xff)"},
+	}
+
+	for _, tt := range tests {
+		var err error = tt.err
+
+		if !errors.Is(err, tt.is) {
+			t.Errorf("%v: does not match %v", err, tt.is)
+		}
+
+		for _, other := range append(tt.isNot, ErrNodeBusy) {
+			if errors.Is(err, other) {
+				t.Errorf("%v: matches %v", err, other)
+			}
+		}
+
+		if !strings.Contains(err.Error(), tt.desc) {
+			t.Errorf("%v: expected description %q", err, tt.desc)
+		}
+	}
+}
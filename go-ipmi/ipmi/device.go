@@ -0,0 +1,258 @@
+package ipmi
+
+// IPM device global commands per section 20, and Get System GUID per section 22.14
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Additional device support bits in DeviceID per section 20.1
+const (
+	DeviceSupportSensor         = 0x01
+	DeviceSupportSDRRepository  = 0x02
+	DeviceSupportSEL            = 0x04
+	DeviceSupportFRUInventory   = 0x08
+	DeviceSupportEventReceiver  = 0x10 // IPMB event receiver
+	DeviceSupportEventGenerator = 0x20 // IPMB event generator
+	DeviceSupportBridge         = 0x40
+	DeviceSupportChassis        = 0x80
+)
+
+var deviceSupportNames = [8]string{
+	"Sensor Device",
+	"SDR Repository Device",
+	"SEL Device",
+	"FRU Inventory Device",
+	"IPMB Event Receiver",
+	"IPMB Event Generator",
+	"Bridge",
+	"Chassis Device",
+}
+
+// Self test results per section 20.4
+const (
+	SelfTestPassed         = 0x55
+	SelfTestNotImplemented = 0x56
+	SelfTestCorrupted      = 0x57 // Corrupted or inaccessible data or devices
+	SelfTestFatal          = 0x58 // Fatal hardware error
+)
+
+// Failure bits in SelfTestResult.Detail when the result is SelfTestCorrupted
+const (
+	SelfTestOperationalFirmware = 0x01 // Controller operational firmware corrupted
+	SelfTestBootBlockFirmware   = 0x02 // Controller update boot block firmware corrupted
+	SelfTestFRUInternalUse      = 0x04 // Internal use area of BMC FRU corrupted
+	SelfTestSDREmpty            = 0x08 // SDR repository empty
+	SelfTestIPMB                = 0x10 // IPMB signal lines do not respond
+	SelfTestFRUDevice           = 0x20 // Cannot access BMC FRU device
+	SelfTestSDRRepository       = 0x40 // Cannot access SDR repository
+	SelfTestSELDevice           = 0x80 // Cannot access SEL device
+)
+
+var selfTestFailureNames = [8]string{
+	"controller operational firmware corrupted",
+	"controller update boot block firmware corrupted",
+	"internal use area of BMC FRU corrupted",
+	"SDR repository empty",
+	"IPMB signal lines do not respond",
+	"cannot access BMC FRU device",
+	"cannot access SDR repository",
+	"cannot access SEL device",
+}
+
+// IANA private enterprise numbers of common BMC and server manufacturers
+var manufacturers = map[uint32]string{
+	2:     "IBM",
+	9:     "Cisco",
+	11:    "Hewlett-Packard",
+	42:    "Sun Microsystems",
+	111:   "Oracle",
+	116:   "Hitachi",
+	311:   "Microsoft",
+	343:   "Intel",
+	674:   "Dell",
+	2011:  "Huawei",
+	3704:  "AMD",
+	5771:  "Cisco",
+	6876:  "VMware",
+	10368: "Fujitsu Siemens",
+	10876: "Super Micro",
+	11129: "Google",
+	15370: "Gigabyte",
+	19046: "Lenovo",
+	20974: "American Megatrends",
+	33049: "Mellanox",
+	47196: "Hewlett Packard Enterprise",
+}
+
+// ManufacturerName returns the name of the manufacturer with the specified IANA enterprise number
+func ManufacturerName(id uint32) string {
+	if name, ok := manufacturers[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%d)", id)
+}
+
+// DeviceID is the response to Get Device ID per section 20.1
+type DeviceID struct {
+	DeviceID          uint8
+	DeviceRevision    uint8
+	ProvidesSDRs      bool
+	UpdateInProgress  bool // Firmware, SDR update or self-initialization in progress
+	FirmwareMajor     uint8
+	FirmwareMinor     uint8 // BCD encoded
+	IPMIVersion       uint8 // Major version in the upper nibble, e.g. IPMIVersion20
+	AdditionalSupport uint8 // DeviceSupport bits
+	ManufacturerID    uint32
+	ProductID         uint16
+	AuxFirmware       []byte // Auxiliary firmware revision, if provided
+}
+
+func (d *DeviceID) MarshalBinary() ([]byte, error) {
+	b := []byte{
+		d.DeviceID,
+		d.DeviceRevision&0x0f | bit(d.ProvidesSDRs, 0x80),
+		d.FirmwareMajor&0x7f | bit(d.UpdateInProgress, 0x80),
+		d.FirmwareMinor,
+		d.IPMIVersion<<4 | d.IPMIVersion>>4,
+		d.AdditionalSupport,
+	}
+	b = appendUint24(b, d.ManufacturerID)
+	b = binary.LittleEndian.AppendUint16(b, d.ProductID)
+	return append(b, d.AuxFirmware...), nil
+}
+
+func (d *DeviceID) UnmarshalBinary(b []byte) error {
+	dec := decoder{b: b}
+	d.DeviceID = dec.uint8()
+
+	rev := dec.uint8()
+	d.DeviceRevision = rev & 0x0f
+	d.ProvidesSDRs = rev&0x80 != 0
+
+	fw := dec.uint8()
+	d.FirmwareMajor = fw & 0x7f
+	d.UpdateInProgress = fw&0x80 != 0
+	d.FirmwareMinor = dec.uint8()
+
+	// Least significant digit is in the upper nibble
+	v := dec.uint8()
+	d.IPMIVersion = v<<4 | v>>4
+
+	d.AdditionalSupport = dec.uint8()
+	d.ManufacturerID = dec.uint24() & 0xfffff
+	d.ProductID = dec.uint16()
+	d.AuxFirmware = dec.rest()
+	return dec.err
+}
+
+// FirmwareRevision returns the major and minor firmware revision, e.g. "1.23"
+func (d *DeviceID) FirmwareRevision() string {
+	return fmt.Sprintf("%d.%02x", d.FirmwareMajor, d.FirmwareMinor)
+}
+
+// Manufacturer returns the manufacturer name, or its IANA enterprise number if unknown
+func (d *DeviceID) Manufacturer() string {
+	return ManufacturerName(d.ManufacturerID)
+}
+
+// SupportedDevices returns the names of the additional device support bits that are set
+func (d *DeviceID) SupportedDevices() []string {
+	var names []string
+	for i, name := range deviceSupportNames {
+		if d.AdditionalSupport&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// SelfTestResult is the response to Get Self Test Results per section 20.4
+type SelfTestResult struct {
+	Result uint8
+	Detail uint8 // Failure bits for SelfTestCorrupted, otherwise device-specific
+}
+
+func (r *SelfTestResult) MarshalBinary() ([]byte, error) {
+	return []byte{r.Result, r.Detail}, nil
+}
+
+func (r *SelfTestResult) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.Result = d.uint8()
+	r.Detail = d.uint8()
+	return d.err
+}
+
+// Passed reports whether the self test passed without error
+func (r *SelfTestResult) Passed() bool {
+	return r.Result == SelfTestPassed
+}
+
+func (r *SelfTestResult) String() string {
+	switch r.Result {
+	case SelfTestPassed:
+		return "passed"
+	case SelfTestNotImplemented:
+		return "not implemented"
+	case SelfTestCorrupted:
+		var failures []string
+		for i, name := range selfTestFailureNames {
+			if r.Detail&(1<<i) != 0 {
+				failures = append(failures, name)
+			}
+		}
+		return "corrupted or inaccessible data or devices: " + strings.Join(failures, ", ")
+	case SelfTestFatal:
+		return fmt.Sprintf("fatal hardware error (0x%02x)", r.Detail)
+	default:
+		return fmt.Sprintf("device-specific failure 0x%02x (0x%02x)", r.Result, r.Detail)
+	}
+}
+
+// GUID is a device or system globally unique ID, as returned by Get Device GUID and Get System GUID
+type GUID [16]byte
+
+func (g *GUID) MarshalBinary() ([]byte, error) {
+	return g[:], nil
+}
+
+func (g *GUID) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	d.bytes(g[:])
+	return d.err
+}
+
+// String formats the GUID with its time fields least significant byte first, as in SMBIOS, so
+// that it matches the system UUID reported by the host operating system.
+func (g GUID) String() string {
+	return fmt.Sprintf("%08x-%04x-%04x-%x-%x", binary.LittleEndian.Uint32(g[0:4]),
+		binary.LittleEndian.Uint16(g[4:6]), binary.LittleEndian.Uint16(g[6:8]), g[8:10], g[10:16])
+}
+
+// getDeviceID returns the device's identity, firmware revision and capabilities
+func (c *conn) getDeviceID() (*DeviceID, error) {
+	resp := &DeviceID{}
+	if err := c.send(NetFnApp, CmdGetDeviceID, nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// getSelfTestResults returns the results of the device's most recent self test
+func (c *conn) getSelfTestResults() (*SelfTestResult, error) {
+	resp := &SelfTestResult{}
+	if err := c.send(NetFnApp, CmdGetSelfTestResults, nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// getGUID returns the GUID returned by Get Device GUID or Get System GUID
+func (c *conn) getGUID(cmd uint8) (GUID, error) {
+	var guid GUID
+	err := c.send(NetFnApp, cmd, nil, &guid)
+	return guid, err
+}
@@ -0,0 +1,73 @@
+package ipmi_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+func TestDeviceID(t *testing.T) {
+	profile := ipmisim.DefaultProfile()
+	profile.Device.SelfTestFailed = ipmi.SelfTestSDREmpty | ipmi.SelfTestFRUDevice
+
+	_, addr := newTestSimulator(t, profile)
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("user", "user", ipmi.PrivLevelUser); err != nil {
+		t.Fatal(err)
+	}
+
+	dev, err := c.GetDeviceID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dev.Manufacturer() != "Intel" || dev.FirmwareRevision() != "1.23" || dev.IPMIVersion != ipmi.IPMIVersion20 {
+		t.Errorf("manufacturer %s, firmware %s, IPMI version %#x", dev.Manufacturer(), dev.FirmwareRevision(),
+			dev.IPMIVersion)
+	}
+
+	want := []string{"Sensor Device", "SDR Repository Device", "SEL Device", "FRU Inventory Device", "Chassis Device"}
+	if got := dev.SupportedDevices(); !reflect.DeepEqual(got, want) {
+		t.Errorf("supported devices %q, expected %q", got, want)
+	}
+
+	result, err := c.GetSelfTestResults()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failures := "corrupted or inaccessible data or devices: SDR repository empty, cannot access BMC FRU device"
+	if result.Passed() || result.String() != failures {
+		t.Errorf("self test %q, expected %q", result, failures)
+	}
+
+	// System GUID is the same as that authenticated in RAKP message 2
+	guid, err := c.GetSystemGUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if guid != ipmi.BMCGUID(c) {
+		t.Errorf("system ipmi.GUID %s, expected %s", guid, ipmi.BMCGUID(c))
+	}
+
+	devGUID, err := c.GetDeviceGUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if devGUID == guid {
+		t.Error("device ipmi.GUID is the same as system ipmi.GUID")
+	}
+}
+
+func TestGUIDString(t *testing.T) {
+	guid := ipmi.GUID{0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	if want := "00112233-4455-6677-8899-aabbccddeeff"; guid.String() != want {
+		t.Errorf("formatted %s, expected %s", guid, want)
+	}
+}
@@ -0,0 +1,306 @@
+package ipmi
+
+// Event offset descriptions per section 42
+
+import "fmt"
+
+// Offset descriptions of generic event / reading types per table 42-2, by event / reading type
+var genericEventOffsets = map[uint8][]string{
+	0x01: {
+		"Lower Non-critical going low",
+		"Lower Non-critical going high",
+		"Lower Critical going low",
+		"Lower Critical going high",
+		"Lower Non-recoverable going low",
+		"Lower Non-recoverable going high",
+		"Upper Non-critical going low",
+		"Upper Non-critical going high",
+		"Upper Critical going low",
+		"Upper Critical going high",
+		"Upper Non-recoverable going low",
+		"Upper Non-recoverable going high",
+	},
+	0x02: {"Transition to Idle", "Transition to Active", "Transition to Busy"},
+	0x03: {"State Deasserted", "State Asserted"},
+	0x04: {"Predictive Failure deasserted", "Predictive Failure asserted"},
+	0x05: {"Limit Not Exceeded", "Limit Exceeded"},
+	0x06: {"Performance Met", "Performance Lags"},
+	0x07: {
+		"Transition to OK",
+		"Transition to Non-Critical from OK",
+		"Transition to Critical from less severe",
+		"Transition to Non-recoverable from less severe",
+		"Transition to Non-Critical from more severe",
+		"Transition to Critical from Non-recoverable",
+		"Transition to Non-recoverable",
+		"Monitor",
+		"Informational",
+	},
+	0x08: {"Device Absent", "Device Present"},
+	0x09: {"Device Disabled", "Device Enabled"},
+	0x0a: {
+		"Transition to Running",
+		"Transition to In Test",
+		"Transition to Power Off",
+		"Transition to On Line",
+		"Transition to Off Line",
+		"Transition to Off Duty",
+		"Transition to Degraded",
+		"Transition to Power Save",
+		"Install Error",
+	},
+	0x0b: {
+		"Fully Redundant",
+		"Redundancy Lost",
+		"Redundancy Degraded",
+		"Non-redundant: Sufficient Resources from Redundant",
+		"Non-redundant: Sufficient Resources from Insufficient Resources",
+		"Non-redundant: Insufficient Resources",
+		"Redundancy Degraded from Fully Redundant",
+		"Redundancy Degraded from Non-redundant",
+	},
+	0x0c: {"D0 Power State", "D1 Power State", "D2 Power State", "D3 Power State"},
+}
+
+// Offset descriptions of sensor-specific events per table 42-3, by sensor type
+var sensorSpecificOffsets = map[uint8][]string{
+	0x05: { // Physical Security
+		"General Chassis Intrusion",
+		"Drive Bay Intrusion",
+		"I/O Card Area Intrusion",
+		"Processor Area Intrusion",
+		"LAN Leash Lost",
+		"Unauthorized Dock",
+		"Fan Area Intrusion",
+	},
+	0x06: { // Platform Security Violation Attempt
+		"Secure Mode Violation Attempt",
+		"Pre-boot Password Violation - User Password",
+		"Pre-boot Password Violation - Setup Password",
+		"Pre-boot Password Violation - Network Boot Password",
+		"Other Pre-boot Password Violation",
+		"Out-of-band Access Password Violation",
+	},
+	0x07: { // Processor
+		"IERR",
+		"Thermal Trip",
+		"FRB1/BIST Failure",
+		"FRB2/Hang in POST Failure",
+		"FRB3/Processor Startup/Initialization Failure",
+		"Configuration Error",
+		"SM BIOS Uncorrectable CPU-complex Error",
+		"Presence Detected",
+		"Disabled",
+		"Terminator Presence Detected",
+		"Throttled",
+		"Uncorrectable Machine Check Exception",
+		"Correctable Machine Check Error",
+	},
+	0x08: { // Power Supply
+		"Presence Detected",
+		"Failure Detected",
+		"Predictive Failure",
+		"Power Supply AC Lost",
+		"AC Lost or Out-of-range",
+		"AC Out-of-range, but Present",
+		"Configuration Error",
+		"Inactive",
+	},
+	0x09: { // Power Unit
+		"Power Off/Power Down",
+		"Power Cycle",
+		"240VA Power Down",
+		"Interlock Power Down",
+		"AC Lost",
+		"Soft Power Control Failure",
+		"Failure Detected",
+		"Predictive Failure",
+	},
+	0x0c: { // Memory
+		"Correctable ECC",
+		"Uncorrectable ECC",
+		"Parity",
+		"Memory Scrub Failed",
+		"Memory Device Disabled",
+		"Correctable ECC Logging Limit Reached",
+		"Presence Detected",
+		"Configuration Error",
+		"Spare",
+		"Throttled",
+		"Critical Overtemperature",
+	},
+	0x0d: { // Drive Slot
+		"Drive Present",
+		"Drive Fault",
+		"Predictive Failure",
+		"Hot Spare",
+		"Parity Check In Progress",
+		"In Critical Array",
+		"In Failed Array",
+		"Rebuild In Progress",
+		"Rebuild Aborted",
+	},
+	0x0f: { // System Firmware Progress
+		"System Firmware Error",
+		"System Firmware Hang",
+		"System Firmware Progress",
+	},
+	0x10: { // Event Logging Disabled
+		"Correctable Memory Error Logging Disabled",
+		"Event Type Logging Disabled",
+		"Log Area Reset/Cleared",
+		"All Event Logging Disabled",
+		"Log Full",
+		"Log Almost Full",
+		"Correctable Machine Check Error Logging Disabled",
+	},
+	0x11: { // Watchdog 1
+		"BIOS Watchdog Reset",
+		"OS Watchdog Reset",
+		"OS Watchdog Shut Down",
+		"OS Watchdog Power Down",
+		"OS Watchdog Power Cycle",
+		"OS Watchdog NMI/Diagnostic Interrupt",
+		"OS Watchdog Expired, Status Only",
+		"OS Watchdog Pre-timeout Interrupt, non-NMI",
+	},
+	0x12: { // System Event
+		"System Reconfigured",
+		"OEM System Boot Event",
+		"Undetermined System Hardware Failure",
+		"Entry Added to Auxiliary Log",
+		"PEF Action",
+		"Timestamp Clock Sync",
+	},
+	0x13: { // Critical Interrupt
+		"Front Panel NMI/Diagnostic Interrupt",
+		"Bus Timeout",
+		"I/O Channel Check NMI",
+		"Software NMI",
+		"PCI PERR",
+		"PCI SERR",
+		"EISA Fail Safe Timeout",
+		"Bus Correctable Error",
+		"Bus Uncorrectable Error",
+		"Fatal NMI",
+		"Bus Fatal Error",
+		"Bus Degraded",
+	},
+	0x14: { // Button / Switch
+		"Power Button Pressed",
+		"Sleep Button Pressed",
+		"Reset Button Pressed",
+		"FRU Latch Open",
+		"FRU Service Request Button",
+	},
+	0x1d: { // System Boot / Restart Initiated
+		"Initiated by Power Up",
+		"Initiated by Hard Reset",
+		"Initiated by Warm Reset",
+		"User Requested PXE Boot",
+		"Automatic Boot to Diagnostic",
+		"OS Initiated Hard Reset",
+		"OS Initiated Warm Reset",
+		"System Restart",
+	},
+	0x1e: { // Boot Error
+		"No Bootable Media",
+		"Non-bootable Diskette Left in Drive",
+		"PXE Server Not Found",
+		"Invalid Boot Sector",
+		"Timeout Waiting for Selection of Boot Source",
+	},
+	0x1f: { // Base OS Boot / Installation Status
+		"A: Boot Completed",
+		"C: Boot Completed",
+		"PXE Boot Completed",
+		"Diagnostic Boot Completed",
+		"CD-ROM Boot Completed",
+		"ROM Boot Completed",
+		"Boot Completed - Device Not Specified",
+		"Installation Started",
+		"Installation Completed",
+		"Installation Aborted",
+		"Installation Failed",
+	},
+	0x20: { // OS Stop / Shutdown
+		"Critical Stop During OS Load",
+		"Run-time Critical Stop",
+		"OS Graceful Stop",
+		"OS Graceful Shutdown",
+		"PEF Initiated Soft Shutdown",
+		"Agent Not Responding",
+	},
+	0x21: { // Slot / Connector
+		"Fault Status Asserted",
+		"Identify Status Asserted",
+		"Device Installed",
+		"Ready for Device Installation",
+		"Ready for Device Removal",
+		"Slot Power is Off",
+		"Device Removal Request",
+		"Interlock Asserted",
+		"Slot is Disabled",
+		"Spare Device",
+	},
+	0x23: { // Watchdog 2
+		"Timer Expired",
+		"Hard Reset",
+		"Power Down",
+		"Power Cycle",
+		"",
+		"",
+		"",
+		"",
+		"Timer Interrupt",
+	},
+	0x25: { // Entity Presence
+		"Present",
+		"Absent",
+		"Disabled",
+	},
+	0x28: { // Management Subsystem Health
+		"Sensor Access Degraded or Unavailable",
+		"Controller Access Degraded or Unavailable",
+		"Management Controller Off-line",
+		"Management Controller Unavailable",
+		"Sensor Failure",
+		"FRU Failure",
+	},
+	0x29: { // Battery
+		"Low",
+		"Failed",
+		"Presence Detected",
+	},
+	0x2b: { // Version Change
+		"Hardware Change Detected",
+		"Firmware or Software Change Detected",
+		"Hardware Incompatibility Detected",
+		"Firmware or Software Incompatibility Detected",
+		"Invalid or Unsupported Hardware Version",
+		"Invalid or Unsupported Firmware or Software Version",
+		"Hardware Change Successful",
+		"Firmware or Software Change Successful",
+	},
+}
+
+// EventDescription returns the description of an event offset for a sensor type and event /
+// reading type code.
+func EventDescription(sensorType, eventType, offset uint8) string {
+	var descs []string
+
+	switch {
+	case eventType == EventReadingTypeSensorSpecific:
+		descs = sensorSpecificOffsets[sensorType]
+	case eventType >= 0x70 && eventType <= 0x7f:
+		return fmt.Sprintf("OEM event offset %#x", offset)
+	default:
+		descs = genericEventOffsets[eventType]
+	}
+
+	if int(offset) < len(descs) && descs[offset] != "" {
+		return descs[offset]
+	}
+
+	return fmt.Sprintf("Event offset %#x", offset)
+}
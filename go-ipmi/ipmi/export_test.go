@@ -0,0 +1,27 @@
+package ipmi
+
+import "testing"
+
+// Internals used by the tests of package ipmi_test, which exercise the client against the simulated
+// BMC of package ipmisim
+
+const (
+	ASFIANA               = asfIANA
+	BootSetComplete       = bootSetComplete
+	BootSetInProgress     = bootSetInProgress
+	ChassisControlInvalid = chassisControlInvalid
+	LANSetInProgress      = lanSetInProgress
+	MaxCipherSuiteEntries = maxCipherSuiteEntries
+	MaxVLANID             = maxVLANID
+)
+
+// BMCGUID returns the managed system GUID authenticated in RAKP message 2
+func BMCGUID(c *Client) GUID {
+	return GUID(c.l.bmcGUID)
+}
+
+// NewFakeInbandClient returns a client connected in-band to a fake OpenIPMI device, which answers
+// requests with handle
+func NewFakeInbandClient(t *testing.T, handle func(netFn, cmd uint8, data []byte) []byte) *Client {
+	return newInbandClient(t, newFakeDevice(handle))
+}
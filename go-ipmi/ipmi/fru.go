@@ -0,0 +1,529 @@
+package ipmi
+
+// FRU inventory device commands per section 34, and decoding of the Platform Management FRU
+// Information Storage Definition v1.0
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Multirecord area record types per FRU specification table 16-2
+const (
+	FRURecordTypePowerSupply = 0x00
+	FRURecordTypeDCOutput    = 0x01
+	FRURecordTypeDCLoad      = 0x02
+)
+
+// Completion codes specific to FRU commands
+var (
+	ErrFRUDeviceBusy = commandCode(0x81, NetFnStorage, CmdReadFRUData)
+)
+
+const (
+	fruChunkSize            = 32 // Conservative read size supported by most BMCs
+	fruBusyRetries          = 5
+	fruBusyDelay            = 20 * time.Millisecond
+	fruAccessWords          = 0x01
+	fruFormatVersion        = 0x01
+	fruCommonHeaderSize     = 8
+	fruEndOfFields          = 0xc1
+	fruMultiRecordHeader    = 5
+	fruMultiRecordEndOfList = 0x80
+	fruLanguageEnglish      = 0x19
+)
+
+// Board manufacturing dates count minutes from 0:00 1/1/96
+var fruEpoch = time.Date(1996, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// errFRUChecksum indicates a FRU area with an invalid checksum
+var errFRUChecksum = errors.New("checksum mismatch")
+
+// SMBIOS chassis types, as used by the chassis info area
+var fruChassisTypes = []string{
+	"Unspecified", "Other", "Unknown", "Desktop", "Low Profile Desktop", "Pizza Box", "Mini Tower",
+	"Tower", "Portable", "Laptop", "Notebook", "Hand Held", "Docking Station", "All in One",
+	"Sub Notebook", "Space-saving", "Lunch Box", "Main Server Chassis", "Expansion Chassis",
+	"SubChassis", "Bus Expansion Chassis", "Peripheral Chassis", "RAID Chassis",
+	"Rack Mount Chassis", "Sealed-case PC", "Multi-system Chassis", "Compact PCI", "Advanced TCA",
+	"Blade", "Blade Enclosure", "Tablet", "Convertible", "Detachable", "IoT Gateway",
+	"Embedded PC", "Mini PC", "Stick PC",
+}
+
+// FRUInventoryAreaInfo per section 34.1
+type FRUInventoryAreaInfo struct {
+	Size       uint16 // Inventory area size in bytes
+	WordAccess bool   // Device is accessed by words, otherwise by bytes
+}
+
+func (r *FRUInventoryAreaInfo) MarshalBinary() ([]byte, error) {
+	return append(binary.LittleEndian.AppendUint16(nil, r.Size), bit(r.WordAccess, fruAccessWords)), nil
+}
+
+func (r *FRUInventoryAreaInfo) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.Size = d.uint16()
+	r.WordAccess = d.uint8()&fruAccessWords != 0
+
+	return d.err
+}
+
+// FRU is the decoded contents of a FRU inventory device. Areas not present are nil.
+type FRU struct {
+	Chassis       *FRUChassisInfo
+	Board         *FRUBoardInfo
+	Product       *FRUProductInfo
+	MultiRecords  []FRUMultiRecord
+	PowerSupplies []FRUPowerSupply
+	DCOutputs     []FRUDCOutput
+}
+
+// FRUChassisInfo per FRU specification section 10
+type FRUChassisInfo struct {
+	Type         uint8 // SMBIOS chassis type
+	PartNumber   string
+	SerialNumber string
+	Custom       []string
+}
+
+// FRUBoardInfo per FRU specification section 11
+type FRUBoardInfo struct {
+	Language     uint8
+	MfgDate      time.Time // Zero if unspecified
+	Manufacturer string
+	ProductName  string
+	SerialNumber string
+	PartNumber   string
+	FileID       string
+	Custom       []string
+}
+
+// FRUProductInfo per FRU specification section 12
+type FRUProductInfo struct {
+	Language     uint8
+	Manufacturer string
+	Name         string
+	PartNumber   string // Part / model number
+	Version      string
+	SerialNumber string
+	AssetTag     string
+	FileID       string
+	Custom       []string
+}
+
+// FRUMultiRecord is an undecoded record of the multirecord area per FRU specification section 16
+type FRUMultiRecord struct {
+	Type    uint8
+	Version uint8 // Record format version
+	Data    []byte
+}
+
+// FRUPowerSupply is a power supply information record per FRU specification section 18.1
+type FRUPowerSupply struct {
+	Capacity                 uint16        // Overall capacity in watts
+	PeakVA                   uint16        // 0xffff if not specified
+	InrushCurrent            uint8         // Amps, 0xff if not specified
+	InrushInterval           uint8         // Milliseconds
+	InputVoltage             [2][2]float64 // Low and high end of two input voltage ranges in volts
+	InputFrequency           [2]uint8      // Low and high end of input frequency range in Hz
+	DropoutTolerance         uint8         // AC dropout tolerance in milliseconds
+	HotSwap                  bool
+	Autoswitch               bool
+	PowerFactorCorrection    bool
+	PredictiveFailSupport    bool
+	HoldUpTime               uint8    // Seconds
+	PeakCapacity             uint16   // Watts
+	CombinedVoltages         [2]uint8 // Voltage codes of combined wattage: 0 = 12V, 1 = -12V, 2 = 5V, 3 = 3.3V
+	CombinedWattage          uint16
+	TachometerLowerThreshold uint8 // Predictive fail tachometer lower threshold in RPS
+}
+
+// FRUDCOutput is a DC output record per FRU specification section 18.2
+type FRUDCOutput struct {
+	Output      uint8   // Output number
+	Standby     bool    // Output is on in standby
+	Nominal     float64 // Volts
+	MaxNegative float64 // Maximum negative voltage deviation in volts
+	MaxPositive float64 // Maximum positive voltage deviation in volts
+	RippleNoise uint16  // Peak-to-peak ripple and noise in millivolts
+	MinCurrent  float64 // Amps
+	MaxCurrent  float64 // Amps
+}
+
+// FRUChassisTypeName returns the name of an SMBIOS chassis type
+func FRUChassisTypeName(t uint8) string {
+	if int(t) < len(fruChassisTypes) {
+		return fruChassisTypes[t]
+	}
+	return fmt.Sprintf("Chassis type 0x%02x", t)
+}
+
+// decodeFRU decodes a FRU inventory area, starting with the common header
+func decodeFRU(b []byte) (*FRU, error) {
+	if len(b) < fruCommonHeaderSize {
+		return nil, ErrShortData
+	}
+
+	if b[0]&0x0f != fruFormatVersion {
+		return nil, fmt.Errorf("unsupported FRU format version %#x", b[0]&0x0f)
+	}
+
+	if checksum(b[:fruCommonHeaderSize]...) != 0 {
+		return nil, fmt.Errorf("FRU common header: %w", errFRUChecksum)
+	}
+
+	fru := &FRU{}
+
+	// Area offsets are in multiples of 8 bytes, with zero indicating an absent area
+	if off := int(b[2]) * 8; off != 0 {
+		area, err := fruArea(b, off)
+		if err != nil {
+			return nil, fmt.Errorf("chassis info area: %w", err)
+		}
+		if fru.Chassis, err = decodeFRUChassisInfo(area); err != nil {
+			return nil, fmt.Errorf("chassis info area: %w", err)
+		}
+	}
+
+	if off := int(b[3]) * 8; off != 0 {
+		area, err := fruArea(b, off)
+		if err != nil {
+			return nil, fmt.Errorf("board info area: %w", err)
+		}
+		if fru.Board, err = decodeFRUBoardInfo(area); err != nil {
+			return nil, fmt.Errorf("board info area: %w", err)
+		}
+	}
+
+	if off := int(b[4]) * 8; off != 0 {
+		area, err := fruArea(b, off)
+		if err != nil {
+			return nil, fmt.Errorf("product info area: %w", err)
+		}
+		if fru.Product, err = decodeFRUProductInfo(area); err != nil {
+			return nil, fmt.Errorf("product info area: %w", err)
+		}
+	}
+
+	if off := int(b[5]) * 8; off != 0 {
+		if err := fru.decodeMultiRecords(b, off); err != nil {
+			return nil, fmt.Errorf("multirecord area: %w", err)
+		}
+	}
+
+	return fru, nil
+}
+
+// fruArea returns the info area at offset off, after checking its length and checksum
+func fruArea(b []byte, off int) ([]byte, error) {
+	if off+2 > len(b) {
+		return nil, ErrShortData
+	}
+
+	n := int(b[off+1]) * 8
+	if n < 2 || off+n > len(b) {
+		return nil, ErrShortData
+	}
+
+	area := b[off : off+n]
+	if checksum(area...) != 0 {
+		return nil, errFRUChecksum
+	}
+
+	if area[0]&0x0f != fruFormatVersion {
+		return nil, fmt.Errorf("unsupported area format version %#x", area[0]&0x0f)
+	}
+
+	return area, nil
+}
+
+// decodeFRUFields decodes type/length prefixed fields until the end of fields marker. The first
+// want fields are always returned, even if empty, followed by any custom fields.
+func decodeFRUFields(b []byte, want int) ([]string, error) {
+	var fields []string
+
+	for len(b) > 0 && b[0] != fruEndOfFields {
+		n := int(b[0] & 0x3f)
+		if n+1 > len(b) {
+			return nil, ErrShortData
+		}
+
+		// Languages other than English should use Unicode for 8-bit fields, which is not supported
+		// by BMCs in practice
+		fields = append(fields, decodeString(b[0]>>6, b[1:1+n]))
+		b = b[1+n:]
+	}
+
+	if len(b) == 0 {
+		return nil, errors.New("missing end of fields marker")
+	}
+
+	for len(fields) < want {
+		fields = append(fields, "")
+	}
+
+	return fields, nil
+}
+
+// decodeFRUChassisInfo decodes the chassis info area
+func decodeFRUChassisInfo(b []byte) (*FRUChassisInfo, error) {
+	if len(b) < 3 {
+		return nil, ErrShortData
+	}
+
+	f, err := decodeFRUFields(b[3:], 2)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FRUChassisInfo{
+		Type:         b[2],
+		PartNumber:   f[0],
+		SerialNumber: f[1],
+		Custom:       f[2:],
+	}, nil
+}
+
+// decodeFRUBoardInfo decodes the board info area
+func decodeFRUBoardInfo(b []byte) (*FRUBoardInfo, error) {
+	if len(b) < 6 {
+		return nil, ErrShortData
+	}
+
+	f, err := decodeFRUFields(b[6:], 5)
+	if err != nil {
+		return nil, err
+	}
+
+	board := &FRUBoardInfo{
+		Language:     b[2],
+		Manufacturer: f[0],
+		ProductName:  f[1],
+		SerialNumber: f[2],
+		PartNumber:   f[3],
+		FileID:       f[4],
+		Custom:       f[5:],
+	}
+
+	if minutes := uint32(b[3]) | uint32(b[4])<<8 | uint32(b[5])<<16; minutes != 0 {
+		board.MfgDate = fruEpoch.Add(time.Duration(minutes) * time.Minute)
+	}
+
+	return board, nil
+}
+
+// decodeFRUProductInfo decodes the product info area
+func decodeFRUProductInfo(b []byte) (*FRUProductInfo, error) {
+	if len(b) < 3 {
+		return nil, ErrShortData
+	}
+
+	f, err := decodeFRUFields(b[3:], 7)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FRUProductInfo{
+		Language:     b[2],
+		Manufacturer: f[0],
+		Name:         f[1],
+		PartNumber:   f[2],
+		Version:      f[3],
+		SerialNumber: f[4],
+		AssetTag:     f[5],
+		FileID:       f[6],
+		Custom:       f[7:],
+	}, nil
+}
+
+// decodeMultiRecords decodes the records of the multirecord area at offset off, until the record
+// marked as the end of the list
+func (fru *FRU) decodeMultiRecords(b []byte, off int) error {
+	for {
+		if off+fruMultiRecordHeader > len(b) {
+			return ErrShortData
+		}
+
+		hdr := b[off : off+fruMultiRecordHeader]
+		if checksum(hdr...) != 0 {
+			return fmt.Errorf("record header at %d: %w", off, errFRUChecksum)
+		}
+
+		n := int(hdr[2])
+		off += fruMultiRecordHeader
+		if off+n > len(b) {
+			return ErrShortData
+		}
+
+		data := b[off : off+n]
+		if checksum(data...) != hdr[3] {
+			return fmt.Errorf("record at %d: %w", off, errFRUChecksum)
+		}
+
+		rec := FRUMultiRecord{Type: hdr[0], Version: hdr[1] & 0x0f, Data: append([]byte(nil), data...)}
+		fru.MultiRecords = append(fru.MultiRecords, rec)
+
+		switch rec.Type {
+		case FRURecordTypePowerSupply:
+			if ps, err := decodeFRUPowerSupply(data); err == nil {
+				fru.PowerSupplies = append(fru.PowerSupplies, *ps)
+			}
+		case FRURecordTypeDCOutput:
+			if out, err := decodeFRUDCOutput(data); err == nil {
+				fru.DCOutputs = append(fru.DCOutputs, *out)
+			}
+		}
+
+		if hdr[1]&fruMultiRecordEndOfList != 0 {
+			return nil
+		}
+
+		off += n
+	}
+}
+
+// decodeFRUPowerSupply decodes a power supply information record
+func decodeFRUPowerSupply(b []byte) (*FRUPowerSupply, error) {
+	if len(b) < 24 {
+		return nil, ErrShortData
+	}
+
+	volts := func(i int) float64 {
+		return float64(binary.LittleEndian.Uint16(b[i:i+2])) / 100
+	}
+
+	peak := binary.LittleEndian.Uint16(b[18:20])
+
+	return &FRUPowerSupply{
+		Capacity:                 binary.LittleEndian.Uint16(b[0:2]) & 0x0fff,
+		PeakVA:                   binary.LittleEndian.Uint16(b[2:4]),
+		InrushCurrent:            b[4],
+		InrushInterval:           b[5],
+		InputVoltage:             [2][2]float64{{volts(6), volts(8)}, {volts(10), volts(12)}},
+		InputFrequency:           [2]uint8{b[14], b[15]},
+		DropoutTolerance:         b[16],
+		HotSwap:                  b[17]&0x08 != 0,
+		Autoswitch:               b[17]&0x04 != 0,
+		PowerFactorCorrection:    b[17]&0x02 != 0,
+		PredictiveFailSupport:    b[17]&0x01 != 0,
+		HoldUpTime:               uint8(peak >> 12),
+		PeakCapacity:             peak & 0x0fff,
+		CombinedVoltages:         [2]uint8{b[20] >> 4, b[20] & 0x0f},
+		CombinedWattage:          binary.LittleEndian.Uint16(b[21:23]),
+		TachometerLowerThreshold: b[23],
+	}, nil
+}
+
+// decodeFRUDCOutput decodes a DC output record
+func decodeFRUDCOutput(b []byte) (*FRUDCOutput, error) {
+	if len(b) < 13 {
+		return nil, ErrShortData
+	}
+
+	// Voltages are signed, in units of 10 mV
+	volts := func(i int) float64 {
+		return float64(int16(binary.LittleEndian.Uint16(b[i:i+2]))) / 100
+	}
+
+	return &FRUDCOutput{
+		Output:      b[0] & 0x0f,
+		Standby:     b[0]&0x80 != 0,
+		Nominal:     volts(1),
+		MaxNegative: volts(3),
+		MaxPositive: volts(5),
+		RippleNoise: binary.LittleEndian.Uint16(b[7:9]),
+		MinCurrent:  float64(binary.LittleEndian.Uint16(b[9:11])) / 1000,
+		MaxCurrent:  float64(binary.LittleEndian.Uint16(b[11:13])) / 1000,
+	}, nil
+}
+
+// getFRUInventoryAreaInfo returns the size of a FRU inventory device, and whether it is accessed
+// by words or bytes
+func (c *conn) getFRUInventoryAreaInfo(deviceID uint8) (*FRUInventoryAreaInfo, error) {
+	resp := &FRUInventoryAreaInfo{}
+
+	if err := c.send(NetFnStorage, CmdGetFRUInventoryAreaInfo, rawRequest{deviceID}, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// readFRUData reads up to count bytes or words from a FRU inventory device at offset, retrying
+// while the device is busy
+func (c *conn) readFRUData(deviceID uint8, offset uint16, count uint8) ([]byte, error) {
+	req := make([]byte, 0, 4)
+	req = append(req, deviceID)
+	req = binary.LittleEndian.AppendUint16(req, offset)
+	req = append(req, count)
+
+	for attempt := 0; ; attempt++ {
+		data, err := c.SendRecv(Request{NetworkFunction: NetFnStorage, Command: CmdReadFRUData, Data: req})
+		if errors.Is(err, ErrFRUDeviceBusy) && attempt < fruBusyRetries {
+			time.Sleep(fruBusyDelay)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		// Count returned, followed by the data
+		if len(data) < 2 || len(data)-2 < int(data[1]) {
+			return nil, ErrShortData
+		}
+
+		return data[2 : 2+int(data[1])], nil
+	}
+}
+
+// readFRU reads the entire inventory area of a FRU device in chunks. The chunk size is reduced
+// if the BMC cannot return as many bytes.
+func (c *conn) readFRU(deviceID uint8) ([]byte, error) {
+	info, err := c.getFRUInventoryAreaInfo(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Offsets and counts of word-accessed devices are in words
+	unit := 1
+	if info.WordAccess {
+		unit = 2
+	}
+
+	chunkSize := fruChunkSize
+	b := make([]byte, 0, info.Size)
+
+	for len(b) < int(info.Size) {
+		n := int(info.Size) - len(b)
+		if n > chunkSize {
+			n = chunkSize
+		}
+
+		chunk, err := c.readFRUData(deviceID, uint16(len(b)/unit), uint8((n+unit-1)/unit))
+		if errors.Is(err, ErrCannotReturnBytes) && chunkSize > 4 {
+			chunkSize /= 2
+			continue
+		} else if err != nil {
+			return b, fmt.Errorf("read FRU data at offset %d: %w", len(b), err)
+		}
+
+		if len(chunk) == 0 {
+			return b, ErrShortData
+		}
+
+		b = append(b, chunk...)
+	}
+
+	return b[:info.Size], nil
+}
+
+// readFRUInventory reads and decodes a FRU inventory device
+func (c *conn) readFRUInventory(deviceID uint8) (*FRU, error) {
+	b, err := c.readFRU(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeFRU(b)
+}
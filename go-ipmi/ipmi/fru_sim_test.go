@@ -0,0 +1,44 @@
+package ipmi_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+func TestReadFRU(t *testing.T) {
+	profile := ipmisim.DefaultProfile()
+	profile.MaxFRURead = 8
+
+	_, addr := newTestSimulator(t, profile)
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("user", "user", ipmi.PrivLevelUser); err != nil {
+		t.Fatal(err)
+	}
+
+	fru, err := c.ReadFRU(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := profile.FRU
+
+	if fru.Chassis == nil || fru.Chassis.SerialNumber != want.ChassisSerialNumber {
+		t.Errorf("unexpected chassis info %+v", fru.Chassis)
+	}
+
+	if fru.Board == nil || !fru.Board.MfgDate.Equal(want.BoardMfgDate) || fru.Board.PartNumber != want.BoardPartNumber {
+		t.Errorf("unexpected board info %+v", fru.Board)
+	}
+
+	if fru.Product == nil || fru.Product.SerialNumber != want.ProductSerialNumber || fru.Product.AssetTag != want.AssetTag {
+		t.Errorf("unexpected product info %+v", fru.Product)
+	}
+
+	if _, err := c.ReadFRU(1); !errors.Is(err, ipmi.ErrNotPresent) {
+		t.Errorf("expected FRU device not present, got %v", err)
+	}
+}
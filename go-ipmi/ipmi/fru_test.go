@@ -0,0 +1,150 @@
+package ipmi
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fruTestArea pads an info area to a multiple of 8 bytes, and sets its length and checksum
+func fruTestArea(b ...byte) []byte {
+	for (len(b)+1)%8 != 0 {
+		b = append(b, 0)
+	}
+	b[1] = uint8((len(b) + 1) / 8)
+
+	return append(b, checksum(b...))
+}
+
+// fruTestRecord encodes a multirecord area record
+func fruTestRecord(typ uint8, last bool, data ...byte) []byte {
+	flags := uint8(0x02)
+	if last {
+		flags |= fruMultiRecordEndOfList
+	}
+
+	hdr := []byte{typ, flags, uint8(len(data)), checksum(data...)}
+	hdr = append(hdr, checksum(hdr...))
+
+	return append(hdr, data...)
+}
+
+// fruTestImage returns a FRU image with chassis and board info areas and power supply records
+func fruTestImage() []byte {
+	chassis := fruTestArea(
+		0x01, 0, 0x17,
+		0xc4, 'C', 'H', '-', '1', // 8-bit ASCII
+		0x42, 0x12, 0x34, // BCD plus
+		0x83, 0x29, 0xdc, 0xa6, // 6-bit ASCII custom field
+		fruEndOfFields,
+	)
+
+	board := fruTestArea(
+		0x01, 0, fruLanguageEnglish, 60, 0, 0,
+		0xc4, 'A', 'C', 'M', 'E',
+		0xc0,
+		0xc2, 'S', '1',
+		0xc2, 'P', '1',
+		0xc0,
+		fruEndOfFields,
+	)
+
+	ps := fruTestRecord(FRURecordTypePowerSupply, false,
+		0xee, 0x02, 0xff, 0xff, 0xff, 5,
+		0x28, 0x23, 0x20, 0x67, 0, 0, 0, 0,
+		47, 63, 10, 0x0e,
+		0x20, 0x13, 0x00, 0, 0, 0,
+	)
+
+	dc := fruTestRecord(FRURecordTypeDCOutput, true,
+		0x81, 0xb0, 0x04, 0xc4, 0xff, 0x3c, 0x00, 0x78, 0x00, 0x00, 0x00, 0x24, 0xf4,
+	)
+
+	chassisOff := 1
+	boardOff := chassisOff + len(chassis)/8
+	multiOff := boardOff + len(board)/8
+
+	hdr := []byte{0x01, 0, uint8(chassisOff), uint8(boardOff), 0, uint8(multiOff), 0}
+	hdr = append(hdr, checksum(hdr...))
+
+	b := append(hdr, chassis...)
+	b = append(b, board...)
+	b = append(b, ps...)
+
+	return append(b, dc...)
+}
+
+func TestDecodeFRU(t *testing.T) {
+	fru, err := decodeFRU(fruTestImage())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chassis := FRUChassisInfo{0x17, "CH-1", "1234", []string{"IPMI"}}
+	if fru.Chassis == nil || !reflect.DeepEqual(*fru.Chassis, chassis) {
+		t.Errorf("chassis info %+v, expected %+v", fru.Chassis, chassis)
+	}
+
+	board := FRUBoardInfo{
+		Language:     fruLanguageEnglish,
+		MfgDate:      time.Date(1996, 1, 1, 1, 0, 0, 0, time.UTC),
+		Manufacturer: "ACME",
+		SerialNumber: "S1",
+		PartNumber:   "P1",
+		Custom:       []string{},
+	}
+	if fru.Board == nil || !reflect.DeepEqual(*fru.Board, board) {
+		t.Errorf("board info %+v, expected %+v", fru.Board, board)
+	}
+
+	if fru.Product != nil {
+		t.Errorf("unexpected product info %+v", fru.Product)
+	}
+
+	ps := FRUPowerSupply{
+		Capacity:              750,
+		PeakVA:                0xffff,
+		InrushCurrent:         0xff,
+		InrushInterval:        5,
+		InputVoltage:          [2][2]float64{{90, 264}, {0, 0}},
+		InputFrequency:        [2]uint8{47, 63},
+		DropoutTolerance:      10,
+		HotSwap:               true,
+		Autoswitch:            true,
+		PowerFactorCorrection: true,
+		HoldUpTime:            1,
+		PeakCapacity:          800,
+	}
+	if len(fru.PowerSupplies) != 1 || fru.PowerSupplies[0] != ps {
+		t.Errorf("power supplies %+v, expected %+v", fru.PowerSupplies, ps)
+	}
+
+	dc := FRUDCOutput{
+		Output:      1,
+		Standby:     true,
+		Nominal:     12,
+		MaxNegative: -0.6,
+		MaxPositive: 0.6,
+		RippleNoise: 120,
+		MaxCurrent:  62.5,
+	}
+	if len(fru.DCOutputs) != 1 || fru.DCOutputs[0] != dc {
+		t.Errorf("DC outputs %+v, expected %+v", fru.DCOutputs, dc)
+	}
+
+	if len(fru.MultiRecords) != 2 {
+		t.Errorf("decoded %d multirecords", len(fru.MultiRecords))
+	}
+}
+
+func TestDecodeFRUChecksum(t *testing.T) {
+	for _, off := range []int{3, 12, 60} {
+		b := fruTestImage()
+		b[off]++
+
+		if _, err := decodeFRU(b); !errors.Is(err, errFRUChecksum) {
+			t.Errorf("corrupt byte %d: expected checksum error, got %v", off, err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package ipmi
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultDevice is the character device of the first IPMI interface of the Linux OpenIPMI driver
+const DefaultDevice = "/dev/ipmi0"
+
+// IPMB slave address of the BMC, which the driver uses as its own address on the IPMB
+const bmcSlaveAddr = 0x20
+
+// OpenIPMI receive message types, from linux/ipmi.h
+const (
+	ipmiResponseRecvType   = 1
+	ipmiAsyncEventRecvType = 2
+	ipmiCmdRecvType        = 3
+)
+
+// ipmiDevice is an OpenIPMI character device. Each method but poll and close corresponds to an
+// ioctl of the driver, which is replaced by a fake device in tests.
+type ipmiDevice interface {
+	// sendCommand sends a request to LUN lun of the BMC via the system interface, tagged with
+	// msgID (IPMICTL_SEND_COMMAND)
+	sendCommand(msgID int64, netFn, lun, cmd uint8, data []byte) error
+
+	// receiveMsg dequeues the next received message, truncating its data to the length of buf
+	// (IPMICTL_RECEIVE_MSG_TRUNC)
+	receiveMsg(buf []byte) (*ipmiRecv, error)
+
+	// setMyAddress sets the IPMB slave address of the driver (IPMICTL_SET_MY_ADDRESS_CMD)
+	setMyAddress(addr uint8) error
+
+	// setTimingParams sets the number of retries and the retry interval of requests that the
+	// driver forwards over IPMB (IPMICTL_SET_TIMING_PARMS_CMD)
+	setTimingParams(retries int, retryTime time.Duration) error
+
+	// poll waits for a message to be received, returning false if none arrived within timeout
+	poll(timeout time.Duration) (bool, error)
+
+	close() error
+}
+
+// ipmiRecv is a message received from the driver
+type ipmiRecv struct {
+	recvType uint8 // ipmiResponseRecvType, ipmiAsyncEventRecvType or ipmiCmdRecvType
+	msgID    int64 // Message ID of the request answered by a response
+	netFn    uint8
+	cmd      uint8
+	data     []byte // Response data, starting with the completion code
+}
+
+// inbandConnection is a transport to the local BMC via the OpenIPMI driver. The driver has no
+// sessions, and requests are sent with administrator privilege.
+type inbandConnection struct {
+	mu      sync.Mutex // Serialises requests
+	dev     ipmiDevice
+	msgID   int64 // ID of the last request sent
+	timeout time.Duration
+	retries int
+}
+
+func newInbandConnection(dev ipmiDevice) (*inbandConnection, error) {
+	ib := &inbandConnection{dev: dev}
+
+	if err := dev.setMyAddress(bmcSlaveAddr); err != nil {
+		return nil, fmt.Errorf("set IPMB address: %w", err)
+	}
+
+	if err := ib.SetTimeout(DefaultTimeout, DefaultRetries); err != nil {
+		return nil, err
+	}
+
+	return ib, nil
+}
+
+// SetTimeout implements Transport, setting the timing parameters of the driver. The total time to
+// wait for a response is set even if the driver rejects them.
+func (ib *inbandConnection) SetTimeout(timeout time.Duration, retries int) error {
+	ib.mu.Lock()
+	defer ib.mu.Unlock()
+
+	ib.timeout = timeout
+	ib.retries = retries
+
+	if err := ib.dev.setTimingParams(retries, timeout); err != nil {
+		return fmt.Errorf("set timing parameters: %w", err)
+	}
+
+	return nil
+}
+
+// SendRecv implements Transport. Requests are serialised, and messages other than the response to
+// the current request, such as late responses to abandoned requests, are discarded.
+func (ib *inbandConnection) SendRecv(req Request) ([]byte, error) {
+	ib.mu.Lock()
+	defer ib.mu.Unlock()
+
+	ib.msgID++
+	if err := ib.dev.sendCommand(ib.msgID, req.NetworkFunction, req.LUN, req.Command, req.Data); err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+
+	wait := ib.timeout * time.Duration(ib.retries+1)
+	deadline := time.Now().Add(wait)
+	buf := make([]byte, ipmiBufSize)
+
+	for {
+		timeout := time.Until(deadline)
+		if timeout <= 0 {
+			return nil, fmt.Errorf("no response within %v: %w", wait, ErrTimeout)
+		}
+
+		if ready, err := ib.dev.poll(timeout); err != nil {
+			return nil, fmt.Errorf("receive response: %w", err)
+		} else if !ready {
+			continue
+		}
+
+		recv, err := ib.dev.receiveMsg(buf)
+		if err != nil {
+			return nil, fmt.Errorf("receive response: %w", err)
+		}
+
+		if recv.recvType == ipmiResponseRecvType && recv.msgID == ib.msgID {
+			return checkCompletionCode(req, append([]byte(nil), recv.data...))
+		}
+	}
+}
+
+func (ib *inbandConnection) Close() error {
+	return ib.dev.close()
+}
@@ -0,0 +1,184 @@
+//go:build linux
+
+package ipmi
+
+import (
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// OpenIPMI address type and channel of the BMC's system interface, from linux/ipmi.h
+const (
+	ipmiSystemInterfaceAddrType = 0x0c
+	ipmiBMCChannel              = 0x0f
+	ipmiMaxAddrSize             = 32
+)
+
+// Structures of the OpenIPMI ioctls, from linux/ipmi.h. Go's alignment rules match those of C, and
+// int matches the size of a C long.
+type (
+	ipmictlSystemInterfaceAddr struct {
+		addrType int32
+		channel  int16
+		lun      uint8
+	}
+
+	// ipmictlAddr is large enough for any address type
+	ipmictlAddr struct {
+		addrType int32
+		channel  int16
+		data     [ipmiMaxAddrSize]byte
+	}
+
+	ipmictlMsg struct {
+		netFn   uint8
+		cmd     uint8
+		dataLen uint16
+		data    unsafe.Pointer
+	}
+
+	ipmictlReq struct {
+		addr    unsafe.Pointer
+		addrLen uint32
+		msgID   int
+		msg     ipmictlMsg
+	}
+
+	ipmictlRecv struct {
+		recvType int32
+		addr     unsafe.Pointer
+		addrLen  uint32
+		msgID    int
+		msg      ipmictlMsg
+	}
+
+	ipmictlTimingParms struct {
+		retries     int32
+		retryTimeMs uint32
+	}
+)
+
+// OpenIPMI ioctl requests, encoded per asm-generic/ioctl.h
+var (
+	ipmictlReceiveMsgTrunc   = ioc(iocRead|iocWrite, 11, unsafe.Sizeof(ipmictlRecv{}))
+	ipmictlSendCommand       = ioc(iocRead, 13, unsafe.Sizeof(ipmictlReq{}))
+	ipmictlSetMyAddressCmd   = ioc(iocRead, 17, unsafe.Sizeof(uint32(0)))
+	ipmictlSetTimingParmsCmd = ioc(iocRead, 22, unsafe.Sizeof(ipmictlTimingParms{}))
+)
+
+const (
+	iocWrite    = 1
+	iocRead     = 2
+	ipmiIOCType = 'i'
+)
+
+func ioc(dir, nr, size uintptr) uintptr {
+	return dir<<30 | size<<16 | ipmiIOCType<<8 | nr
+}
+
+// linuxDevice is an OpenIPMI character device, e.g. /dev/ipmi0
+type linuxDevice struct {
+	f *os.File
+}
+
+func openIPMIDevice(path string) (ipmiDevice, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &linuxDevice{f}, nil
+}
+
+func (d *linuxDevice) ioctl(req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, d.f.Fd(), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+func (d *linuxDevice) sendCommand(msgID int64, netFn, lun, cmd uint8, data []byte) error {
+	addr := &ipmictlSystemInterfaceAddr{
+		addrType: ipmiSystemInterfaceAddrType,
+		channel:  ipmiBMCChannel,
+		lun:      lun & 0x03,
+	}
+
+	req := &ipmictlReq{
+		addr:    unsafe.Pointer(addr),
+		addrLen: uint32(unsafe.Sizeof(*addr)),
+		msgID:   int(msgID),
+		msg:     ipmictlMsg{netFn: netFn, cmd: cmd, dataLen: uint16(len(data))},
+	}
+
+	if len(data) > 0 {
+		req.msg.data = unsafe.Pointer(&data[0])
+	}
+
+	return d.ioctl(ipmictlSendCommand, unsafe.Pointer(req))
+}
+
+func (d *linuxDevice) receiveMsg(buf []byte) (*ipmiRecv, error) {
+	addr := &ipmictlAddr{}
+
+	recv := &ipmictlRecv{
+		addr:    unsafe.Pointer(addr),
+		addrLen: uint32(unsafe.Sizeof(*addr)),
+		msg:     ipmictlMsg{dataLen: uint16(len(buf)), data: unsafe.Pointer(&buf[0])},
+	}
+
+	// Messages too large for buf are truncated and dequeued, reporting EMSGSIZE
+	if err := d.ioctl(ipmictlReceiveMsgTrunc, unsafe.Pointer(recv)); err != nil && err != syscall.EMSGSIZE {
+		return nil, err
+	}
+
+	return &ipmiRecv{
+		recvType: uint8(recv.recvType),
+		msgID:    int64(recv.msgID),
+		netFn:    recv.msg.netFn,
+		cmd:      recv.msg.cmd,
+		data:     buf[:min(int(recv.msg.dataLen), len(buf))],
+	}, nil
+}
+
+func (d *linuxDevice) setMyAddress(addr uint8) error {
+	v := uint32(addr)
+	return d.ioctl(ipmictlSetMyAddressCmd, unsafe.Pointer(&v))
+}
+
+func (d *linuxDevice) setTimingParams(retries int, retryTime time.Duration) error {
+	p := &ipmictlTimingParms{retries: int32(retries), retryTimeMs: uint32(retryTime.Milliseconds())}
+	return d.ioctl(ipmictlSetTimingParmsCmd, unsafe.Pointer(p))
+}
+
+// poll waits for the device to become readable, using ppoll since poll is not available on all
+// architectures
+func (d *linuxDevice) poll(timeout time.Duration) (bool, error) {
+	const pollIn = 0x0001
+
+	fds := [1]struct {
+		fd      int32
+		events  int16
+		revents int16
+	}{{fd: int32(d.f.Fd()), events: pollIn}}
+
+	ts := syscall.NsecToTimespec(int64(timeout))
+
+	n, _, errno := syscall.Syscall6(syscall.SYS_PPOLL, uintptr(unsafe.Pointer(&fds[0])), 1,
+		uintptr(unsafe.Pointer(&ts)), 0, 0, 0)
+	if errno == syscall.EINTR {
+		return false, nil
+	} else if errno != 0 {
+		return false, errno
+	}
+
+	return n > 0, nil
+}
+
+func (d *linuxDevice) close() error {
+	return d.f.Close()
+}
@@ -0,0 +1,29 @@
+//go:build linux
+
+package ipmi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestIoctlRequests(t *testing.T) {
+	if unsafe.Sizeof(uintptr(0)) != 8 {
+		t.Skip("ioctl requests below are for 64-bit architectures")
+	}
+
+	// Values from linux/ipmi.h on x86-64
+	for _, tt := range []struct {
+		name      string
+		req, want uintptr
+	}{
+		{"IPMICTL_RECEIVE_MSG_TRUNC", ipmictlReceiveMsgTrunc, 0xc030690b},
+		{"IPMICTL_SEND_COMMAND", ipmictlSendCommand, 0x8028690d},
+		{"IPMICTL_SET_MY_ADDRESS_CMD", ipmictlSetMyAddressCmd, 0x80046911},
+		{"IPMICTL_SET_TIMING_PARMS_CMD", ipmictlSetTimingParmsCmd, 0x80086916},
+	} {
+		if tt.req != tt.want {
+			t.Errorf("%s: %#x, expected %#x", tt.name, tt.req, tt.want)
+		}
+	}
+}
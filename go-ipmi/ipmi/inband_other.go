@@ -0,0 +1,10 @@
+//go:build !linux
+
+package ipmi
+
+import "errors"
+
+// openIPMIDevice is not implemented on this platform, which lacks the OpenIPMI driver
+func openIPMIDevice(path string) (ipmiDevice, error) {
+	return nil, errors.New("in-band IPMI not supported on this platform")
+}
@@ -0,0 +1,34 @@
+package ipmi_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+func TestInbandSimulator(t *testing.T) {
+	c := ipmi.NewFakeInbandClient(t, ipmisim.New(ipmisim.DefaultProfile()).HandleSystemInterface)
+
+	if id, err := c.GetDeviceID(); err != nil || id.ManufacturerID != ipmisim.DefaultProfile().Device.ManufacturerID {
+		t.Errorf("device ID %+v, error %v", id, err)
+	}
+
+	// Commands work without a session
+	checkSensors(t, c)
+
+	if err := c.ChassisControl(ipmi.ChassisPowerDown); err != nil {
+		t.Fatal(err)
+	}
+
+	if status, err := c.GetChassisStatus(); err != nil || status.PowerOn {
+		t.Errorf("chassis status %+v, error %v", status, err)
+	}
+
+	// Session commands are rejected by the system interface
+	req := ipmi.Request{NetworkFunction: ipmi.NetFnApp, Command: ipmi.CmdCloseSession, Data: make([]byte, 4)}
+	if err := c.Send(req, nil); !errors.Is(err, ipmi.ErrInvalidCommand) {
+		t.Errorf("expected invalid command, got %v", err)
+	}
+}
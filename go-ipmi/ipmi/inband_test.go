@@ -0,0 +1,203 @@
+package ipmi
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDevice is an OpenIPMI character device answering requests with handle, such as a simulator's
+// system interface
+type fakeDevice struct {
+	handle func(netFn, cmd uint8, data []byte) []byte
+
+	mu        sync.Mutex
+	received  []*ipmiRecv // Messages awaiting receipt
+	ready     chan struct{}
+	drop      bool // Discard requests instead of answering them
+	myAddr    uint8
+	retries   int
+	retryTime time.Duration
+	timingErr error // Returned by setTimingParams
+	closed    bool
+}
+
+func newFakeDevice(handle func(netFn, cmd uint8, data []byte) []byte) *fakeDevice {
+	return &fakeDevice{handle: handle, ready: make(chan struct{}, 1)}
+}
+
+// queue adds a received message, waking a blocked poll
+func (d *fakeDevice) queue(recv *ipmiRecv) {
+	d.mu.Lock()
+	d.received = append(d.received, recv)
+	d.mu.Unlock()
+
+	select {
+	case d.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (d *fakeDevice) sendCommand(msgID int64, netFn, lun, cmd uint8, data []byte) error {
+	d.mu.Lock()
+	drop := d.drop
+	d.mu.Unlock()
+
+	if !drop {
+		resp := d.handle(netFn, cmd, data)
+		d.queue(&ipmiRecv{ipmiResponseRecvType, msgID, netFn | 1, cmd, resp})
+	}
+
+	return nil
+}
+
+func (d *fakeDevice) receiveMsg(buf []byte) (*ipmiRecv, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.received) == 0 {
+		return nil, errors.New("no message available")
+	}
+
+	recv := *d.received[0]
+	d.received = d.received[1:]
+	recv.data = buf[:copy(buf, recv.data)]
+
+	return &recv, nil
+}
+
+func (d *fakeDevice) setMyAddress(addr uint8) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.myAddr = addr
+	return nil
+}
+
+func (d *fakeDevice) setTimingParams(retries int, retryTime time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.retries, d.retryTime = retries, retryTime
+	return d.timingErr
+}
+
+func (d *fakeDevice) poll(timeout time.Duration) (bool, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		d.mu.Lock()
+		n := len(d.received)
+		d.mu.Unlock()
+
+		if n > 0 {
+			return true, nil
+		}
+
+		select {
+		case <-d.ready:
+		case <-timer.C:
+			return false, nil
+		}
+	}
+}
+
+func (d *fakeDevice) close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	return nil
+}
+
+// newInbandClient returns a client connected in-band to a fake device
+func newInbandClient(t *testing.T, dev *fakeDevice) *Client {
+	ib, err := newInbandConnection(dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient(ib)
+	if err := c.SetTimeout(50*time.Millisecond, 1); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	return c
+}
+
+// testSystemInterface answers Get Device ID and Get Self Test Results, rejecting other commands
+func testSystemInterface(netFn, cmd uint8, data []byte) []byte {
+	switch {
+	case netFn == NetFnApp && cmd == CmdGetDeviceID:
+		return []byte{0, 0x20, 0x01, 0x01, 0x23, 0x02, 0x87, 0x57, 0x01, 0x00, 0x01, 0x00}
+	case netFn == NetFnApp && cmd == CmdGetSelfTestResults:
+		return []byte{0, 0x55, 0}
+	}
+	return []byte{uint8(ErrInvalidCommand)}
+}
+
+func TestInband(t *testing.T) {
+	dev := newFakeDevice(testSystemInterface)
+	c := newInbandClient(t, dev)
+
+	if dev.myAddr != bmcSlaveAddr || dev.retries != 1 || dev.retryTime != 50*time.Millisecond {
+		t.Errorf("address %#x, timing parameters %d, %v", dev.myAddr, dev.retries, dev.retryTime)
+	}
+
+	if id, err := c.GetDeviceID(); err != nil || id.ManufacturerID != 343 {
+		t.Errorf("device ID %+v, error %v", id, err)
+	}
+
+	// Events and late responses to earlier requests are discarded
+	dev.queue(&ipmiRecv{ipmiAsyncEventRecvType, 0, NetFnSensorEvent, 0x02, nil})
+	dev.queue(&ipmiRecv{ipmiResponseRecvType, 1, NetFnApp | 1, CmdGetDeviceID, []byte{0}})
+
+	if _, err := c.GetSelfTestResults(); err != nil {
+		t.Error(err)
+	}
+
+	if err := c.OpenSession("admin", "admin", PrivLevelAdmin); !errors.Is(err, ErrLANOnly) {
+		t.Errorf("expected LAN only error, got %v", err)
+	}
+
+	dev.mu.Lock()
+	dev.drop = true
+	dev.mu.Unlock()
+
+	if _, err := c.GetDeviceID(); !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected timeout, got %v", err)
+	}
+
+	c.Close()
+	if !dev.closed {
+		t.Error("device not closed")
+	}
+}
+
+func TestInbandTimingParams(t *testing.T) {
+	errInvalid := errors.New("invalid argument")
+
+	dev := newFakeDevice(testSystemInterface)
+	dev.timingErr = errInvalid
+	if _, err := newInbandConnection(dev); !errors.Is(err, errInvalid) {
+		t.Errorf("open: expected timing parameters error, got %v", err)
+	}
+
+	dev = newFakeDevice(testSystemInterface)
+	c := newInbandClient(t, dev)
+
+	dev.mu.Lock()
+	dev.timingErr = errInvalid
+	dev.drop = true
+	dev.mu.Unlock()
+
+	if err := c.SetTimeout(10*time.Millisecond, 0); !errors.Is(err, errInvalid) {
+		t.Errorf("expected timing parameters error, got %v", err)
+	}
+
+	// The time to wait for a response is set regardless
+	start := time.Now()
+	if _, err := c.GetDeviceID(); !errors.Is(err, ErrTimeout) || time.Since(start) > 80*time.Millisecond {
+		t.Errorf("expected timeout after 10ms, got %v after %v", err, time.Since(start))
+	}
+}
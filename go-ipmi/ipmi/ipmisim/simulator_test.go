@@ -0,0 +1,180 @@
+package ipmisim
+
+import (
+	"errors"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+)
+
+// newTestSimulator starts a simulator on a local UDP socket, returning its address
+func newTestSimulator(t *testing.T, profile *Profile) (*Simulator, string) {
+	pc, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim := New(profile)
+	go sim.Serve(pc)
+	t.Cleanup(func() { sim.Close() })
+
+	return sim, pc.LocalAddr().String()
+}
+
+// dialSimulator connects a client to the simulator, with short timeouts
+func dialSimulator(t *testing.T, addr string) *ipmi.Client {
+	c, err := ipmi.Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.SetTimeout(50*time.Millisecond, 2)
+	t.Cleanup(func() { c.Close() })
+
+	return c
+}
+
+// checkSensors reads all sensors in the default profile and checks their first readings
+func checkSensors(t *testing.T, c *ipmi.Client) {
+	records, err := c.ReadSDRRepository()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != len(DefaultProfile().Sensors) {
+		t.Fatalf("read %d SDR records", len(records))
+	}
+
+	values := make(map[string]*ipmi.SensorValue)
+
+	for _, rec := range records {
+		v, err := c.ReadSensor(rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values[v.Name] = v
+	}
+
+	for name, want := range map[string]float64{"CPU Temp": 45, "Fan 1": 4800, "12V": 12} {
+		v := values[name]
+		if v == nil || !v.Analog || math.Abs(v.Value-want) > 1e-9 {
+			t.Errorf("sensor %q: unexpected value %+v", name, v)
+		} else if v.ThresholdStatus() != "ok" {
+			t.Errorf("sensor %q: unexpected status %s", name, v.ThresholdStatus())
+		}
+	}
+
+	if v := values["PS1 Status"]; v == nil || v.Analog || v.Reading.States != 0x0001 {
+		t.Errorf("sensor \"PS1 Status\": unexpected reading %+v", v)
+	}
+}
+
+func TestSimulatorSessionIPMIv15(t *testing.T) {
+	for _, authType := range []uint8{ipmi.AuthTypeNone, ipmi.AuthTypeMD2, ipmi.AuthTypeMD5, ipmi.AuthTypePassword} {
+		profile := DefaultProfile()
+		profile.IPMIv20 = false
+		profile.AuthTypes = []uint8{authType}
+
+		_, addr := newTestSimulator(t, profile)
+		c := dialSimulator(t, addr)
+
+		if err := c.OpenSession("admin", "admin", ipmi.PrivLevelAdmin); err != nil {
+			t.Fatalf("auth type %d: %v", authType, err)
+		}
+
+		if c.Version() != ipmi.IPMIVersion15 || c.PrivLevel() != ipmi.PrivLevelAdmin {
+			t.Errorf("auth type %d: version %#x, privilege level %d", authType, c.Version(), c.PrivLevel())
+		}
+
+		checkSensors(t, c)
+	}
+}
+
+func TestSimulatorSessionIPMIv20(t *testing.T) {
+	for _, suite := range DefaultProfile().CipherSuites {
+		profile := DefaultProfile()
+		profile.CipherSuites = []uint8{suite}
+
+		_, addr := newTestSimulator(t, profile)
+		c := dialSimulator(t, addr)
+
+		if err := c.OpenSession("operator", "operator", ipmi.PrivLevelOperator); err != nil {
+			t.Fatalf("cipher suite %d: %v", suite, err)
+		}
+
+		if c.Version() != ipmi.IPMIVersion20 || c.PrivLevel() != ipmi.PrivLevelOperator {
+			t.Errorf("cipher suite %d: version %#x, privilege level %d", suite, c.Version(), c.PrivLevel())
+		}
+
+		checkSensors(t, c)
+	}
+}
+
+func TestSimulatorAuthFailure(t *testing.T) {
+	profile := DefaultProfile()
+	_, addr := newTestSimulator(t, profile)
+
+	tests := []struct {
+		username, password string
+		priv               uint8
+		err                error
+	}{
+		{"admin", "wrong", ipmi.PrivLevelAdmin, ipmi.ErrIntegrity},
+		{"nobody", "nobody", ipmi.PrivLevelAdmin, ipmi.RAKPStatus(0x0d)},
+		{"user", "user", ipmi.PrivLevelAdmin, ipmi.RAKPStatus(0x0a)},
+	}
+
+	for _, tt := range tests {
+		err := dialSimulator(t, addr).OpenSession(tt.username, tt.password, tt.priv)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("user %q: expected error %q, got %v", tt.username, tt.err, err)
+		}
+	}
+
+	// IPMI v1.5 BMCs reject unknown users when issuing the challenge
+	profile.IPMIv20 = false
+	_, addr = newTestSimulator(t, profile)
+
+	err := dialSimulator(t, addr).OpenSession("nobody", "nobody", ipmi.PrivLevelAdmin)
+
+	var cmdErr *ipmi.CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Command != ipmi.CmdGetSessionChallenge || !errors.Is(err, ipmi.ErrInvalidUserName) {
+		t.Errorf("expected invalid user name error, got %v", err)
+	}
+}
+
+func TestSimulatorFaults(t *testing.T) {
+	profile := DefaultProfile()
+	profile.MaxSDRRead = 8
+
+	sim, addr := newTestSimulator(t, profile)
+	sim.SetFaults(Faults{Loss: 0.2, Seed: 1})
+
+	c := dialSimulator(t, addr)
+	c.SetTimeout(10*time.Millisecond, 10)
+
+	if err := c.OpenSession("admin", "admin", ipmi.PrivLevelAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	// Session setup payloads are not integrity protected, so corrupt responses are only injected
+	// once the session is established.
+	sim.SetFaults(Faults{Loss: 0.2, Malformed: 0.2, Seed: 1})
+
+	records, err := c.ReadSDRRepository()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rec := range records {
+		if _, err := c.ReadSensor(rec); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// A retransmitted Close Session would go unanswered once the session has been closed
+	sim.SetFaults(Faults{})
+}
@@ -0,0 +1,669 @@
+package ipmi
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+const ipmiBufSize = 1024
+
+// Software ID of a remote console, the requester address of LAN requests
+const remoteConsoleAddr = 0x81
+
+// Maximum number of outstanding requests, limited by the 6-bit requester sequence number
+const maxOutstanding = 64
+
+// Default retransmission parameters
+const (
+	DefaultTimeout = time.Second
+	DefaultRetries = 3
+)
+
+// IPMI versions
+const (
+	IPMIVersion15 = 0x15
+	IPMIVersion20 = 0x20
+)
+
+var (
+	ErrTimeout        = errors.New("timeout waiting for response")
+	ErrTooManyPending = errors.New("too many outstanding requests")
+)
+
+// Completion codes specific to Get Session Challenge
+var (
+	ErrInvalidUserName  = commandCode(0x81, NetFnApp, CmdGetSessionChallenge)
+	ErrNullUserDisabled = commandCode(0x82, NetFnApp, CmdGetSessionChallenge) // Null user name not enabled
+)
+
+// requestKey identifies the response to an outstanding request. For IPMI payloads, responses are
+// matched by requester sequence number, network function and command. Session setup payloads are
+// matched by payload type alone, and Presence Pongs by the ASF message tag in rqSeq.
+type requestKey struct {
+	class       uint8 // RMCP message class, or zero for IPMI
+	payloadType uint8
+	rqSeq       uint8
+	netFn       uint8 // Response network function
+	cmd         uint8
+}
+
+// response is a decoded response dispatched to the goroutine awaiting it
+type response struct {
+	data []byte
+	err  error
+}
+
+type lanConnection struct {
+	mu       sync.Mutex                   // Protects session state and pending requests
+	pending  map[requestKey]chan response // Outstanding requests awaiting a response
+	payloads map[uint8]chan []byte        // Active payloads by type, receiving unsolicited packets
+	rqSeq    uint8                        // Next requester sequence number
+	done     chan struct{}                // Closed when receive loop exits
+	readErr  error                        // Error that terminated receive loop
+
+	conn               net.Conn // Socket connection
+	version            uint8    // IPMI version of session
+	username           [16]byte // Username, null padded
+	password           [20]byte // Password, null padded
+	authType           uint8    // Session authentication type
+	perMsgAuthDisabled bool     // BMC does not authenticate messages after session activation
+	priv               uint8    // Privilege level
+	sequence           uint32   // Inbound session sequence number (remote console to BMC)
+	outSequence        uint32   // Outbound session sequence number (BMC to remote console)
+	sessionID          uint32   // Session ID assigned by BMC
+	asfTag             uint8    // Message tag of the last Presence Ping
+
+	// RMCP+ session state
+	SessionKeys               // Negotiated cipher suite and keys
+	consoleSessionID uint32   // Session ID assigned by remote console
+	bmcGUID          [16]byte // Managed system GUID
+	sik              []byte   // Session integrity key
+	inSequence       uint32   // Highest sequence number of authenticated packets from the BMC
+	inWindow         uint32   // Sequence numbers received, bit n for inSequence - n
+
+	timeout time.Duration // Time to wait for a response before retransmitting
+	retries int           // Maximum number of retransmissions
+}
+
+func newLanConnection(host string) (*lanConnection, error) {
+	l := &lanConnection{
+		pending:  make(map[requestKey]chan response),
+		payloads: make(map[uint8]chan []byte),
+		done:     make(chan struct{}),
+		timeout:  DefaultTimeout,
+		retries:  DefaultRetries,
+	}
+
+	// Deadline only applies to resolving the host name
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	if conn, err := dialer.DialContext(ctx, "udp4", host); err != nil {
+		return nil, err
+	} else {
+		l.conn = conn
+	}
+
+	go l.recvLoop()
+
+	return l, nil
+}
+
+func (l *lanConnection) SetTimeout(timeout time.Duration, retries int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.timeout = timeout
+	l.retries = retries
+
+	return nil
+}
+
+// timing returns the timeout and maximum number of retransmissions
+func (l *lanConnection) timing() (time.Duration, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.timeout, l.retries
+}
+
+func (l *lanConnection) Close() error {
+	l.mu.Lock()
+	active := l.sessionID != 0
+	l.mu.Unlock()
+
+	if active {
+		l.closeSession()
+	}
+	return l.conn.Close()
+}
+
+// openSession establishes a session with the BMC, using RMCP+ if the BMC supports IPMI v2.0 and
+// falling back to an IPMI v1.5 session per section 13.14 otherwise.
+func (l *lanConnection) openSession(username, password string, priv uint8) error {
+	l.mu.Lock()
+	l.username = [16]byte{}
+	l.password = [20]byte{}
+	copy(l.username[:], username)
+	copy(l.password[:], password)
+	l.priv = priv
+	l.mu.Unlock()
+
+	caps, err := l.getAuthCapabilities(CurrentChannel, priv)
+	if err != nil {
+		return err
+	}
+
+	if err := l.selectAuthType(caps); err != nil {
+		return err
+	}
+
+	if l.version == IPMIVersion20 {
+		if err := l.openRMCPPlusSession(); err != nil {
+			return err
+		}
+
+		return l.setSessionPrivLevel(l.priv)
+	}
+
+	challenge, err := l.getSessionChallenge()
+	if err != nil {
+		return err
+	}
+
+	if err := l.activateSession(challenge); err != nil {
+		return err
+	}
+
+	return l.setSessionPrivLevel(l.priv)
+}
+
+func (l *lanConnection) getAuthCapabilities(channel, priv uint8) (*AuthCapabilitiesResponse, error) {
+	req := &AuthCapabilitiesRequest{ExtendedData: true, ChannelNumber: channel, PrivLevel: priv}
+	resp := &AuthCapabilitiesResponse{}
+
+	if err := l.send(NetFnApp, CmdGetChannelAuthCapabilities, req, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ping sends an RMCP Presence Ping, which is answered outside of any session
+func (l *lanConnection) ping() (*Pong, error) {
+	l.mu.Lock()
+	l.asfTag = (l.asfTag + 1) % asfTagNoResponse
+	tag := l.asfTag
+	l.mu.Unlock()
+
+	data, err := l.roundTrip(requestKey{class: rmcpClassASF, rqSeq: tag}, func() ([]byte, error) {
+		return EncodeASFMessage(asfTypePresencePing, tag, nil), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pong := &Pong{}
+	if err := pong.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("decode presence pong: %w", err)
+	}
+
+	return pong, nil
+}
+
+// selectAuthType chooses the session type and authentication type from those offered by the BMC
+func (l *lanConnection) selectAuthType(resp *AuthCapabilitiesResponse) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.perMsgAuthDisabled = resp.PerMsgAuthDisabled
+
+	// Extended capabilities are only valid if the BMC indicates IPMI v2.0 extended data
+	l.version = IPMIVersion15
+	if resp.ExtendedData && resp.ExtCapabilities&ExtCapIPMIv20 != 0 {
+		l.version = IPMIVersion20
+		return nil
+	}
+
+	// Check for supported auth type in order of preference
+	for _, t := range []uint8{AuthTypeMD5, AuthTypeMD2, AuthTypePassword, AuthTypeNone} {
+		if resp.AuthTypes&(1<<t) != 0 {
+			l.authType = t
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no supported authentication type offered by BMC: %#x", resp.AuthTypes)
+}
+
+// getSessionChallenge requests a temporary session ID and challenge string from the BMC
+func (l *lanConnection) getSessionChallenge() ([16]byte, error) {
+	req := &SessionChallengeRequest{l.authType, string(bytes.TrimRight(l.username[:], "\x00"))}
+	resp := SessionChallengeResponse{}
+
+	if err := l.send(NetFnApp, CmdGetSessionChallenge, req, &resp); err != nil {
+		return resp.Challenge, err
+	}
+
+	// Subsequent messages up to session activation are sent with the temporary session ID
+	l.mu.Lock()
+	l.sessionID = resp.TemporarySessionID
+	l.mu.Unlock()
+
+	return resp.Challenge, nil
+}
+
+// activateSession activates the session using the challenge string previously obtained from the
+// BMC, and records the session ID and initial inbound sequence number that it assigns.
+func (l *lanConnection) activateSession(challenge [16]byte) error {
+	// Outbound sequence number must be non-zero
+	outSequence := rand.Uint32() | 1
+
+	req := &ActivateSessionRequest{l.authType, l.priv, challenge, outSequence}
+	resp := ActivateSessionResponse{}
+
+	if err := l.send(NetFnApp, CmdActivateSession, req, &resp); err != nil {
+		l.mu.Lock()
+		l.sessionID = 0
+		l.mu.Unlock()
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.outSequence = outSequence
+	l.authType = resp.AuthType
+	l.sessionID = resp.SessionID
+
+	// Sequence number zero is reserved for messages outside of a session
+	l.sequence = resp.InitialInboundSeq
+	if l.sequence == 0 {
+		l.sequence++
+	}
+
+	if resp.MaxPrivLevel < l.priv {
+		l.priv = resp.MaxPrivLevel
+	}
+
+	return nil
+}
+
+// setSessionPrivLevel raises the session privilege level, which always starts at User level
+func (l *lanConnection) setSessionPrivLevel(priv uint8) error {
+	resp := SessionPrivLevel{}
+
+	if err := l.send(NetFnApp, CmdSetSessionPrivLevel, &SessionPrivLevel{priv}, &resp); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.priv = resp.PrivLevel
+	l.mu.Unlock()
+
+	return nil
+}
+
+func (l *lanConnection) closeSession() error {
+	l.mu.Lock()
+	sessionID := l.sessionID
+	l.mu.Unlock()
+
+	err := l.send(NetFnApp, CmdCloseSession, &CloseSessionRequest{sessionID}, nil)
+
+	// Session is no longer usable once the BMC has responded, even with an error
+	var cmdErr *CommandError
+	if err == nil || errors.As(err, &cmdErr) {
+		l.mu.Lock()
+		l.sessionID = 0
+		l.sequence = 0
+		l.mu.Unlock()
+	}
+
+	return err
+}
+
+// sessionFormat wraps payloads in the session packets of an IPMI version, and verifies and unwraps
+// the session packets received from the BMC. Methods must be called with l.mu held, since encoding
+// consumes a session sequence number.
+type sessionFormat interface {
+	encode(payloadType uint8, payload []byte) ([]byte, error)
+	decode(b []byte) (payloadType uint8, payload []byte, err error)
+}
+
+// ipmiV15Format frames IPMI v1.5 session packets, which carry only IPMI messages, authenticated by
+// the auth code of the session
+type ipmiV15Format struct {
+	l *lanConnection
+}
+
+func (f ipmiV15Format) encode(payloadType uint8, payload []byte) ([]byte, error) {
+	if payloadType != payloadTypeIPMI {
+		return nil, fmt.Errorf("payload type %#x requires IPMI v2.0", payloadType)
+	}
+
+	session := SessionHeader{
+		Sequence:  f.l.nextSequence(),
+		SessionID: f.l.sessionID,
+	}
+
+	// Messages outside of a session (i.e. prior to Activate Session) are unauthenticated
+	if f.l.sessionID != 0 {
+		session.AuthType = f.l.authType
+	}
+
+	return EncodeSessionPacket(session, f.l.password[:], payload), nil
+}
+
+func (f ipmiV15Format) decode(b []byte) (uint8, []byte, error) {
+	m, err := newMessageFromBytes(b)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := f.verifyAuthCode(m); err != nil {
+		return 0, nil, err
+	}
+
+	return payloadTypeIPMI, m.payload, nil
+}
+
+// format returns the session format of outgoing IPMI messages, which are sent in IPMI v1.5 format
+// prior to RMCP+ session activation. Must be called with l.mu held.
+func (l *lanConnection) format() sessionFormat {
+	if l.version == IPMIVersion20 && l.sessionID != 0 {
+		return rmcpPlusFormat{l}
+	}
+	return ipmiV15Format{l}
+}
+
+// message builds a request packet, addressed to the BMC. Must be called with l.mu held, since it
+// consumes a session sequence number.
+func (l *lanConnection) message(req Request, rqSeq uint8) ([]byte, error) {
+	msg := EncodeMessage(MessageHeader{
+		RsAddr:     bmcSlaveAddr,
+		NetFnRsLUN: (req.NetworkFunction << 2) | (req.LUN & 3), // NetFn, target LUN
+		RqAddr:     remoteConsoleAddr,                          // Source address
+		RqSeq:      rqSeq << 2,                                 // Sequence number, requester LUN 0
+		Command:    req.Command,
+	}, req.Data)
+
+	return l.format().encode(payloadTypeIPMI, msg)
+}
+
+// nextSequence returns the session sequence number for the next outgoing message. The sequence
+// number remains zero until a session has been activated, and skips zero upon wrapping.
+func (l *lanConnection) nextSequence() uint32 {
+	seq := l.sequence
+	if l.sequence != 0 {
+		l.sequence++
+		if l.sequence == 0 {
+			l.sequence++
+		}
+	}
+	return seq
+}
+
+// decodeResponse decodes a packet received from the BMC, returning the key of the request that it
+// answers and the response data. Must be called with l.mu held.
+func (l *lanConnection) decodeResponse(b []byte) (requestKey, []byte, error) {
+	var key requestKey
+
+	hdr, err := decodeRMCPHeader(b)
+	if err != nil {
+		return key, nil, err
+	}
+
+	if hdr.Class == rmcpClassASF {
+		msgType, tag, data, err := DecodeASFMessage(b)
+		if err != nil {
+			return key, nil, err
+		} else if msgType != asfTypePresencePong {
+			return key, nil, fmt.Errorf("unsupported ASF message type: %#x", msgType)
+		}
+
+		return requestKey{class: rmcpClassASF, rqSeq: tag}, data, nil
+	} else if hdr.Class != rmcpClassIPMI {
+		return key, nil, fmt.Errorf("unsupported RMCP class: %#x", hdr.Class)
+	}
+
+	// Session format of the response is identified by its auth type field
+	var format sessionFormat = ipmiV15Format{l}
+	if len(b) > rmcpHeaderSize && b[rmcpHeaderSize] == authTypeRMCPPlus {
+		format = rmcpPlusFormat{l}
+	}
+
+	payloadType, payload, err := format.decode(b)
+	if err != nil {
+		return key, nil, err
+	}
+
+	if payloadType != payloadTypeIPMI {
+		return requestKey{payloadType: payloadType}, payload, nil
+	}
+
+	msg, data, err := DecodeMessage(payload)
+	if err != nil {
+		return key, nil, err
+	}
+
+	// Responder and requester fields are swapped in responses
+	key = requestKey{
+		payloadType: payloadTypeIPMI,
+		rqSeq:       msg.RqSeq >> 2,
+		netFn:       msg.NetFnRsLUN >> 2,
+		cmd:         msg.Command,
+	}
+
+	return key, data, nil
+}
+
+// recvLoop receives packets from the BMC and dispatches them to the goroutines awaiting them,
+// until the connection is closed. Packets that cannot be decoded or that do not match an
+// outstanding request are discarded.
+func (l *lanConnection) recvLoop() {
+	for {
+		pkt, err := l.recvPacket()
+		if err != nil {
+			l.mu.Lock()
+			l.readErr = err
+			l.mu.Unlock()
+			close(l.done)
+			return
+		}
+
+		l.mu.Lock()
+		key, data, err := l.decodeResponse(pkt)
+		if ch, ok := l.pending[key]; ok && err == nil {
+			delete(l.pending, key)
+			ch <- response{data, nil}
+		} else if ch, ok := l.payloads[key.payloadType]; ok && err == nil && key.payloadType != payloadTypeIPMI {
+			// Payload packets are dropped if not consumed, and retransmitted by the BMC
+			select {
+			case ch <- data:
+			default:
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// allocRqSeq returns an unused requester sequence number. Must be called with l.mu held.
+func (l *lanConnection) allocRqSeq() (uint8, error) {
+	inUse := make(map[uint8]bool)
+	for key := range l.pending {
+		if key.payloadType == payloadTypeIPMI {
+			inUse[key.rqSeq] = true
+		}
+	}
+
+	for i := 0; i < maxOutstanding; i++ {
+		seq := l.rqSeq
+		l.rqSeq = (l.rqSeq + 1) % maxOutstanding
+		if !inUse[seq] {
+			return seq, nil
+		}
+	}
+
+	return 0, ErrTooManyPending
+}
+
+// roundTrip sends a packet and waits for the response matching key. If no response is received
+// within the timeout, a new packet is built and sent, doubling the timeout on each attempt.
+// Packets are rebuilt for each attempt so that they carry a fresh session sequence number. The
+// build function is called with l.mu held.
+func (l *lanConnection) roundTrip(key requestKey, build func() ([]byte, error)) ([]byte, error) {
+	ch := make(chan response, 1)
+
+	l.mu.Lock()
+	if _, ok := l.pending[key]; ok {
+		l.mu.Unlock()
+		return nil, fmt.Errorf("duplicate request: %+v", key)
+	}
+	l.pending[key] = ch
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		delete(l.pending, key)
+		l.mu.Unlock()
+	}()
+
+	timeout, retries := l.timing()
+
+	for attempt := 0; attempt <= retries; attempt++ {
+		l.mu.Lock()
+		pkt, err := build()
+		l.mu.Unlock()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := l.conn.Write(pkt); err != nil {
+			return nil, fmt.Errorf("send request: %w", err)
+		}
+
+		timer := time.NewTimer(timeout)
+
+		select {
+		case resp := <-ch:
+			timer.Stop()
+			return resp.data, resp.err
+		case <-l.done:
+			timer.Stop()
+			return nil, fmt.Errorf("receive response: %w", l.readErr)
+		case <-timer.C:
+			timeout *= 2
+		}
+	}
+
+	return nil, fmt.Errorf("no response after %d attempts: %w", retries+1, ErrTimeout)
+}
+
+// pendingResponse is an outstanding request that was not sent by roundTrip, such as a request
+// bridged by the BMC, whose response the BMC sends in a packet of its own
+type pendingResponse struct {
+	l     *lanConnection
+	key   requestKey
+	ch    chan response
+	rqSeq uint8 // Requester sequence number to send the request with
+}
+
+// expect allocates a requester sequence number for a request of network function netFn and command
+// cmd that is sent by other means, and registers it to receive the response. The registration must
+// be released with cancel.
+func (l *lanConnection) expect(netFn, cmd uint8) (*pendingResponse, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rqSeq, err := l.allocRqSeq()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pendingResponse{
+		l:     l,
+		key:   requestKey{payloadType: payloadTypeIPMI, rqSeq: rqSeq, netFn: netFn | 1, cmd: cmd},
+		ch:    make(chan response, 1),
+		rqSeq: rqSeq,
+	}
+	l.pending[p.key] = p.ch
+
+	return p, nil
+}
+
+// wait waits up to timeout for the response, returning its data starting with the completion code
+func (p *pendingResponse) wait(timeout time.Duration) ([]byte, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case resp := <-p.ch:
+		return resp.data, resp.err
+	case <-p.l.done:
+		return nil, fmt.Errorf("receive response: %w", p.l.readErr)
+	case <-timer.C:
+		return nil, fmt.Errorf("no response within %v: %w", timeout, ErrTimeout)
+	}
+}
+
+// cancel releases the requester sequence number, if the response has not been received
+func (p *pendingResponse) cancel() {
+	p.l.mu.Lock()
+	defer p.l.mu.Unlock()
+
+	if p.l.pending[p.key] == p.ch {
+		delete(p.l.pending, p.key)
+	}
+}
+
+func (l *lanConnection) recvPacket() ([]byte, error) {
+	buf := make([]byte, ipmiBufSize)
+	n, err := l.conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf[:n], nil
+}
+
+// send sends a session management request, which is specific to LAN connections
+func (l *lanConnection) send(netFn, cmd uint8, req encoding.BinaryMarshaler, resp encoding.BinaryUnmarshaler) error {
+	return (&conn{l}).send(netFn, cmd, req, resp)
+}
+
+// SendRecv implements Transport, multiplexing concurrent requests by requester sequence number
+func (l *lanConnection) SendRecv(req Request) ([]byte, error) {
+	l.mu.Lock()
+	rqSeq, err := l.allocRqSeq()
+	l.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	key := requestKey{
+		payloadType: payloadTypeIPMI,
+		rqSeq:       rqSeq,
+		netFn:       req.NetworkFunction | 1,
+		cmd:         req.Command,
+	}
+
+	data, err := l.roundTrip(key, func() ([]byte, error) {
+		return l.message(req, rqSeq)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return checkCompletionCode(req, data)
+}
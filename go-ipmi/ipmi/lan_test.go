@@ -0,0 +1,156 @@
+package ipmi
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestConnection returns a lanConnection to a local UDP socket, with short timeouts
+func newTestConnection(t *testing.T) (*lanConnection, net.PacketConn) {
+	pc, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := newLanConnection(pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.timeout = 20 * time.Millisecond
+	l.retries = 2
+
+	return l, pc
+}
+
+// testResponse builds a sessionless IPMI v1.5 response to a request packet
+func testResponse(t *testing.T, req []byte, data []byte) []byte {
+	m, err := newMessageFromBytes(req)
+	if err != nil {
+		t.Error(err)
+		return nil
+	}
+
+	msg := EncodeMessage(MessageHeader{
+		RsAddr:     m.RqAddr,
+		NetFnRsLUN: m.NetFnRsLUN | 0x04, // Response network function
+		RqAddr:     m.RsAddr,
+		RqSeq:      m.RqSeq,
+		Command:    m.Command,
+	}, data)
+
+	buf := new(bytes.Buffer)
+	binaryWrite(buf, rmcpHeader{Version: rmcpVersion1, RMCPSequenceNumber: 0xff, Class: rmcpClassIPMI})
+	binaryWrite(buf, SessionHeader{})
+	buf.WriteByte(uint8(len(msg)))
+	buf.Write(msg)
+
+	return buf.Bytes()
+}
+
+func TestRoundTripRetransmit(t *testing.T) {
+	l, pc := newTestConnection(t)
+	defer pc.Close()
+	defer l.conn.Close()
+
+	// Drop the first request and answer the second
+	go func() {
+		buf := make([]byte, ipmiBufSize)
+		for i := 0; ; i++ {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if i > 0 {
+				pc.WriteTo(testResponse(t, buf[:n], []byte{0x00, uint8(i)}), addr)
+			}
+		}
+	}()
+
+	data, err := l.SendRecv(Request{NetworkFunction: NetFnApp, Command: CmdGetDeviceID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, []byte{0x00, 0x01}) {
+		t.Errorf("unexpected response: % x", data)
+	}
+}
+
+func TestRoundTripTimeout(t *testing.T) {
+	l, pc := newTestConnection(t)
+	defer pc.Close()
+	defer l.conn.Close()
+
+	_, err := l.SendRecv(Request{NetworkFunction: NetFnApp, Command: CmdGetDeviceID})
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	const n = 32
+
+	l, pc := newTestConnection(t)
+	defer pc.Close()
+	defer l.conn.Close()
+
+	l.timeout = time.Second
+
+	// Collect all requests, then answer them in reverse order, echoing the request data
+	go func() {
+		var (
+			reqs  [][]byte
+			addrs []net.Addr
+		)
+
+		for len(reqs) < n {
+			buf := make([]byte, ipmiBufSize)
+			size, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			reqs = append(reqs, buf[:size])
+			addrs = append(addrs, addr)
+		}
+
+		for i := len(reqs) - 1; i >= 0; i-- {
+			m, err := newMessageFromBytes(reqs[i])
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			pc.WriteTo(testResponse(t, reqs[i], append([]byte{0x00}, m.data...)), addrs[i])
+		}
+	}()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i uint8) {
+			defer wg.Done()
+
+			// Timeouts may be changed while requests are outstanding
+			if i%8 == 0 {
+				l.SetTimeout(time.Second, 3)
+			}
+
+			data, err := l.SendRecv(Request{NetworkFunction: NetFnApp, Command: CmdGetDeviceID, Data: []byte{i}})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			if !bytes.Equal(data, []byte{0x00, i}) {
+				t.Errorf("request %d received response % x", i, data)
+			}
+		}(uint8(i))
+	}
+
+	wg.Wait()
+}
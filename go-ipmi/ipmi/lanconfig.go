@@ -0,0 +1,433 @@
+package ipmi
+
+// LAN configuration parameters per section 23
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// LAN configuration parameter selectors per table 23-4
+const (
+	LANParamSetInProgress      = 0
+	LANParamAuthTypeSupport    = 1
+	LANParamAuthTypeEnables    = 2
+	LANParamIPAddress          = 3
+	LANParamIPSource           = 4
+	LANParamMACAddress         = 5
+	LANParamSubnetMask         = 6
+	LANParamDefaultGateway     = 12
+	LANParamDefaultGatewayMAC  = 13
+	LANParamBackupGateway      = 14
+	LANParamBackupGatewayMAC   = 15
+	LANParamVLANID             = 20
+	LANParamVLANPriority       = 21
+	LANParamCipherSuiteSupport = 22
+	LANParamCipherSuites       = 23
+	LANParamCipherSuitePrivs   = 24
+	LANParamIPv6Support        = 50
+	LANParamIPv6Enables        = 51
+	LANParamIPv6Status         = 55
+	LANParamIPv6StaticAddress  = 56
+	LANParamIPv6DynamicAddress = 59
+)
+
+// Set In Progress parameter values
+const (
+	lanSetComplete   = 0x00
+	lanSetInProgress = 0x01
+)
+
+// Completion codes specific to Get and Set LAN Configuration Parameters
+var (
+	ErrLANParamNotSupported = commandCode(0x80, NetFnTransport, CmdSetLANConfigParams, CmdGetLANConfigParams)
+	ErrLANSetInProgress     = commandCode(0x81, NetFnTransport, CmdSetLANConfigParams)
+	ErrLANParamReadOnly     = commandCode(0x82, NetFnTransport, CmdSetLANConfigParams)
+	ErrLANParamWriteOnly    = commandCode(0x83, NetFnTransport, CmdGetLANConfigParams)
+)
+
+// IP address sources
+const (
+	IPSourceUnspecified = 0x00
+	IPSourceStatic      = 0x01
+	IPSourceDHCP        = 0x02
+	IPSourceBIOS        = 0x03 // Loaded by BIOS or system software
+	IPSourceOther       = 0x04
+)
+
+// IPv6/IPv4 support bits in LANConfig.IPv6Support
+const (
+	IPv6SupportIPv6Only = 0x01
+	IPv6SupportDual     = 0x02 // IPv6 and IPv4 simultaneously
+	IPv6SupportAlerting = 0x04 // IPv6 destination addresses for LAN alerting
+)
+
+// IPv6/IPv4 addressing enables
+const (
+	AddressingIPv4Only = 0x00
+	AddressingIPv6Only = 0x01
+	AddressingDual     = 0x02
+)
+
+// IPv6 address sources
+const (
+	IPv6SourceStatic = 0x00
+	IPv6SourceSLAAC  = 0x01
+	IPv6SourceDHCPv6 = 0x02
+)
+
+// IPv6 address status
+const (
+	IPv6StatusActive     = 0x00
+	IPv6StatusDisabled   = 0x01
+	IPv6StatusPending    = 0x02
+	IPv6StatusFailed     = 0x03
+	IPv6StatusDeprecated = 0x04
+	IPv6StatusInvalid    = 0x05
+)
+
+const (
+	maxCipherSuiteEntries = 16
+	vlanEnable            = 0x80
+	maxVLANID             = 0xfff
+	ipv6AddressEnable     = 0x80
+)
+
+// LANConfig holds the decoded LAN configuration parameters of a channel. Fields of parameters that
+// the BMC does not support are left empty.
+type LANConfig struct {
+	AuthTypeSupport   uint8    // Bit n set if authentication type n is supported
+	AuthTypeEnables   [5]uint8 // Enabled authentication types of each privilege level, from callback to OEM
+	IPSource          uint8
+	IPAddress         net.IP
+	SubnetMask        net.IP
+	MACAddress        net.HardwareAddr
+	DefaultGateway    net.IP
+	DefaultGatewayMAC net.HardwareAddr
+	BackupGateway     net.IP
+	BackupGatewayMAC  net.HardwareAddr
+	VLANEnabled       bool
+	VLANID            uint16
+	VLANPriority      uint8
+	CipherSuites      []uint8 // Supported RMCP+ cipher suite IDs
+	CipherSuitePrivs  []uint8 // Maximum privilege level of each of CipherSuites, or zero if unused
+	IPv6Support       uint8
+	IPv6Enables       uint8
+	IPv6Addresses     []IPv6Address // Static addresses, followed by dynamic addresses
+}
+
+// IPv6Address is a static or dynamic IPv6 address of a LAN channel
+type IPv6Address struct {
+	Set          uint8 // Set selector of the static or dynamic address parameter
+	Source       uint8 // IPv6SourceStatic, IPv6SourceSLAAC or IPv6SourceDHCPv6
+	Enabled      bool  // Always set for dynamic addresses
+	Address      net.IP
+	PrefixLength uint8
+	Status       uint8
+}
+
+// lanParamGetter returns the first n bytes of a LAN configuration parameter, or nil if the parameter
+// is not supported
+type lanParamGetter func(param, set uint8, n int) ([]byte, error)
+
+// LANConfigParam is an encoded LAN configuration parameter, as written by SetLANConfig
+type LANConfigParam struct {
+	Selector uint8
+	Data     []byte
+}
+
+// IPSourceParam selects the IP address source, e.g. IPSourceDHCP
+func IPSourceParam(source uint8) LANConfigParam {
+	return LANConfigParam{LANParamIPSource, []byte{source & 0x0f}}
+}
+
+// IPv4Param encodes an IPv4 address parameter, i.e. LANParamIPAddress, LANParamSubnetMask,
+// LANParamDefaultGateway or LANParamBackupGateway
+func IPv4Param(selector uint8, ip net.IP) (LANConfigParam, error) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return LANConfigParam{}, fmt.Errorf("not an IPv4 address: %s", ip)
+	}
+	return LANConfigParam{selector, append([]byte(nil), ip4...)}, nil
+}
+
+// VLANParam enables or disables 802.1q VLAN tagging with the specified VLAN ID
+func VLANParam(id uint16, enabled bool) (LANConfigParam, error) {
+	if id > maxVLANID || (enabled && id == 0) {
+		return LANConfigParam{}, fmt.Errorf("invalid VLAN ID: %d", id)
+	}
+	return LANConfigParam{LANParamVLANID, []byte{uint8(id), bit(enabled, vlanEnable) | uint8(id>>8)}}, nil
+}
+
+// VLANPriorityParam sets the 802.1q VLAN priority, from 0 to 7
+func VLANPriorityParam(priority uint8) (LANConfigParam, error) {
+	if priority > 7 {
+		return LANConfigParam{}, fmt.Errorf("invalid VLAN priority: %d", priority)
+	}
+	return LANConfigParam{LANParamVLANPriority, []byte{priority}}, nil
+}
+
+// AuthTypeEnablesParam sets the IPMI v1.5 authentication types enabled for each of the callback,
+// user, operator, admin and OEM privilege levels
+func AuthTypeEnablesParam(enables [5]uint8) LANConfigParam {
+	return LANConfigParam{LANParamAuthTypeEnables, append([]byte(nil), enables[:]...)}
+}
+
+// CipherSuitePrivsParam sets the maximum privilege level of each RMCP+ cipher suite, in the order
+// of LANConfig.CipherSuites. A privilege level of zero marks a cipher suite unused, preventing
+// sessions from being established with it.
+func CipherSuitePrivsParam(privs []uint8) (LANConfigParam, error) {
+	if len(privs) > maxCipherSuiteEntries {
+		return LANConfigParam{}, fmt.Errorf("too many cipher suite entries: %d", len(privs))
+	}
+
+	b := make([]byte, 1+maxCipherSuiteEntries/2)
+	for i, priv := range privs {
+		b[1+i/2] |= (priv & 0x0f) << (4 * (i % 2))
+	}
+
+	return LANConfigParam{LANParamCipherSuitePrivs, b}, nil
+}
+
+// IPv6EnablesParam selects IPv4, IPv6 or dual stack addressing, e.g. AddressingDual
+func IPv6EnablesParam(mode uint8) LANConfigParam {
+	return LANConfigParam{LANParamIPv6Enables, []byte{mode}}
+}
+
+// IPv6StaticAddressParam sets a static IPv6 address and prefix length, selected by set
+func IPv6StaticAddressParam(set uint8, ip net.IP, prefixLength uint8, enabled bool) (LANConfigParam, error) {
+	if ip.To16() == nil || ip.To4() != nil || prefixLength > 128 {
+		return LANConfigParam{}, fmt.Errorf("invalid IPv6 address: %s/%d", ip, prefixLength)
+	}
+
+	b := append([]byte{set, bit(enabled, ipv6AddressEnable) | IPv6SourceStatic}, ip.To16()...)
+
+	return LANConfigParam{LANParamIPv6StaticAddress, append(b, prefixLength)}, nil
+}
+
+// SetLANConfigParamsRequest per section 23.1
+type SetLANConfigParamsRequest struct {
+	Channel uint8
+	Param   LANConfigParam
+}
+
+func (r *SetLANConfigParamsRequest) MarshalBinary() ([]byte, error) {
+	return append([]byte{r.Channel & 0x0f, r.Param.Selector}, r.Param.Data...), nil
+}
+
+func (r *SetLANConfigParamsRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.Channel = d.uint8() & 0x0f
+	r.Param.Selector = d.uint8()
+	r.Param.Data = d.rest()
+
+	return d.err
+}
+
+// GetLANConfigParamsRequest per section 23.2
+type GetLANConfigParamsRequest struct {
+	Channel uint8
+	Param   uint8
+	Set     uint8
+	Block   uint8
+}
+
+func (r *GetLANConfigParamsRequest) MarshalBinary() ([]byte, error) {
+	return []byte{r.Channel & 0x0f, r.Param, r.Set, r.Block}, nil
+}
+
+func (r *GetLANConfigParamsRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.Channel = d.uint8() & 0x0f
+	r.Param = d.uint8()
+	r.Set = d.uint8()
+	r.Block = d.uint8()
+
+	return d.err
+}
+
+// GetLANConfigParamsResponse per section 23.2
+type GetLANConfigParamsResponse struct {
+	Revision uint8 // Parameter revision
+	Data     []byte
+}
+
+func (r *GetLANConfigParamsResponse) MarshalBinary() ([]byte, error) {
+	return append([]byte{r.Revision}, r.Data...), nil
+}
+
+func (r *GetLANConfigParamsResponse) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.Revision = d.uint8()
+	r.Data = d.rest()
+
+	return d.err
+}
+
+// setLANConfigParam sets a LAN configuration parameter
+func (c *conn) setLANConfigParam(channel, param uint8, value []byte) error {
+	req := &SetLANConfigParamsRequest{channel, LANConfigParam{param, value}}
+	return c.send(NetFnTransport, CmdSetLANConfigParams, req, nil)
+}
+
+// getLANConfigParam returns the data of a LAN configuration parameter
+func (c *conn) getLANConfigParam(channel, param, set, block uint8) ([]byte, error) {
+	req := &GetLANConfigParamsRequest{channel, param, set, block}
+	resp := &GetLANConfigParamsResponse{}
+
+	if err := c.send(NetFnTransport, CmdGetLANConfigParams, req, resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// setLANConfig writes LAN configuration parameters, holding the set in progress lock so that the
+// update does not interleave with that of another session. BMCs typically apply the new settings
+// when the lock is released. BMCs that do not support the lock are written to regardless.
+func (c *conn) setLANConfig(channel uint8, params []LANConfigParam) (err error) {
+	err = c.setLANConfigParam(channel, LANParamSetInProgress, []byte{lanSetInProgress})
+	if errors.Is(err, ErrLANSetInProgress) {
+		return fmt.Errorf("LAN configuration locked by another session: %w", err)
+	} else if err == nil {
+		defer func() {
+			if cerr := c.setLANConfigParam(channel, LANParamSetInProgress, []byte{lanSetComplete}); err == nil {
+				err = cerr
+			}
+		}()
+	} else if !errors.Is(err, ErrLANParamNotSupported) {
+		return err
+	}
+
+	for _, p := range params {
+		if err := c.setLANConfigParam(channel, p.Selector, p.Data); err != nil {
+			return fmt.Errorf("set LAN configuration parameter %d: %w", p.Selector, err)
+		}
+	}
+
+	return nil
+}
+
+// getLANConfig reads and decodes the LAN configuration parameters of a channel, skipping those
+// that the BMC does not support
+func (c *conn) getLANConfig(channel uint8) (*LANConfig, error) {
+	cfg := &LANConfig{}
+
+	get := func(param, set uint8, n int) ([]byte, error) {
+		b, err := c.getLANConfigParam(channel, param, set, 0)
+		if errors.Is(err, ErrLANParamNotSupported) {
+			return nil, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("get LAN configuration parameter %d: %w", param, err)
+		} else if len(b) < n {
+			return nil, fmt.Errorf("get LAN configuration parameter %d: %w", param, ErrShortData)
+		}
+		return b[:n], nil
+	}
+
+	params := []struct {
+		param  uint8
+		size   int
+		decode func(b []byte)
+	}{
+		{LANParamAuthTypeSupport, 1, func(b []byte) { cfg.AuthTypeSupport = b[0] & 0x3f }},
+		{LANParamAuthTypeEnables, 5, func(b []byte) { copy(cfg.AuthTypeEnables[:], b) }},
+		{LANParamIPSource, 1, func(b []byte) { cfg.IPSource = b[0] & 0x0f }},
+		{LANParamIPAddress, 4, func(b []byte) { cfg.IPAddress = net.IP(b).To16() }},
+		{LANParamSubnetMask, 4, func(b []byte) { cfg.SubnetMask = net.IP(b).To16() }},
+		{LANParamMACAddress, 6, func(b []byte) { cfg.MACAddress = net.HardwareAddr(b) }},
+		{LANParamDefaultGateway, 4, func(b []byte) { cfg.DefaultGateway = net.IP(b).To16() }},
+		{LANParamDefaultGatewayMAC, 6, func(b []byte) { cfg.DefaultGatewayMAC = net.HardwareAddr(b) }},
+		{LANParamBackupGateway, 4, func(b []byte) { cfg.BackupGateway = net.IP(b).To16() }},
+		{LANParamBackupGatewayMAC, 6, func(b []byte) { cfg.BackupGatewayMAC = net.HardwareAddr(b) }},
+		{LANParamVLANID, 2, func(b []byte) {
+			cfg.VLANEnabled = b[1]&vlanEnable != 0
+			cfg.VLANID = uint16(b[1]&0x0f)<<8 | uint16(b[0])
+		}},
+		{LANParamVLANPriority, 1, func(b []byte) { cfg.VLANPriority = b[0] & 0x07 }},
+		{LANParamIPv6Support, 1, func(b []byte) { cfg.IPv6Support = b[0] & 0x07 }},
+		{LANParamIPv6Enables, 1, func(b []byte) { cfg.IPv6Enables = b[0] }},
+	}
+
+	for _, p := range params {
+		b, err := get(p.param, 0, p.size)
+		if err != nil {
+			return nil, err
+		} else if b != nil {
+			p.decode(b)
+		}
+	}
+
+	if err := getCipherSuitePrivs(cfg, get); err != nil {
+		return nil, err
+	}
+
+	if cfg.IPv6Support != 0 {
+		if err := getIPv6Addresses(cfg, get); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// getCipherSuitePrivs reads the cipher suite IDs and their maximum privilege levels
+func getCipherSuitePrivs(c *LANConfig, get lanParamGetter) error {
+	b, err := get(LANParamCipherSuiteSupport, 0, 1)
+	if err != nil || b == nil {
+		return err
+	}
+
+	n := int(min(b[0]&0x1f, maxCipherSuiteEntries))
+
+	// Reserved byte, followed by cipher suite IDs
+	if b, err = get(LANParamCipherSuites, 0, 1+n); err != nil || b == nil {
+		return err
+	}
+	c.CipherSuites = append([]uint8(nil), b[1:]...)
+
+	// Reserved byte, followed by a privilege level nibble for each cipher suite
+	if b, err = get(LANParamCipherSuitePrivs, 0, 1+maxCipherSuiteEntries/2); err != nil || b == nil {
+		return err
+	}
+
+	c.CipherSuitePrivs = make([]uint8, n)
+	for i := range c.CipherSuitePrivs {
+		c.CipherSuitePrivs[i] = b[1+i/2] >> (4 * (i % 2)) & 0x0f
+	}
+
+	return nil
+}
+
+// getIPv6Addresses reads the static and dynamic IPv6 addresses
+func getIPv6Addresses(c *LANConfig, get lanParamGetter) error {
+	// Maximum number of static and dynamic addresses
+	status, err := get(LANParamIPv6Status, 0, 3)
+	if err != nil || status == nil {
+		return err
+	}
+
+	for i, param := range []uint8{LANParamIPv6StaticAddress, LANParamIPv6DynamicAddress} {
+		for set := uint8(0); set < status[i]; set++ {
+			// Set selector, source, address, prefix length and status
+			b, err := get(param, set, 20)
+			if err != nil {
+				return err
+			} else if b == nil {
+				break
+			}
+
+			c.IPv6Addresses = append(c.IPv6Addresses, IPv6Address{
+				Set:          b[0],
+				Source:       b[1] & 0x0f,
+				Enabled:      param == LANParamIPv6DynamicAddress || b[1]&ipv6AddressEnable != 0,
+				Address:      append(net.IP(nil), b[2:18]...),
+				PrefixLength: b[18],
+				Status:       b[19],
+			})
+		}
+	}
+
+	return nil
+}
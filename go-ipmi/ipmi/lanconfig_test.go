@@ -0,0 +1,158 @@
+package ipmi_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+func TestLANConfig(t *testing.T) {
+	_, addr := newTestSimulator(t, ipmisim.DefaultProfile())
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("admin", "admin", ipmi.PrivLevelAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := c.GetLANConfig(ipmi.CurrentChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.IPSource != ipmi.IPSourceStatic || !config.IPAddress.Equal(net.IPv4(192, 0, 2, 10)) ||
+		config.MACAddress.String() != "02:00:00:00:00:01" || config.VLANEnabled {
+		t.Errorf("unexpected LAN configuration %+v", config)
+	}
+
+	if len(config.CipherSuites) == 0 || len(config.CipherSuitePrivs) != len(config.CipherSuites) {
+		t.Errorf("cipher suites %v, privileges %v", config.CipherSuites, config.CipherSuitePrivs)
+	}
+
+	ip, err := ipmi.IPv4Param(ipmi.LANParamIPAddress, net.IPv4(198, 51, 100, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vlan, err := ipmi.VLANParam(100, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ipv6, err := ipmi.IPv6StaticAddressParam(0, net.ParseIP("2001:db8::7"), 64, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.SetLANConfig(ipmi.CurrentChannel, ip, vlan, ipv6); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err = c.GetLANConfig(ipmi.CurrentChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !config.IPAddress.Equal(net.IPv4(198, 51, 100, 7)) || !config.VLANEnabled || config.VLANID != 100 {
+		t.Errorf("unexpected LAN configuration %+v", config)
+	}
+
+	want := ipmi.IPv6Address{Source: ipmi.IPv6SourceStatic, Enabled: true, Address: net.ParseIP("2001:db8::7"),
+		PrefixLength: 64, Status: ipmi.IPv6StatusActive}
+	if a := config.IPv6Addresses[0]; !a.Address.Equal(want.Address) || a.Enabled != want.Enabled ||
+		a.PrefixLength != want.PrefixLength || a.Status != want.Status {
+		t.Errorf("IPv6 address %+v, expected %+v", a, want)
+	}
+
+	// MAC address is read-only
+	err = c.SetLANConfigParam(ipmi.CurrentChannel, ipmi.LANParamMACAddress, []byte{2, 0, 0, 0, 0, 2})
+	if !errors.Is(err, ipmi.ErrLANParamReadOnly) {
+		t.Errorf("expected read-only parameter, got %v", err)
+	}
+
+	// Another session may not update the configuration while the lock is held
+	if err := c.SetLANConfigParam(ipmi.CurrentChannel, ipmi.LANParamSetInProgress, []byte{ipmi.LANSetInProgress}); err != nil {
+		t.Fatal(err)
+	}
+
+	other := dialSimulator(t, addr)
+	if err := other.OpenSession("admin", "admin", ipmi.PrivLevelAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := other.SetLANConfig(ipmi.CurrentChannel, ipmi.IPSourceParam(ipmi.IPSourceDHCP)); !errors.Is(err, ipmi.ErrLANSetInProgress) {
+		t.Errorf("expected set in progress, got %v", err)
+	}
+}
+
+func TestLANConfigCipherSuitePrivs(t *testing.T) {
+	profile := ipmisim.DefaultProfile()
+	profile.CipherSuites = []uint8{3, 17}
+	_, addr := newTestSimulator(t, profile)
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("admin", "admin", ipmi.PrivLevelAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := c.GetLANConfig(ipmi.CurrentChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mark cipher suite 17 unused, and limit cipher suite 3 to operator privilege
+	privs := make([]uint8, len(config.CipherSuites))
+	for i, id := range config.CipherSuites {
+		if id == 3 {
+			privs[i] = ipmi.PrivLevelOperator
+		}
+	}
+
+	param, err := ipmi.CipherSuitePrivsParam(privs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.SetLANConfig(ipmi.CurrentChannel, param); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err = c.GetLANConfig(ipmi.CurrentChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, id := range config.CipherSuites {
+		if config.CipherSuitePrivs[i] != privs[i] {
+			t.Errorf("cipher suite %d: privilege level %d, expected %d", id, config.CipherSuitePrivs[i], privs[i])
+		}
+	}
+
+	// Sessions may no longer be established with cipher suite 17
+	err = dialSimulator(t, addr).OpenSession("admin", "admin", ipmi.PrivLevelAdmin)
+	if !errors.Is(err, ipmi.RAKPStatus(0x11)) {
+		t.Errorf("expected no cipher suite match, got %v", err)
+	}
+
+	if _, err := ipmi.CipherSuitePrivsParam(make([]uint8, ipmi.MaxCipherSuiteEntries+1)); err == nil {
+		t.Error("too many cipher suite entries accepted")
+	}
+}
+
+func TestLANConfigParamErrors(t *testing.T) {
+	if _, err := ipmi.IPv4Param(ipmi.LANParamIPAddress, net.ParseIP("2001:db8::1")); err == nil {
+		t.Error("IPv6 address accepted as IPv4 parameter")
+	}
+	if _, err := ipmi.VLANParam(0, true); err == nil {
+		t.Error("VLAN ID 0 accepted")
+	}
+	if _, err := ipmi.VLANParam(ipmi.MaxVLANID+1, true); err == nil {
+		t.Error("VLAN ID out of range accepted")
+	}
+	if _, err := ipmi.VLANPriorityParam(8); err == nil {
+		t.Error("VLAN priority out of range accepted")
+	}
+	if _, err := ipmi.IPv6StaticAddressParam(0, net.IPv4(192, 0, 2, 1), 24, true); err == nil {
+		t.Error("IPv4 address accepted as IPv6 static address")
+	}
+}
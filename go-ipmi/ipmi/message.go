@@ -0,0 +1,177 @@
+package ipmi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+const (
+	// Network function codes per section 5.1
+	NetFnChassis     = 0x00
+	NetFnSensorEvent = 0x04
+	NetFnApp         = 0x06
+	NetFnStorage     = 0x0a
+	NetFnTransport   = 0x0c
+	NetFnGroupExtn   = 0x2c
+)
+
+// SessionHeader is the session header of an IPMI v1.5 packet
+type SessionHeader struct {
+	AuthType  uint8
+	Sequence  uint32
+	SessionID uint32
+}
+
+// MessageHeader is the header of an IPMI message, as sent on the LAN or IPMB
+type MessageHeader struct {
+	RsAddr     uint8 // Responder slave address
+	NetFnRsLUN uint8 // Network function, responder LUN
+	Checksum   uint8
+	RqAddr     uint8 // Requester address
+	RqSeq      uint8 // Requester sequence number
+	Command    uint8
+}
+
+type message struct {
+	*rmcpHeader
+	*SessionHeader
+	authCode [16]byte
+	*MessageHeader
+	data    []byte
+	payload []byte // IPMI message as sent on the wire, from responder address to final checksum
+}
+
+// newMessageFromBytes decodes an IPMI v1.5 session packet
+func newMessageFromBytes(b []byte) (*message, error) {
+	if len(b) < rmcpHeaderSize+ipmiSessionSize+1+ipmiHeaderSize+1 {
+		return nil, ErrShortData
+	}
+
+	m := &message{
+		rmcpHeader:    &rmcpHeader{},
+		SessionHeader: &SessionHeader{},
+	}
+
+	r := bytes.NewReader(b)
+
+	if err := binary.Read(r, binary.LittleEndian, m.rmcpHeader); err != nil {
+		return nil, err
+	}
+
+	if err := binary.Read(r, binary.LittleEndian, m.SessionHeader); err != nil {
+		return nil, err
+	}
+
+	// Auth code field is only present for authenticated sessions
+	if m.SessionHeader.AuthType != AuthTypeNone {
+		if _, err := io.ReadFull(r, m.authCode[:]); err != nil {
+			return nil, err
+		}
+	}
+
+	msgLen, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	if int(msgLen) > r.Len() {
+		return nil, ErrShortData
+	}
+
+	m.payload = make([]byte, msgLen)
+	if _, err := io.ReadFull(r, m.payload); err != nil {
+		return nil, err
+	}
+
+	if m.MessageHeader, m.data, err = DecodeMessage(m.payload); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// DecodeSessionPacket decodes an IPMI v1.5 session packet, returning the session header, the auth
+// code, which is zero outside of authenticated sessions, and the IPMI message
+func DecodeSessionPacket(b []byte) (*SessionHeader, [16]byte, []byte, error) {
+	m, err := newMessageFromBytes(b)
+	if err != nil {
+		return nil, [16]byte{}, nil, err
+	}
+
+	return m.SessionHeader, m.authCode, m.payload, nil
+}
+
+// EncodeSessionPacket wraps an IPMI message in an IPMI v1.5 session packet, calculating the auth
+// code with the specified password if the session is authenticated.
+func EncodeSessionPacket(session SessionHeader, password []byte, msg []byte) []byte {
+	buf := new(bytes.Buffer)
+
+	binaryWrite(buf, rmcpHeader{
+		Version:            rmcpVersion1,
+		RMCPSequenceNumber: 0xff,
+		Class:              rmcpClassIPMI,
+	})
+
+	binaryWrite(buf, session)
+
+	// Auth code field is only present for authenticated sessions
+	if session.AuthType != AuthTypeNone {
+		authCode := AuthCode(session.AuthType, password, session.SessionID, session.Sequence, msg)
+		buf.Write(authCode[:])
+	}
+
+	buf.WriteByte(uint8(len(msg)))
+	buf.Write(msg)
+
+	return buf.Bytes()
+}
+
+// EncodeMessage assembles an IPMI message from the header and data, calculating both checksums
+func EncodeMessage(hdr MessageHeader, data []byte) []byte {
+	hdr.Checksum = checksum(hdr.RsAddr, hdr.NetFnRsLUN)
+
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{hdr.RsAddr, hdr.NetFnRsLUN, hdr.Checksum, hdr.RqAddr, hdr.RqSeq, hdr.Command})
+	buf.Write(data)
+	buf.WriteByte(checksum(hdr.RqAddr, hdr.RqSeq, hdr.Command) + checksum(data...))
+
+	return buf.Bytes()
+}
+
+// DecodeMessage splits an IPMI message into header and data, verifying both checksums
+func DecodeMessage(b []byte) (*MessageHeader, []byte, error) {
+	if len(b) < ipmiHeaderSize+1 {
+		return nil, nil, ErrShortData
+	}
+
+	hdr := &MessageHeader{}
+	if err := binary.Read(bytes.NewReader(b), binary.LittleEndian, hdr); err != nil {
+		return nil, nil, err
+	}
+
+	if checksum(hdr.RsAddr, hdr.NetFnRsLUN) != hdr.Checksum {
+		return nil, nil, ErrInvalidData
+	}
+
+	data := b[ipmiHeaderSize : len(b)-1]
+
+	// Checksum byte should be the last byte, immediately after the data
+	if checksum(hdr.RqAddr, hdr.RqSeq, hdr.Command)+checksum(data...) != b[len(b)-1] {
+		return nil, nil, ErrInvalidData
+	}
+
+	return hdr, data, nil
+}
+
+func binaryWrite(w io.Writer, data interface{}) error {
+	return binary.Write(w, binary.LittleEndian, data)
+}
+
+func checksum(b ...uint8) uint8 {
+	var c uint8
+	for _, x := range b {
+		c += x
+	}
+	return -c
+}
@@ -0,0 +1,121 @@
+package ipmi
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	rmcpVersion1  = 0x06
+	rmcpClassASF  = 0x06
+	rmcpClassIPMI = 0x07
+
+	// RMCP sequence number of messages that are not to be acknowledged
+	rmcpNoAck = 0xff
+)
+
+// ASF message types, per the DMTF Alert Standard Format specification
+const (
+	asfIANA             = 4542 // IANA enterprise number of ASF messages
+	asfTypePresencePong = 0x40
+	asfTypePresencePing = 0x80
+
+	// Message tag of messages that are not to be answered
+	asfTagNoResponse = 0xff
+
+	asfMessageHeaderSize = 8
+)
+
+// Presence Pong supported entities and interactions bits
+const (
+	asfEntityIPMI                    = 0x80
+	asfInteractionSecurityExtensions = 0x20
+)
+
+var (
+	rmcpHeaderSize  = binary.Size(rmcpHeader{})
+	ipmiSessionSize = binary.Size(SessionHeader{})
+	ipmiHeaderSize  = binary.Size(MessageHeader{})
+)
+
+type rmcpHeader struct {
+	Version            uint8
+	Reserved           uint8
+	RMCPSequenceNumber uint8
+	Class              uint8
+}
+
+// TODO: Deprecate this function
+func decodeRMCPHeader(buf []byte) (*rmcpHeader, error) {
+	if len(buf) < rmcpHeaderSize {
+		return nil, ErrShortData
+	}
+
+	return &rmcpHeader{buf[0], buf[1], buf[2], buf[3]}, nil
+}
+
+// Pong is the Presence Pong response of a management controller to an RMCP Presence Ping. Pong
+// only reports whether IPMI is supported, not its version, which is found from
+// GetChannelAuthCapabilities.
+type Pong struct {
+	EnterpriseNumber   uint32 // IANA enterprise number, asfIANA unless OEMData is defined
+	OEMData            uint32
+	IPMI               bool  // IPMI supported
+	ASFVersion         uint8 // 1 for ASF 1.0, or zero if ASF is unsupported
+	SecurityExtensions bool  // RMCP security extensions supported
+}
+
+func (p *Pong) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint32(b, p.EnterpriseNumber)
+	binary.BigEndian.PutUint32(b[4:], p.OEMData)
+	b[8] = bit(p.IPMI, asfEntityIPMI) | p.ASFVersion&0x0f
+	b[9] = bit(p.SecurityExtensions, asfInteractionSecurityExtensions)
+
+	return b, nil
+}
+
+func (p *Pong) UnmarshalBinary(b []byte) error {
+	if len(b) < 10 {
+		return ErrShortData
+	}
+
+	*p = Pong{
+		EnterpriseNumber:   binary.BigEndian.Uint32(b),
+		OEMData:            binary.BigEndian.Uint32(b[4:]),
+		IPMI:               b[8]&asfEntityIPMI != 0,
+		ASFVersion:         b[8] & 0x0f,
+		SecurityExtensions: b[9]&asfInteractionSecurityExtensions != 0,
+	}
+
+	return nil
+}
+
+// EncodeASFMessage builds an RMCP packet of the ASF class. Integers in ASF messages are big-endian,
+// unlike IPMI.
+func EncodeASFMessage(msgType, tag uint8, data []byte) []byte {
+	b := []byte{rmcpVersion1, 0, rmcpNoAck, rmcpClassASF}
+	b = binary.BigEndian.AppendUint32(b, asfIANA)
+	b = append(b, msgType, tag, 0, uint8(len(data)))
+
+	return append(b, data...)
+}
+
+// DecodeASFMessage decodes an RMCP packet of the ASF class, returning the message type, tag and data
+func DecodeASFMessage(b []byte) (uint8, uint8, []byte, error) {
+	if len(b) < rmcpHeaderSize+asfMessageHeaderSize {
+		return 0, 0, nil, ErrShortData
+	}
+
+	b = b[rmcpHeaderSize:]
+	if iana := binary.BigEndian.Uint32(b); iana != asfIANA {
+		return 0, 0, nil, fmt.Errorf("unsupported ASF enterprise number: %d", iana)
+	}
+
+	msgType, tag, n := b[4], b[5], int(b[7])
+	if len(b) < asfMessageHeaderSize+n {
+		return 0, 0, nil, ErrShortData
+	}
+
+	return msgType, tag, b[asfMessageHeaderSize : asfMessageHeaderSize+n], nil
+}
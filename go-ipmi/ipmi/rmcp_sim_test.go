@@ -0,0 +1,34 @@
+package ipmi_test
+
+import (
+	"testing"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+func TestPing(t *testing.T) {
+	_, addr := newTestSimulator(t, ipmisim.DefaultProfile())
+	c := dialSimulator(t, addr)
+
+	for i := 0; i < 3; i++ {
+		pong, err := c.Ping()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !pong.IPMI || pong.EnterpriseNumber != ipmi.ASFIANA {
+			t.Errorf("unexpected pong %+v", pong)
+		}
+	}
+
+	// IPMI requests are unaffected by pings on the same connection
+	caps, err := c.GetChannelAuthCapabilities(ipmi.CurrentChannel, ipmi.PrivLevelAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !caps.ExtendedData || caps.ExtCapabilities&ipmi.ExtCapIPMIv20 == 0 {
+		t.Errorf("unexpected capabilities %+v", caps)
+	}
+}
@@ -0,0 +1,38 @@
+package ipmi
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodePresencePong(t *testing.T) {
+	// Presence Pong of a typical BMC, supporting IPMI and ASF 1.0
+	b := []byte{
+		0x06, 0x00, 0xff, 0x06, 0x00, 0x00, 0x11, 0xbe, 0x40, 0x07, 0x00, 0x10,
+		0x00, 0x00, 0x11, 0xbe, 0x00, 0x00, 0x00, 0x00, 0x81, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	}
+
+	msgType, tag, data, err := DecodeASFMessage(b)
+	if err != nil || msgType != asfTypePresencePong || tag != 7 {
+		t.Fatalf("message type %#x, tag %d, error %v", msgType, tag, err)
+	}
+
+	pong := &Pong{}
+	if err := pong.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Pong{EnterpriseNumber: asfIANA, IPMI: true, ASFVersion: 1}
+	if *pong != want {
+		t.Errorf("got %+v, want %+v", *pong, want)
+	}
+
+	if enc, _ := pong.MarshalBinary(); !bytes.Equal(enc, data) {
+		t.Errorf("encoded % x, want % x", enc, data)
+	}
+
+	if _, _, _, err := DecodeASFMessage(b[:len(b)-1]); err != ErrShortData {
+		t.Errorf("expected short packet, got %v", err)
+	}
+}
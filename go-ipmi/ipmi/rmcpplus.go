@@ -0,0 +1,818 @@
+package ipmi
+
+// RMCP+ session support for IPMI v2.0 per section 13
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"math/rand"
+)
+
+const authTypeRMCPPlus = 0x06
+
+// Payload types per section 13.27.3
+const (
+	payloadTypeIPMI                = 0x00
+	payloadTypeSOL                 = 0x01
+	payloadTypeOpenSessionRequest  = 0x10
+	payloadTypeOpenSessionResponse = 0x11
+	payloadTypeRAKP1               = 0x12
+	payloadTypeRAKP2               = 0x13
+	payloadTypeRAKP3               = 0x14
+	payloadTypeRAKP4               = 0x15
+
+	payloadTypeMask      = 0x3f
+	payloadAuthenticated = 0x40
+	payloadEncrypted     = 0x80
+)
+
+// Authentication algorithms per section 13.28
+const (
+	authRAKPNone       = 0x00
+	authRAKPHMACSHA1   = 0x01
+	authRAKPHMACMD5    = 0x02
+	authRAKPHMACSHA256 = 0x03
+)
+
+// Integrity algorithms per section 13.28.4
+const (
+	integrityNone          = 0x00
+	integrityHMACSHA196    = 0x01
+	integrityHMACMD5128    = 0x02
+	integrityMD5128        = 0x03
+	integrityHMACSHA256128 = 0x04
+)
+
+// Confidentiality algorithms per section 13.28.5
+const (
+	confNone      = 0x00
+	confAESCBC128 = 0x01
+	confXRC4128   = 0x02
+	confXRC440    = 0x03
+)
+
+// Requested maximum privilege level flag in RAKP message 1, to look up user by name only
+const rakpNameOnlyLookup = 0x10
+
+var (
+	rmcpPlusSessionSize = binary.Size(rmcpPlusSession{})
+
+	ErrIntegrity         = errors.New("invalid integrity check value")
+	ErrSessionMismatch   = errors.New("unexpected session ID in response")
+	ErrNotEncrypted      = errors.New("unencrypted payload in confidential session")
+	ErrAlgorithmMismatch = errors.New("BMC selected algorithms other than those proposed")
+	ErrSequence          = errors.New("session sequence number outside window")
+)
+
+// Number of session sequence numbers behind the highest received that are accepted in
+// authenticated packets, per section 6.12.13
+const sequenceWindow = 32
+
+// RAKPStatus is the status code returned in RMCP+ session setup payloads per table 13-15
+type RAKPStatus uint8
+
+var rakpStatusCodes = map[RAKPStatus]string{
+	0x01: "Insufficient resources to create a session",
+	0x02: "Invalid session ID",
+	0x03: "Invalid payload type",
+	0x04: "Invalid authentication algorithm",
+	0x05: "Invalid integrity algorithm",
+	0x06: "No matching authentication payload",
+	0x07: "No matching integrity payload",
+	0x08: "Inactive session ID",
+	0x09: "Invalid role",
+	0x0a: "Unauthorized role or privilege level requested",
+	0x0b: "Insufficient resources to create a session at the requested role",
+	0x0c: "Invalid name length",
+	0x0d: "Unauthorized name",
+	0x0e: "Unauthorized GUID",
+	0x0f: "Invalid integrity check value",
+	0x10: "Invalid confidentiality algorithm",
+	0x11: "No cipher suite match with proposed security algorithms",
+	0x12: "Illegal or unrecognized parameter",
+}
+
+func (s RAKPStatus) Error() string {
+	if str, ok := rakpStatusCodes[s]; ok {
+		return str
+	}
+	return fmt.Sprintf("RAKP status code: %X", uint8(s))
+}
+
+// CipherSuite describes the algorithms identified by a cipher suite ID per table 22-20
+type CipherSuite struct {
+	ID              uint8
+	Auth            uint8
+	Integrity       uint8
+	Confidentiality uint8
+}
+
+// Supported cipher suites in order of preference
+var cipherSuites = []CipherSuite{
+	{17, authRAKPHMACSHA256, integrityHMACSHA256128, confAESCBC128},
+	{3, authRAKPHMACSHA1, integrityHMACSHA196, confAESCBC128},
+	{16, authRAKPHMACSHA256, integrityHMACSHA256128, confNone},
+	{2, authRAKPHMACSHA1, integrityHMACSHA196, confNone},
+	{15, authRAKPHMACSHA256, integrityNone, confNone},
+	{1, authRAKPHMACSHA1, integrityNone, confNone},
+}
+
+// LookupCipherSuite returns the algorithms of a supported cipher suite ID
+func LookupCipherSuite(id uint8) (CipherSuite, bool) {
+	for _, c := range cipherSuites {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return CipherSuite{}, false
+}
+
+// authHash returns the hash function used by the authentication algorithm
+func (c CipherSuite) authHash() func() hash.Hash {
+	if c.Auth == authRAKPHMACSHA256 {
+		return sha256.New
+	}
+	return sha1.New
+}
+
+// icvLen returns the length of the integrity check value in RAKP message 4
+func (c CipherSuite) icvLen() int {
+	if c.Auth == authRAKPHMACSHA256 {
+		return 16 // HMAC-SHA256-128
+	}
+	return 12 // HMAC-SHA1-96
+}
+
+// integrityHash returns the hash function used by the integrity algorithm
+func (c CipherSuite) integrityHash() func() hash.Hash {
+	if c.Integrity == integrityHMACSHA256128 {
+		return sha256.New
+	}
+	return sha1.New
+}
+
+// authCodeLen returns the length of the auth code trailer on authenticated packets
+func (c CipherSuite) authCodeLen() int {
+	switch c.Integrity {
+	case integrityHMACSHA196:
+		return 12
+	case integrityHMACSHA256128:
+		return 16
+	}
+	return 0
+}
+
+// SessionKeys holds the negotiated cipher suite and derived keys of an active RMCP+ session
+type SessionKeys struct {
+	suite CipherSuite // Negotiated cipher suite
+	k1    []byte      // Integrity key
+	k2    []byte      // Confidentiality key
+}
+
+// RAKPExchange holds the values exchanged during RAKP authentication per section 13.31, from which
+// both the remote console and the BMC calculate the auth codes and session integrity key.
+type RAKPExchange struct {
+	Suite            CipherSuite
+	KUID             []byte // User password
+	ConsoleSessionID uint32
+	ManagedSessionID uint32
+	RM               [16]byte // Remote console random number
+	RC               [16]byte // Managed system random number
+	GUID             [16]byte // Managed system GUID
+	Role             uint8    // Requested maximum privilege level and lookup flag
+	Username         []byte
+}
+
+// RAKP2AuthCode calculates the key exchange auth code sent by the BMC in RAKP message 2
+func (x *RAKPExchange) RAKP2AuthCode() []byte {
+	mac := hmac.New(x.Suite.authHash(), x.KUID)
+	mac.Write(le32(x.ConsoleSessionID))
+	mac.Write(le32(x.ManagedSessionID))
+	mac.Write(x.RM[:])
+	mac.Write(x.RC[:])
+	mac.Write(x.GUID[:])
+	mac.Write([]byte{x.Role, uint8(len(x.Username))})
+	mac.Write(x.Username)
+	return mac.Sum(nil)
+}
+
+// RAKP3AuthCode calculates the key exchange auth code sent by the remote console in RAKP message 3
+func (x *RAKPExchange) RAKP3AuthCode() []byte {
+	mac := hmac.New(x.Suite.authHash(), x.KUID)
+	mac.Write(x.RC[:])
+	mac.Write(le32(x.ConsoleSessionID))
+	mac.Write([]byte{x.Role, uint8(len(x.Username))})
+	mac.Write(x.Username)
+	return mac.Sum(nil)
+}
+
+// SIK calculates the session integrity key, using Kuid in place of the BMC key (Kg)
+func (x *RAKPExchange) SIK() []byte {
+	mac := hmac.New(x.Suite.authHash(), x.KUID)
+	mac.Write(x.RM[:])
+	mac.Write(x.RC[:])
+	mac.Write([]byte{x.Role, uint8(len(x.Username))})
+	mac.Write(x.Username)
+	return mac.Sum(nil)
+}
+
+// RAKP4ICV calculates the integrity check value sent by the BMC in RAKP message 4
+func (x *RAKPExchange) RAKP4ICV(sik []byte) []byte {
+	mac := hmac.New(x.Suite.authHash(), sik)
+	mac.Write(x.RM[:])
+	mac.Write(le32(x.ManagedSessionID))
+	mac.Write(x.GUID[:])
+	return mac.Sum(nil)[:x.Suite.icvLen()]
+}
+
+// rmcpPlusSession is the IPMI v2.0 RMCP+ session header per table 13-8
+type rmcpPlusSession struct {
+	AuthType      uint8 // Always authTypeRMCPPlus
+	PayloadType   uint8
+	SessionID     uint32
+	Sequence      uint32
+	PayloadLength uint16
+}
+
+// algorithmPayload proposes or confirms a security algorithm per section 13.17
+type algorithmPayload struct {
+	Type      uint8
+	Reserved1 uint16
+	Length    uint8
+	Algorithm uint8
+	Reserved2 [3]uint8
+}
+
+// openSessionRequest per section 13.17
+type openSessionRequest struct {
+	Tag              uint8
+	PrivLevel        uint8
+	Reserved         uint16
+	ConsoleSessionID uint32
+	Auth             algorithmPayload
+	Integrity        algorithmPayload
+	Confidentiality  algorithmPayload
+}
+
+// openSessionResponse per section 13.18
+type openSessionResponse struct {
+	Tag              uint8
+	Status           uint8
+	PrivLevel        uint8
+	Reserved         uint8
+	ConsoleSessionID uint32
+	ManagedSessionID uint32
+	Auth             algorithmPayload
+	Integrity        algorithmPayload
+	Confidentiality  algorithmPayload
+}
+
+// ChannelCipherSuitesRequest per section 22.15
+type ChannelCipherSuitesRequest struct {
+	ChannelNumber uint8
+	PayloadType   uint8
+	ListBySuite   bool  // List algorithms by cipher suite, rather than all supported algorithms
+	ListIndex     uint8 // Index of 16 byte block of records
+}
+
+func (r *ChannelCipherSuitesRequest) MarshalBinary() ([]byte, error) {
+	return []byte{r.ChannelNumber & 0x0f, r.PayloadType & 0x3f, bit(r.ListBySuite, 0x80) | r.ListIndex&0x3f}, nil
+}
+
+func (r *ChannelCipherSuitesRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	channel, payloadType, index := d.uint8(), d.uint8(), d.uint8()
+
+	*r = ChannelCipherSuitesRequest{channel & 0x0f, payloadType & 0x3f, index&0x80 != 0, index & 0x3f}
+
+	return d.err
+}
+
+// ChannelCipherSuitesResponse per section 22.15
+type ChannelCipherSuitesResponse struct {
+	ChannelNumber uint8
+	Records       []byte // Up to 16 bytes of cipher suite records
+}
+
+func (r *ChannelCipherSuitesResponse) MarshalBinary() ([]byte, error) {
+	return append([]byte{r.ChannelNumber}, r.Records...), nil
+}
+
+func (r *ChannelCipherSuitesResponse) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.ChannelNumber = d.uint8()
+	r.Records = d.rest()
+
+	return d.err
+}
+
+// getChannelCipherSuites returns the IDs of the cipher suites supported by the BMC
+func (l *lanConnection) getChannelCipherSuites(channel uint8) ([]uint8, error) {
+	var records []byte
+
+	// Cipher suite records are returned 16 bytes at a time
+	for index := uint8(0); index < 0x40; index++ {
+		req := &ChannelCipherSuitesRequest{channel, payloadTypeIPMI, true, index}
+		resp := ChannelCipherSuitesResponse{}
+
+		if err := l.send(NetFnApp, CmdGetChannelCipherSuites, req, &resp); err != nil {
+			return nil, err
+		}
+
+		records = append(records, resp.Records...)
+
+		if len(resp.Records) < 16 {
+			break
+		}
+	}
+
+	return parseCipherSuiteRecords(records), nil
+}
+
+// parseCipherSuiteRecords extracts the cipher suite IDs from cipher suite records per table 22-19
+func parseCipherSuiteRecords(b []byte) []uint8 {
+	var ids []uint8
+
+	for i := 0; i < len(b); {
+		switch b[i] {
+		case 0xc0: // Standard cipher suite
+			if i+1 < len(b) {
+				ids = append(ids, b[i+1])
+			}
+			i += 2
+		case 0xc1: // OEM cipher suite, followed by 3-byte IANA enterprise number
+			i += 5
+		default: // Algorithm number
+			i++
+		}
+	}
+
+	return ids
+}
+
+// selectCipherSuite chooses the most preferred cipher suite supported by the BMC
+func (l *lanConnection) selectCipherSuite() (CipherSuite, error) {
+	ids, err := l.getChannelCipherSuites(CurrentChannel)
+	if err != nil {
+		return CipherSuite{}, err
+	}
+
+	for _, c := range cipherSuites {
+		for _, id := range ids {
+			if c.ID == id {
+				return c, nil
+			}
+		}
+	}
+
+	return CipherSuite{}, fmt.Errorf("no supported cipher suite offered by BMC: %v", ids)
+}
+
+// openRMCPPlusSession establishes an IPMI v2.0 RMCP+ session with the BMC per section 13.15,
+// authenticating with the RAKP protocol and deriving integrity and confidentiality keys.
+func (l *lanConnection) openRMCPPlusSession() error {
+	suite, err := l.selectCipherSuite()
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.suite = suite
+	l.consoleSessionID = rand.Uint32() | 1
+	l.mu.Unlock()
+
+	// Open Session Request
+	osReq := openSessionRequest{
+		PrivLevel:        l.priv,
+		ConsoleSessionID: l.consoleSessionID,
+		Auth:             algorithmPayload{Type: 0x00, Length: 8, Algorithm: suite.Auth},
+		Integrity:        algorithmPayload{Type: 0x01, Length: 8, Algorithm: suite.Integrity},
+		Confidentiality:  algorithmPayload{Type: 0x02, Length: 8, Algorithm: suite.Confidentiality},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := binaryWrite(buf, osReq); err != nil {
+		return err
+	}
+
+	payload, err := l.sendPayload(payloadTypeOpenSessionRequest, payloadTypeOpenSessionResponse, buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if len(payload) < 2 {
+		return ErrShortData
+	}
+
+	if payload[1] != 0 {
+		return RAKPStatus(payload[1])
+	}
+
+	osResp := openSessionResponse{}
+	if err := binary.Read(bytes.NewReader(payload), binary.LittleEndian, &osResp); err != nil {
+		return err
+	}
+
+	if osResp.ConsoleSessionID != l.consoleSessionID {
+		return ErrSessionMismatch
+	}
+
+	if err := checkAlgorithms(suite, &osResp); err != nil {
+		return err
+	}
+
+	x := &RAKPExchange{
+		Suite:            suite,
+		KUID:             bytes.TrimRight(l.password[:], "\x00"),
+		ConsoleSessionID: l.consoleSessionID,
+		ManagedSessionID: osResp.ManagedSessionID,
+		Role:             l.priv | rakpNameOnlyLookup,
+		Username:         bytes.TrimRight(l.username[:], "\x00"),
+	}
+
+	// RAKP message 1
+	if _, err := crand.Read(x.RM[:]); err != nil {
+		return err
+	}
+
+	buf.Reset()
+	buf.Write([]byte{0x01, 0, 0, 0}) // Message tag, reserved
+	buf.Write(le32(x.ManagedSessionID))
+	buf.Write(x.RM[:])
+	buf.Write([]byte{x.Role, 0, 0, uint8(len(x.Username))})
+	buf.Write(x.Username)
+
+	// RAKP message 2
+	payload, err = l.sendPayload(payloadTypeRAKP1, payloadTypeRAKP2, buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if len(payload) < 2 {
+		return ErrShortData
+	}
+
+	if payload[1] != 0 {
+		return RAKPStatus(payload[1])
+	}
+
+	authLen := suite.authHash()().Size()
+	if len(payload) < 40+authLen {
+		return ErrShortData
+	}
+
+	if binary.LittleEndian.Uint32(payload[4:8]) != l.consoleSessionID {
+		return ErrSessionMismatch
+	}
+
+	copy(x.RC[:], payload[8:24])
+	copy(x.GUID[:], payload[24:40])
+
+	if !hmac.Equal(x.RAKP2AuthCode(), payload[40:40+authLen]) {
+		return ErrIntegrity
+	}
+
+	sik := x.SIK()
+
+	// RAKP message 3
+	buf.Reset()
+	buf.Write([]byte{0x02, 0, 0, 0}) // Message tag, status, reserved
+	buf.Write(le32(x.ManagedSessionID))
+	buf.Write(x.RAKP3AuthCode())
+
+	// RAKP message 4
+	payload, err = l.sendPayload(payloadTypeRAKP3, payloadTypeRAKP4, buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if len(payload) < 2 {
+		return ErrShortData
+	}
+
+	if payload[1] != 0 {
+		return RAKPStatus(payload[1])
+	}
+
+	if len(payload) < 8+suite.icvLen() {
+		return ErrShortData
+	}
+
+	if !hmac.Equal(x.RAKP4ICV(sik), payload[8:8+suite.icvLen()]) {
+		return ErrIntegrity
+	}
+
+	// Session is now active
+	l.mu.Lock()
+	l.bmcGUID = x.GUID
+	l.sik = sik
+	l.SessionKeys = *NewSessionKeys(suite, sik)
+	l.sessionID = x.ManagedSessionID
+	l.sequence = 1
+	l.inSequence, l.inWindow = 0, 0
+	l.mu.Unlock()
+
+	return nil
+}
+
+// checkAlgorithms verifies that the BMC confirmed the algorithms of the proposed cipher suite in
+// the Open Session response. A BMC must not substitute others, e.g. weaker ones.
+func checkAlgorithms(suite CipherSuite, resp *openSessionResponse) error {
+	// Algorithm numbers occupy the low six bits
+	if resp.Auth.Algorithm&0x3f != suite.Auth || resp.Integrity.Algorithm&0x3f != suite.Integrity ||
+		resp.Confidentiality.Algorithm&0x3f != suite.Confidentiality {
+		return fmt.Errorf("%w: auth 0x%02x, integrity 0x%02x, confidentiality 0x%02x", ErrAlgorithmMismatch,
+			resp.Auth.Algorithm, resp.Integrity.Algorithm, resp.Confidentiality.Algorithm)
+	}
+
+	return nil
+}
+
+// NewSessionKeys derives the integrity and confidentiality keys of an RMCP+ session from the SIK
+func NewSessionKeys(suite CipherSuite, sik []byte) *SessionKeys {
+	return &SessionKeys{suite: suite, k1: suite.deriveKey(sik, 0x01), k2: suite.deriveKey(sik, 0x02)}
+}
+
+// deriveKey generates additional key material from the SIK per section 13.32
+func (c CipherSuite) deriveKey(sik []byte, n uint8) []byte {
+	mac := hmac.New(c.authHash(), sik)
+	mac.Write(bytes.Repeat([]byte{n}, mac.Size()))
+	return mac.Sum(nil)
+}
+
+// sendPayload sends a session setup payload and returns the payload of the response
+func (l *lanConnection) sendPayload(reqType, respType uint8, payload []byte) ([]byte, error) {
+	return l.roundTrip(requestKey{payloadType: respType}, func() ([]byte, error) {
+		return rmcpPlusFormat{l}.encode(reqType, payload)
+	})
+}
+
+// rmcpPlusFormat frames IPMI v2.0 RMCP+ session packets, which are encrypted and authenticated
+// once the session is active if the cipher suite requires it
+type rmcpPlusFormat struct {
+	l *lanConnection
+}
+
+func (f rmcpPlusFormat) encode(payloadType uint8, payload []byte) ([]byte, error) {
+	if f.l.sessionID == 0 {
+		return EncodeRMCPPlusPacket(nil, 0, 0, payloadType, payload)
+	}
+
+	return EncodeRMCPPlusPacket(&f.l.SessionKeys, f.l.sessionID, f.l.nextSequence(), payloadType, payload)
+}
+
+func (f rmcpPlusFormat) decode(b []byte) (uint8, []byte, error) {
+	if f.l.sessionID == 0 {
+		return DecodeRMCPPlusPacket(nil, 0, b)
+	}
+
+	payloadType, payload, err := DecodeRMCPPlusPacket(&f.l.SessionKeys, f.l.consoleSessionID, b)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Replayed authenticated packets are rejected. The sequence number follows the auth type,
+	// payload type and session ID of the session header.
+	if f.l.suite.Integrity != integrityNone &&
+		!f.l.acceptSequence(binary.LittleEndian.Uint32(b[rmcpHeaderSize+6:])) {
+		return 0, nil, ErrSequence
+	}
+
+	return payloadType, payload, nil
+}
+
+// acceptSequence records the session sequence number of an authenticated packet from the BMC,
+// reporting whether it is within the sliding window of section 6.12.13. Numbers ahead of the
+// highest received advance the window, and those up to sequenceWindow behind are accepted once.
+func (l *lanConnection) acceptSequence(seq uint32) bool {
+	if seq == 0 {
+		return false
+	} else if l.inSequence == 0 {
+		l.inSequence, l.inWindow = seq, 1
+		return true
+	}
+
+	// Serial number arithmetic, as sequence numbers wrap
+	switch diff := int32(seq - l.inSequence); {
+	case diff > 0:
+		l.inSequence = seq
+		l.inWindow = l.inWindow<<uint(diff) | 1
+		return true
+	case diff > -sequenceWindow:
+		bit := uint32(1) << uint(-diff)
+		if l.inWindow&bit != 0 {
+			return false
+		}
+		l.inWindow |= bit
+		return true
+	}
+
+	return false
+}
+
+// EncodeRMCPPlusPacket wraps a payload in an RMCP+ session packet, addressed to the session ID
+// assigned by the peer. Outside of an active session, keys is nil and the payload is sent in the
+// clear.
+func EncodeRMCPPlusPacket(keys *SessionKeys, sessionID, sequence uint32, payloadType uint8, payload []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	err := binaryWrite(buf, rmcpHeader{
+		Version:            rmcpVersion1,
+		RMCPSequenceNumber: 0xff,
+		Class:              rmcpClassIPMI,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hdr := rmcpPlusSession{
+		AuthType:    authTypeRMCPPlus,
+		PayloadType: payloadType,
+		SessionID:   sessionID,
+		Sequence:    sequence,
+	}
+
+	if keys != nil {
+		if keys.suite.Confidentiality == confAESCBC128 {
+			hdr.PayloadType |= payloadEncrypted
+			if payload, err = keys.encryptPayload(payload); err != nil {
+				return nil, err
+			}
+		}
+
+		if keys.suite.Integrity != integrityNone {
+			hdr.PayloadType |= payloadAuthenticated
+		}
+	}
+
+	hdr.PayloadLength = uint16(len(payload))
+
+	session := new(bytes.Buffer)
+	if err := binaryWrite(session, hdr); err != nil {
+		return nil, err
+	}
+	session.Write(payload)
+
+	// Integrity pad so that the authenticated data, including pad length and next header fields,
+	// is a multiple of four bytes.
+	if hdr.PayloadType&payloadAuthenticated != 0 {
+		padLen := (4 - (session.Len()+2)%4) % 4
+		session.Write(bytes.Repeat([]byte{0xff}, padLen))
+		session.Write([]byte{uint8(padLen), 0x07})
+		session.Write(keys.integrityMAC(session.Bytes()))
+	}
+
+	buf.ReadFrom(session)
+
+	return buf.Bytes(), nil
+}
+
+// DecodeRMCPPlusPacket verifies and decrypts an RMCP+ session packet, which must be addressed to
+// sessionID if keys is non-nil, returning the payload type and payload.
+func DecodeRMCPPlusPacket(keys *SessionKeys, sessionID uint32, b []byte) (uint8, []byte, error) {
+	if len(b) < rmcpHeaderSize+rmcpPlusSessionSize {
+		return 0, nil, ErrShortData
+	}
+
+	session := b[rmcpHeaderSize:]
+
+	hdr := rmcpPlusSession{}
+	if err := binary.Read(bytes.NewReader(session), binary.LittleEndian, &hdr); err != nil {
+		return 0, nil, err
+	}
+
+	if hdr.AuthType != authTypeRMCPPlus {
+		return 0, nil, ErrInvalidData
+	}
+
+	end := rmcpPlusSessionSize + int(hdr.PayloadLength)
+	if end > len(session) {
+		return 0, nil, ErrShortData
+	}
+
+	if keys != nil {
+		if hdr.SessionID != sessionID {
+			return 0, nil, ErrSessionMismatch
+		}
+
+		if keys.suite.Integrity != integrityNone {
+			if hdr.PayloadType&payloadAuthenticated == 0 {
+				return 0, nil, ErrIntegrity
+			}
+
+			n := len(session) - keys.suite.authCodeLen()
+			if n < end+2 {
+				return 0, nil, ErrShortData
+			}
+
+			if !hmac.Equal(keys.integrityMAC(session[:n]), session[n:]) {
+				return 0, nil, ErrIntegrity
+			}
+		}
+	}
+
+	payload := session[rmcpPlusSessionSize:end]
+
+	// Only session setup payloads may be sent in the clear once confidentiality is negotiated
+	if keys != nil && keys.suite.Confidentiality != confNone && hdr.PayloadType&payloadEncrypted == 0 &&
+		!isSessionSetupPayload(hdr.PayloadType&payloadTypeMask) {
+		return 0, nil, ErrNotEncrypted
+	}
+
+	if hdr.PayloadType&payloadEncrypted != 0 {
+		if keys == nil || keys.suite.Confidentiality != confAESCBC128 {
+			return 0, nil, ErrInvalidData
+		}
+
+		var err error
+		if payload, err = keys.decryptPayload(payload); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	return hdr.PayloadType & payloadTypeMask, payload, nil
+}
+
+// isSessionSetupPayload reports whether a payload type is exchanged before the session is active,
+// i.e. Open Session or RAKP
+func isSessionSetupPayload(payloadType uint8) bool {
+	return payloadType >= payloadTypeOpenSessionRequest && payloadType <= payloadTypeRAKP4
+}
+
+// integrityMAC calculates the auth code for an authenticated packet per section 13.28.4
+func (k *SessionKeys) integrityMAC(b []byte) []byte {
+	mac := hmac.New(k.suite.integrityHash(), k.k1)
+	mac.Write(b)
+	return mac.Sum(nil)[:k.suite.authCodeLen()]
+}
+
+// encryptPayload encrypts a payload with AES-CBC-128 per section 13.29, prepending the IV
+func (k *SessionKeys) encryptPayload(payload []byte) ([]byte, error) {
+	padLen := (aes.BlockSize - (len(payload)+1)%aes.BlockSize) % aes.BlockSize
+
+	plaintext := make([]byte, len(payload), len(payload)+padLen+1)
+	copy(plaintext, payload)
+	for i := 1; i <= padLen; i++ {
+		plaintext = append(plaintext, uint8(i))
+	}
+	plaintext = append(plaintext, uint8(padLen))
+
+	out := make([]byte, aes.BlockSize+len(plaintext))
+	iv := out[:aes.BlockSize]
+	if _, err := crand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(k.k2[:16])
+	if err != nil {
+		return nil, err
+	}
+
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out[aes.BlockSize:], plaintext)
+
+	return out, nil
+}
+
+// le32 returns the little-endian encoding of a 32-bit integer
+func le32(v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, v)
+}
+
+// decryptPayload decrypts an AES-CBC-128 payload per section 13.29, removing the confidentiality
+// trailer.
+func (k *SessionKeys) decryptPayload(b []byte) ([]byte, error) {
+	if len(b) < 2*aes.BlockSize || len(b)%aes.BlockSize != 0 {
+		return nil, ErrInvalidData
+	}
+
+	block, err := aes.NewCipher(k.k2[:16])
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := make([]byte, len(b)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, b[:aes.BlockSize]).CryptBlocks(plaintext, b[aes.BlockSize:])
+
+	padLen := int(plaintext[len(plaintext)-1])
+	if padLen >= aes.BlockSize {
+		return nil, ErrInvalidData
+	}
+
+	n := len(plaintext) - 1 - padLen
+	for i := 0; i < padLen; i++ {
+		if plaintext[n+i] != uint8(i+1) {
+			return nil, ErrInvalidData
+		}
+	}
+
+	return plaintext[:n], nil
+}
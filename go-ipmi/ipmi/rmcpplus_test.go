@@ -0,0 +1,219 @@
+package ipmi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestParseCipherSuiteRecords(t *testing.T) {
+	// Records for cipher suites 1, 2, 3, an OEM suite and 17
+	records := []byte{
+		0xc0, 0x01, 0x01,
+		0xc0, 0x02, 0x01, 0x41,
+		0xc0, 0x03, 0x01, 0x41, 0x81,
+		0xc1, 0x80, 0x57, 0x01, 0x00, 0x01,
+		0xc0, 0x11, 0x03, 0x44, 0x81,
+	}
+
+	ids := parseCipherSuiteRecords(records)
+	if !bytes.Equal(ids, []uint8{1, 2, 3, 17}) {
+		t.Errorf("unexpected cipher suite IDs: %v", ids)
+	}
+}
+
+func TestRMCPPlusMessageRoundTrip(t *testing.T) {
+	for _, suite := range cipherSuites {
+		l := &lanConnection{
+			version:          IPMIVersion20,
+			SessionKeys:      SessionKeys{suite: suite},
+			sessionID:        0x12345678,
+			consoleSessionID: 0x12345678,
+			sequence:         1,
+			sik:              bytes.Repeat([]byte{0x5a}, 20),
+		}
+		l.k1 = suite.deriveKey(l.sik, 0x01)
+		l.k2 = suite.deriveKey(l.sik, 0x02)
+		format := rmcpPlusFormat{l}
+
+		for n := 0; n < 40; n++ {
+			payload := bytes.Repeat([]byte{0xa5}, n)
+
+			pkt, err := format.encode(payloadTypeIPMI, payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			payloadType, decoded, err := format.decode(pkt)
+			if err != nil {
+				t.Fatalf("suite %d, payload len %d: %v", suite.ID, n, err)
+			}
+
+			if payloadType != payloadTypeIPMI || !bytes.Equal(decoded, payload) {
+				t.Errorf("suite %d, payload len %d: decoded payload mismatch", suite.ID, n)
+			}
+
+			if suite.Integrity != integrityNone {
+				// Replayed packets must be rejected
+				if _, _, err := format.decode(pkt); err != ErrSequence {
+					t.Errorf("suite %d, payload len %d: expected sequence error for replay, got %v", suite.ID, n, err)
+				}
+
+				// Authenticated data must be a multiple of four bytes
+				if (len(pkt)-rmcpHeaderSize-suite.authCodeLen())%4 != 0 {
+					t.Errorf("suite %d, payload len %d: bad integrity pad", suite.ID, n)
+				}
+
+				// Tampering with the payload must be detected
+				pkt[len(pkt)-suite.authCodeLen()-3] ^= 0xff
+				if _, _, err := format.decode(pkt); err == nil {
+					t.Errorf("suite %d, payload len %d: tampered packet accepted", suite.ID, n)
+				}
+			}
+		}
+	}
+}
+
+func TestAcceptSequence(t *testing.T) {
+	tests := []struct {
+		seqs   []uint32 // Received in turn
+		accept []bool
+	}{
+		{[]uint32{1, 2, 3, 5, 4}, []bool{true, true, true, true, true}},
+		{[]uint32{7, 7, 6, 7}, []bool{true, false, true, false}},
+		{[]uint32{0, 1, 0}, []bool{false, true, false}},
+		{[]uint32{40, 9, 8, 100}, []bool{true, true, false, true}}, // Window of 32 back from 40
+		{[]uint32{100, 68, 69, 69}, []bool{true, false, true, false}},
+		{[]uint32{0xfffffffe, 0xffffffff, 1, 0xffffffff, 0xfffffffd}, []bool{true, true, true, false, true}},
+		{[]uint32{5, 5 + 0x80000000}, []bool{true, false}}, // Too far ahead to be distinguished from behind
+	}
+
+	for _, tt := range tests {
+		l := &lanConnection{}
+		for i, seq := range tt.seqs {
+			if accepted := l.acceptSequence(seq); accepted != tt.accept[i] {
+				t.Errorf("%v: sequence number %#x accepted %v, expected %v", tt.seqs, seq, accepted, tt.accept[i])
+			}
+		}
+	}
+}
+
+func TestCheckAlgorithms(t *testing.T) {
+	suite, _ := LookupCipherSuite(17)
+
+	tests := []struct {
+		auth, integrity, conf uint8
+		ok                    bool
+	}{
+		{suite.Auth, suite.Integrity, suite.Confidentiality, true},
+		{suite.Auth | 0x40, suite.Integrity, suite.Confidentiality, true}, // Reserved bits are ignored
+		{authRAKPNone, suite.Integrity, suite.Confidentiality, false},
+		{suite.Auth, integrityNone, suite.Confidentiality, false},
+		{suite.Auth, suite.Integrity, confNone, false},
+	}
+
+	for _, tt := range tests {
+		resp := &openSessionResponse{
+			Auth:            algorithmPayload{Type: 0x00, Length: 8, Algorithm: tt.auth},
+			Integrity:       algorithmPayload{Type: 0x01, Length: 8, Algorithm: tt.integrity},
+			Confidentiality: algorithmPayload{Type: 0x02, Length: 8, Algorithm: tt.conf},
+		}
+
+		if err := checkAlgorithms(suite, resp); (err == nil) != tt.ok || (err != nil && !errors.Is(err, ErrAlgorithmMismatch)) {
+			t.Errorf("algorithms 0x%02x, 0x%02x, 0x%02x: expected ok %v, got %v", tt.auth, tt.integrity, tt.conf, tt.ok, err)
+		}
+	}
+}
+
+func TestRMCPPlusRejectsUnencrypted(t *testing.T) {
+	for _, suite := range cipherSuites {
+		if suite.Confidentiality == confNone {
+			continue
+		}
+
+		sik := bytes.Repeat([]byte{0x5a}, 20)
+		keys := *NewSessionKeys(suite, sik)
+
+		// Same integrity key, but payloads sent in the clear
+		plain := keys
+		plain.suite.Confidentiality = confNone
+
+		for _, payloadType := range []uint8{payloadTypeIPMI, payloadTypeSOL} {
+			pkt, err := EncodeRMCPPlusPacket(&plain, 0x1234, 1, payloadType, []byte{1, 2, 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, _, err := DecodeRMCPPlusPacket(&keys, 0x1234, pkt); err != ErrNotEncrypted {
+				t.Errorf("suite %d, payload type %d: expected unencrypted payload error, got %v",
+					suite.ID, payloadType, err)
+			}
+		}
+	}
+}
+
+// Known answers for the RAKP auth codes, SIK and derived keys of sections 13.31 and 13.32, computed
+// from the specification's formulas with an independent implementation (Python hmac), rather than
+// with the code under test
+func TestRAKPKnownAnswers(t *testing.T) {
+	tests := []struct {
+		suite                            uint8
+		rakp2, rakp3, sik, rakp4, k1, k2 string
+	}{
+		{
+			suite: 3, // RAKP-HMAC-SHA1, HMAC-SHA1-96
+			rakp2: "552fc3355f03bd380862b8f8de4fbac96050777b",
+			rakp3: "5db4c85fa43a62da00259e259f49842730990c18",
+			sik:   "8443e6aa220894122518edf6d9078b650948ed27",
+			rakp4: "1d9e4b9ec8f0040d704034cc",
+			k1:    "89c197104b5ea182058ddecd4f0afac416974e20",
+			k2:    "408be27dbed3df6b15a0c28b4af9dfef3915dd85",
+		},
+		{
+			suite: 17, // RAKP-HMAC-SHA256, HMAC-SHA256-128
+			rakp2: "a861ddbcb84762fbba9646f106d132c4fc69fd5d2bd5a66fa8fd14ce112fdb0a",
+			rakp3: "21133d6d5aeb2b89e2b20724951de969e69bee0853a1cf9dcafd99471dacb53f",
+			sik:   "2bee32961c89af6b22542eda32789e169aa0c6ea298a2779df7a2c760c1b8e5b",
+			rakp4: "4b72640f5a3350ef2900f34339a29fbd",
+			k1:    "f7a48a5dbb5f2a31bb8e55f8a95cd54b8ff4e40dd3fa62737ce896a13f124823",
+			k2:    "4b01795421476d86ce08957600a10fb5d1acbff138610162bb65b34bdcd77b50",
+		},
+	}
+
+	for _, tt := range tests {
+		suite, _ := LookupCipherSuite(tt.suite)
+
+		x := &RAKPExchange{
+			Suite:            suite,
+			KUID:             []byte("admin"),
+			ConsoleSessionID: 0xa0a1a2a3,
+			ManagedSessionID: 0x02000300,
+			Role:             PrivLevelAdmin | rakpNameOnlyLookup,
+			Username:         []byte("admin"),
+		}
+
+		for i := 0; i < 16; i++ {
+			x.RM[i], x.RC[i], x.GUID[i] = uint8(i), uint8(0x10+i), uint8(0x20+i)
+		}
+
+		sik := x.SIK()
+
+		for _, v := range []struct {
+			name string
+			got  []byte
+			want string
+		}{
+			{"RAKP 2 auth code", x.RAKP2AuthCode(), tt.rakp2},
+			{"RAKP 3 auth code", x.RAKP3AuthCode(), tt.rakp3},
+			{"SIK", sik, tt.sik},
+			{"RAKP 4 ICV", x.RAKP4ICV(sik), tt.rakp4},
+			{"K1", x.Suite.deriveKey(sik, 0x01), tt.k1},
+			{"K2", x.Suite.deriveKey(sik, 0x02), tt.k2},
+		} {
+			if got := hex.EncodeToString(v.got); got != v.want {
+				t.Errorf("suite %d %s = %s, want %s", tt.suite, v.name, got, v.want)
+			}
+		}
+	}
+}
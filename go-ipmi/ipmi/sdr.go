@@ -0,0 +1,561 @@
+package ipmi
+
+// Sensor Data Record repository access per section 33, and record formats per section 43
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// SDR record types per table 43-1 onwards
+const (
+	SDRTypeFullSensor       = 0x01
+	SDRTypeCompactSensor    = 0x02
+	SDRTypeEventOnly        = 0x03
+	SDRTypeFRUDeviceLocator = 0x11
+	SDRTypeMCDeviceLocator  = 0x12
+)
+
+const (
+	sdrHeaderSize         = 5
+	sdrChunkSize          = 16 // Conservative partial read size supported by all BMCs
+	sdrReservationRetries = 3
+	sdrRecordIDFirst      = 0x0000
+	sdrRecordIDLast       = 0xffff
+)
+
+// SDRRepositoryInfo per section 33.9
+type SDRRepositoryInfo struct {
+	Version          uint8 // SDR version, BCD encoded
+	RecordCount      uint16
+	FreeSpace        uint16
+	LastAddition     uint32 // Timestamp of most recent addition
+	LastErase        uint32 // Timestamp of most recent erase
+	OperationSupport uint8
+}
+
+func (r *SDRRepositoryInfo) MarshalBinary() ([]byte, error) {
+	b := binary.LittleEndian.AppendUint16([]byte{r.Version}, r.RecordCount)
+	b = binary.LittleEndian.AppendUint16(b, r.FreeSpace)
+	b = binary.LittleEndian.AppendUint32(b, r.LastAddition)
+	b = binary.LittleEndian.AppendUint32(b, r.LastErase)
+
+	return append(b, r.OperationSupport), nil
+}
+
+func (r *SDRRepositoryInfo) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.Version = d.uint8()
+	r.RecordCount = d.uint16()
+	r.FreeSpace = d.uint16()
+	r.LastAddition = d.uint32()
+	r.LastErase = d.uint32()
+	r.OperationSupport = d.uint8()
+
+	return d.err
+}
+
+// ReserveSDRRepositoryResponse per section 33.11
+type ReserveSDRRepositoryResponse struct {
+	ReservationID uint16
+}
+
+func (r *ReserveSDRRepositoryResponse) MarshalBinary() ([]byte, error) {
+	return binary.LittleEndian.AppendUint16(nil, r.ReservationID), nil
+}
+
+func (r *ReserveSDRRepositoryResponse) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.ReservationID = d.uint16()
+
+	return d.err
+}
+
+// GetSDRRequest per section 33.12
+type GetSDRRequest struct {
+	ReservationID uint16
+	RecordID      uint16
+	Offset        uint8
+	Length        uint8 // 0xff reads entire record
+}
+
+func (r *GetSDRRequest) MarshalBinary() ([]byte, error) {
+	b := binary.LittleEndian.AppendUint16(nil, r.ReservationID)
+	b = binary.LittleEndian.AppendUint16(b, r.RecordID)
+
+	return append(b, r.Offset, r.Length), nil
+}
+
+func (r *GetSDRRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.ReservationID = d.uint16()
+	r.RecordID = d.uint16()
+	r.Offset = d.uint8()
+	r.Length = d.uint8()
+
+	return d.err
+}
+
+// GetSDRResponse per section 33.12
+type GetSDRResponse struct {
+	NextRecordID uint16
+	Data         []byte // Requested part of the record
+}
+
+func (r *GetSDRResponse) MarshalBinary() ([]byte, error) {
+	return append(binary.LittleEndian.AppendUint16(nil, r.NextRecordID), r.Data...), nil
+}
+
+func (r *GetSDRResponse) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.NextRecordID = d.uint16()
+	r.Data = d.rest()
+
+	return d.err
+}
+
+// SDRRecord is implemented by all decoded SDR record types
+type SDRRecord interface {
+	Header() *SDRHeader
+}
+
+// SDRHeader is common to all SDR records per section 43
+type SDRHeader struct {
+	RecordID uint16
+	Version  uint8
+	Type     uint8
+	Length   uint8 // Length of record body, excluding header
+}
+
+// Header returns the record header
+func (h *SDRHeader) Header() *SDRHeader {
+	return h
+}
+
+// SensorKey identifies a sensor, and is common to sensor records. The owner is the controller
+// that answers Get Sensor Reading for the sensor: the BMC, or a satellite controller on an IPMB
+// channel behind it, or system software if bit 0 of OwnerID is set.
+type SensorKey struct {
+	OwnerID      uint8 // IPMB slave address, or system software ID if bit 0 is set
+	Channel      uint8 // Channel of the owner, zero for the BMC and the primary IPMB
+	OwnerLUN     uint8 // LUN of the sensor, 0 - 3
+	SensorNumber uint8
+}
+
+// decodeSensorKey decodes the sensor owner ID, owner LUN and sensor number bytes of a sensor record.
+// The FRU inventory device owner LUN in bits 3:2 of the owner LUN byte is not part of the key.
+func decodeSensorKey(b []byte) SensorKey {
+	return SensorKey{OwnerID: b[0], Channel: b[1] >> 4, OwnerLUN: b[1] & 0x03, SensorNumber: b[2]}
+}
+
+// FullSensorRecord per table 43-1
+type FullSensorRecord struct {
+	SDRHeader
+	SensorKey
+	EntityID            uint8
+	EntityInstance      uint8
+	Initialization      uint8
+	Capabilities        uint8
+	SensorType          uint8
+	EventReadingType    uint8
+	AssertionMask       uint16 // Also lower threshold reading mask
+	DeassertionMask     uint16 // Also upper threshold reading mask
+	ReadingMask         uint16 // Also settable / readable threshold mask
+	Units1              uint8  // Analog data format, rate unit, modifier unit, percentage
+	BaseUnit            uint8
+	ModifierUnit        uint8
+	Linearization       uint8
+	M                   int16 // 10-bit signed
+	Tolerance           uint8
+	B                   int16 // 10-bit signed
+	Accuracy            uint16
+	AccuracyExp         uint8
+	Direction           uint8
+	RExp                int8 // Result exponent (K2), 4-bit signed
+	BExp                int8 // B exponent (K1), 4-bit signed
+	AnalogFlags         uint8
+	NominalReading      uint8
+	NormalMax           uint8
+	NormalMin           uint8
+	SensorMax           uint8
+	SensorMin           uint8
+	UpperNonRecoverable uint8
+	UpperCritical       uint8
+	UpperNonCritical    uint8
+	LowerNonRecoverable uint8
+	LowerCritical       uint8
+	LowerNonCritical    uint8
+	PositiveHysteresis  uint8
+	NegativeHysteresis  uint8
+	OEM                 uint8
+	IDString            string
+}
+
+// CompactSensorRecord per table 43-2
+type CompactSensorRecord struct {
+	SDRHeader
+	SensorKey
+	EntityID           uint8
+	EntityInstance     uint8
+	Initialization     uint8
+	Capabilities       uint8
+	SensorType         uint8
+	EventReadingType   uint8
+	AssertionMask      uint16
+	DeassertionMask    uint16
+	ReadingMask        uint16
+	Units1             uint8
+	BaseUnit           uint8
+	ModifierUnit       uint8
+	RecordSharing      uint16
+	PositiveHysteresis uint8
+	NegativeHysteresis uint8
+	OEM                uint8
+	IDString           string
+}
+
+// EventOnlyRecord per table 43-3
+type EventOnlyRecord struct {
+	SDRHeader
+	SensorKey
+	EntityID         uint8
+	EntityInstance   uint8
+	SensorType       uint8
+	EventReadingType uint8
+	RecordSharing    uint16
+	OEM              uint8
+	IDString         string
+}
+
+// FRUDeviceLocatorRecord per table 43-7
+type FRUDeviceLocatorRecord struct {
+	SDRHeader
+	AccessAddress      uint8
+	FRUDeviceID        uint8 // FRU device ID, or slave address for non-intelligent devices
+	Logical            bool  // Logical FRU device, accessed via FRU commands to mgmt controller
+	AccessLUN          uint8
+	PrivateBus         uint8
+	Channel            uint8
+	DeviceType         uint8
+	DeviceTypeModifier uint8
+	EntityID           uint8
+	EntityInstance     uint8
+	OEM                uint8
+	IDString           string
+}
+
+// MCDeviceLocatorRecord per table 43-8
+type MCDeviceLocatorRecord struct {
+	SDRHeader
+	SlaveAddress       uint8
+	Channel            uint8
+	PowerStateInit     uint8 // Power state notification, global initialization
+	DeviceCapabilities uint8
+	EntityID           uint8
+	EntityInstance     uint8
+	OEM                uint8
+	IDString           string
+}
+
+// GenericSDRRecord holds the undecoded body of an unsupported record type
+type GenericSDRRecord struct {
+	SDRHeader
+	Data []byte
+}
+
+// getSDRRepositoryInfo returns information about the SDR repository
+func (c *conn) getSDRRepositoryInfo() (*SDRRepositoryInfo, error) {
+	resp := &SDRRepositoryInfo{}
+
+	if err := c.send(NetFnStorage, CmdGetSDRRepositoryInfo, nil, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// reserveSDRRepository obtains a reservation ID, required for partial reads of SDR records
+func (c *conn) reserveSDRRepository() (uint16, error) {
+	resp := ReserveSDRRepositoryResponse{}
+
+	if err := c.send(NetFnStorage, CmdReserveSDRRepository, nil, &resp); err != nil {
+		return 0, err
+	}
+
+	return resp.ReservationID, nil
+}
+
+// getSDRPartial reads part of an SDR record, returning the ID of the next record and the data
+func (c *conn) getSDRPartial(reservationID, recordID uint16, offset, length uint8) (uint16, []byte, error) {
+	req := &GetSDRRequest{reservationID, recordID, offset, length}
+	resp := GetSDRResponse{}
+
+	if err := c.send(NetFnStorage, CmdGetSDR, req, &resp); err != nil {
+		return 0, nil, err
+	}
+
+	return resp.NextRecordID, resp.Data, nil
+}
+
+// getSDR reads an entire SDR record in chunks, returning the ID of the next record and the raw
+// record including header. The read is restarted with a new reservation if the reservation is
+// canceled, e.g. by a concurrent update of the repository.
+func (c *conn) getSDR(reservationID, recordID uint16) (uint16, []byte, uint16, error) {
+	chunkSize := uint8(sdrChunkSize)
+
+	for attempt := 0; ; attempt++ {
+		next, record, err := c.readSDR(reservationID, recordID, &chunkSize)
+		if err == nil {
+			return next, record, reservationID, nil
+		}
+
+		if !errors.Is(err, ErrReservationCanceled) || attempt >= sdrReservationRetries {
+			return 0, nil, reservationID, err
+		}
+
+		if reservationID, err = c.reserveSDRRepository(); err != nil {
+			return 0, nil, reservationID, err
+		}
+	}
+}
+
+func (c *conn) readSDR(reservationID, recordID uint16, chunkSize *uint8) (uint16, []byte, error) {
+	next, record, err := c.getSDRPartial(reservationID, recordID, 0, sdrHeaderSize)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if len(record) < sdrHeaderSize {
+		return 0, nil, ErrShortData
+	}
+
+	total := sdrHeaderSize + int(record[4])
+
+	for len(record) < total {
+		n := total - len(record)
+		if n > int(*chunkSize) {
+			n = int(*chunkSize)
+		}
+
+		_, chunk, err := c.getSDRPartial(reservationID, recordID, uint8(len(record)), uint8(n))
+		if errors.Is(err, ErrCannotReturnBytes) && *chunkSize > 4 {
+			// BMC cannot return this many bytes at once; retry with smaller chunks
+			*chunkSize /= 2
+			continue
+		} else if err != nil {
+			return 0, nil, err
+		}
+
+		if len(chunk) == 0 {
+			return 0, nil, ErrShortData
+		}
+
+		record = append(record, chunk...)
+	}
+
+	return next, record[:total], nil
+}
+
+// readSDRRepository reads and decodes all records in the SDR repository
+func (c *conn) readSDRRepository() ([]SDRRecord, error) {
+	reservationID, err := c.reserveSDRRepository()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []SDRRecord
+
+	for id := uint16(sdrRecordIDFirst); id != sdrRecordIDLast; {
+		var b []byte
+
+		id, b, reservationID, err = c.getSDR(reservationID, id)
+		if err != nil {
+			return records, fmt.Errorf("read SDR: %w", err)
+		}
+
+		r, err := decodeSDR(b)
+		if err != nil {
+			return records, fmt.Errorf("decode SDR: %w", err)
+		}
+
+		records = append(records, r)
+	}
+
+	return records, nil
+}
+
+// decodeSDR decodes a raw SDR record, including header
+func decodeSDR(b []byte) (SDRRecord, error) {
+	if len(b) < sdrHeaderSize {
+		return nil, ErrShortData
+	}
+
+	hdr := SDRHeader{
+		RecordID: binary.LittleEndian.Uint16(b[0:2]),
+		Version:  b[2],
+		Type:     b[3],
+		Length:   b[4],
+	}
+
+	if len(b) < sdrHeaderSize+int(hdr.Length) {
+		return nil, ErrShortData
+	}
+
+	// Offsets below are relative to the start of the record, and are one less than the byte
+	// numbers in the spec tables.
+	b = b[:sdrHeaderSize+int(hdr.Length)]
+
+	switch hdr.Type {
+	case SDRTypeFullSensor:
+		if len(b) < 48 {
+			return nil, ErrShortData
+		}
+
+		return &FullSensorRecord{
+			SDRHeader:           hdr,
+			SensorKey:           decodeSensorKey(b[5:8]),
+			EntityID:            b[8],
+			EntityInstance:      b[9],
+			Initialization:      b[10],
+			Capabilities:        b[11],
+			SensorType:          b[12],
+			EventReadingType:    b[13],
+			AssertionMask:       binary.LittleEndian.Uint16(b[14:16]),
+			DeassertionMask:     binary.LittleEndian.Uint16(b[16:18]),
+			ReadingMask:         binary.LittleEndian.Uint16(b[18:20]),
+			Units1:              b[20],
+			BaseUnit:            b[21],
+			ModifierUnit:        b[22],
+			Linearization:       b[23] & 0x7f,
+			M:                   signExtend(uint16(b[24])|uint16(b[25]&0xc0)<<2, 10),
+			Tolerance:           b[25] & 0x3f,
+			B:                   signExtend(uint16(b[26])|uint16(b[27]&0xc0)<<2, 10),
+			Accuracy:            uint16(b[27]&0x3f) | uint16(b[28]&0xf0)<<2,
+			AccuracyExp:         (b[28] >> 2) & 0x03,
+			Direction:           b[28] & 0x03,
+			RExp:                int8(signExtend(uint16(b[29]>>4), 4)),
+			BExp:                int8(signExtend(uint16(b[29]&0x0f), 4)),
+			AnalogFlags:         b[30],
+			NominalReading:      b[31],
+			NormalMax:           b[32],
+			NormalMin:           b[33],
+			SensorMax:           b[34],
+			SensorMin:           b[35],
+			UpperNonRecoverable: b[36],
+			UpperCritical:       b[37],
+			UpperNonCritical:    b[38],
+			LowerNonRecoverable: b[39],
+			LowerCritical:       b[40],
+			LowerNonCritical:    b[41],
+			PositiveHysteresis:  b[42],
+			NegativeHysteresis:  b[43],
+			OEM:                 b[46],
+			IDString:            sdrIDString(b[47:]),
+		}, nil
+
+	case SDRTypeCompactSensor:
+		if len(b) < 32 {
+			return nil, ErrShortData
+		}
+
+		return &CompactSensorRecord{
+			SDRHeader:          hdr,
+			SensorKey:          decodeSensorKey(b[5:8]),
+			EntityID:           b[8],
+			EntityInstance:     b[9],
+			Initialization:     b[10],
+			Capabilities:       b[11],
+			SensorType:         b[12],
+			EventReadingType:   b[13],
+			AssertionMask:      binary.LittleEndian.Uint16(b[14:16]),
+			DeassertionMask:    binary.LittleEndian.Uint16(b[16:18]),
+			ReadingMask:        binary.LittleEndian.Uint16(b[18:20]),
+			Units1:             b[20],
+			BaseUnit:           b[21],
+			ModifierUnit:       b[22],
+			RecordSharing:      binary.LittleEndian.Uint16(b[23:25]),
+			PositiveHysteresis: b[25],
+			NegativeHysteresis: b[26],
+			OEM:                b[30],
+			IDString:           sdrIDString(b[31:]),
+		}, nil
+
+	case SDRTypeEventOnly:
+		if len(b) < 17 {
+			return nil, ErrShortData
+		}
+
+		return &EventOnlyRecord{
+			SDRHeader:        hdr,
+			SensorKey:        decodeSensorKey(b[5:8]),
+			EntityID:         b[8],
+			EntityInstance:   b[9],
+			SensorType:       b[10],
+			EventReadingType: b[11],
+			RecordSharing:    binary.LittleEndian.Uint16(b[12:14]),
+			OEM:              b[15],
+			IDString:         sdrIDString(b[16:]),
+		}, nil
+
+	case SDRTypeFRUDeviceLocator:
+		if len(b) < 16 {
+			return nil, ErrShortData
+		}
+
+		return &FRUDeviceLocatorRecord{
+			SDRHeader:          hdr,
+			AccessAddress:      b[5] >> 1,
+			FRUDeviceID:        b[6],
+			Logical:            b[7]&0x80 != 0,
+			AccessLUN:          (b[7] >> 3) & 0x03,
+			PrivateBus:         b[7] & 0x07,
+			Channel:            b[8] >> 4,
+			DeviceType:         b[10],
+			DeviceTypeModifier: b[11],
+			EntityID:           b[12],
+			EntityInstance:     b[13],
+			OEM:                b[14],
+			IDString:           sdrIDString(b[15:]),
+		}, nil
+
+	case SDRTypeMCDeviceLocator:
+		if len(b) < 16 {
+			return nil, ErrShortData
+		}
+
+		return &MCDeviceLocatorRecord{
+			SDRHeader:          hdr,
+			SlaveAddress:       b[5] >> 1,
+			Channel:            b[6] & 0x0f,
+			PowerStateInit:     b[7],
+			DeviceCapabilities: b[8],
+			EntityID:           b[12],
+			EntityInstance:     b[13],
+			OEM:                b[14],
+			IDString:           sdrIDString(b[15:]),
+		}, nil
+	}
+
+	return &GenericSDRRecord{hdr, b[sdrHeaderSize:]}, nil
+}
+
+// sdrIDString decodes an ID string, prefixed by its type/length byte per section 43.15
+func sdrIDString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+
+	n := int(b[0] & 0x1f)
+	if n > len(b)-1 {
+		n = len(b) - 1
+	}
+
+	return decodeString(b[0]>>6, b[1:1+n])
+}
+
+// signExtend interprets the low n bits of v as a two's complement signed integer
+func signExtend(v uint16, n uint) int16 {
+	shift := 16 - n
+	return int16(v<<shift) >> shift
+}
@@ -0,0 +1,65 @@
+package ipmi
+
+import (
+	"testing"
+)
+
+func TestDecodeFullSensorRecord(t *testing.T) {
+	// Temperature sensor, M = 1, B = -5, K1 = 1, K2 = -1, 8-bit ASCII ID string
+	b := []byte{
+		0x2a, 0x00, 0x51, 0x01, 0x33, // Header
+		0x20, 0x00, 0x30, // Sensor key
+		0x03, 0x01, 0x7f, 0x68, 0x01, 0x01, // Entity, init, caps, sensor type, event/reading type
+		0x95, 0x7a, 0x95, 0x7a, 0x3f, 0x3f, // Masks
+		0x80, 0x01, 0x00, 0x00, // Units, linearization
+		0x01, 0x00, 0xfb, 0xc0, 0x00, 0xf1, // M, tolerance, B, accuracy, direction, exponents
+		0x07, 0x19, 0x32, 0x05, 0x7f, 0x80, // Analog flags, nominal, normal max/min, sensor max/min
+		0x5a, 0x55, 0x50, 0x00, 0x00, 0x00, // Thresholds
+		0x02, 0x02, 0x00, 0x00, 0x00, // Hysteresis, reserved, OEM
+		0xc8, 'C', 'P', 'U', ' ', 'T', 'e', 'm', 'p',
+	}
+
+	r, err := decodeSDR(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs, ok := r.(*FullSensorRecord)
+	if !ok {
+		t.Fatalf("unexpected record type %T", r)
+	}
+
+	if fs.RecordID != 0x2a || fs.SensorNumber != 0x30 || fs.SensorType != 0x01 {
+		t.Errorf("unexpected record header / key: %+v", fs)
+	}
+
+	if fs.M != 1 || fs.B != -5 || fs.BExp != 1 || fs.RExp != -1 {
+		t.Errorf("unexpected conversion factors: M=%d B=%d K1=%d K2=%d", fs.M, fs.B, fs.BExp, fs.RExp)
+	}
+
+	if fs.UpperNonRecoverable != 0x5a || fs.UpperCritical != 0x55 || fs.UpperNonCritical != 0x50 {
+		t.Errorf("unexpected thresholds: %+v", fs)
+	}
+
+	if fs.IDString != "CPU Temp" {
+		t.Errorf("unexpected ID string: %q", fs.IDString)
+	}
+}
+
+func TestDecodeString(t *testing.T) {
+	tests := []struct {
+		typ  uint8
+		in   []byte
+		want string
+	}{
+		{stringType6BitASCII, []byte{0x29, 0xdc, 0xa6}, "IPMI"},
+		{stringTypeBCDPlus, []byte{0x12, 0xa3, 0xb4}, "12 3-4"},
+		{stringType8BitASCII, []byte{'a', 'b', 0xe9, 0x00}, "abé"},
+	}
+
+	for _, tt := range tests {
+		if got := decodeString(tt.typ, tt.in); got != tt.want {
+			t.Errorf("decodeString(%d, % x) = %q, want %q", tt.typ, tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+package ipmi
+
+// System Event Log commands per section 31, and event record formats per section 32
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SEL record types per section 32
+const (
+	SELRecordTypeSystemEvent = 0x02
+	SELRecordTypeOEMMin      = 0xc0 // 0xc0 - 0xdf are timestamped OEM records
+	SELRecordTypeOEMNoTime   = 0xe0 // 0xe0 - 0xff are non-timestamped OEM records
+)
+
+// Completion codes specific to SEL commands
+var (
+	ErrSELEraseInProgress = commandCode(0x81, NetFnStorage, CmdGetSELEntry, CmdClearSEL)
+)
+
+const (
+	selRecordSize           = 16
+	selRecordIDFirst        = 0x0000
+	selRecordIDLast         = 0xffff
+	selClearGetStatus       = 0x00
+	selClearInitiate        = 0xaa
+	selEraseCompleted       = 0x01
+	selClearPollInterval    = 100 * time.Millisecond
+	selClearTimeout         = 30 * time.Second
+	selTimestampUnspecified = 0xffffffff
+	selTimestampPreInitMax  = 0x20000000 // Timestamps up to here are relative to BMC initialization
+)
+
+// SELInfo per section 31.2
+type SELInfo struct {
+	Version          uint8 // SEL version, BCD encoded
+	Entries          uint16
+	FreeSpace        uint16 // Free space in bytes
+	LastAddition     uint32 // Timestamp of most recent addition
+	LastErase        uint32 // Timestamp of most recent erase
+	OperationSupport uint8
+}
+
+func (r *SELInfo) MarshalBinary() ([]byte, error) {
+	b := binary.LittleEndian.AppendUint16([]byte{r.Version}, r.Entries)
+	b = binary.LittleEndian.AppendUint16(b, r.FreeSpace)
+	b = binary.LittleEndian.AppendUint32(b, r.LastAddition)
+	b = binary.LittleEndian.AppendUint32(b, r.LastErase)
+
+	return append(b, r.OperationSupport), nil
+}
+
+func (r *SELInfo) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.Version = d.uint8()
+	r.Entries = d.uint16()
+	r.FreeSpace = d.uint16()
+	r.LastAddition = d.uint32()
+	r.LastErase = d.uint32()
+	r.OperationSupport = d.uint8()
+
+	return d.err
+}
+
+// ReserveSELResponse per section 31.4
+type ReserveSELResponse struct {
+	ReservationID uint16
+}
+
+func (r *ReserveSELResponse) MarshalBinary() ([]byte, error) {
+	return binary.LittleEndian.AppendUint16(nil, r.ReservationID), nil
+}
+
+func (r *ReserveSELResponse) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.ReservationID = d.uint16()
+
+	return d.err
+}
+
+// GetSELEntryRequest per section 31.5
+type GetSELEntryRequest struct {
+	ReservationID uint16 // Only required for partial reads
+	RecordID      uint16
+	Offset        uint8
+	Length        uint8 // 0xff reads entire record
+}
+
+func (r *GetSELEntryRequest) MarshalBinary() ([]byte, error) {
+	b := binary.LittleEndian.AppendUint16(nil, r.ReservationID)
+	b = binary.LittleEndian.AppendUint16(b, r.RecordID)
+
+	return append(b, r.Offset, r.Length), nil
+}
+
+func (r *GetSELEntryRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.ReservationID = d.uint16()
+	r.RecordID = d.uint16()
+	r.Offset = d.uint8()
+	r.Length = d.uint8()
+
+	return d.err
+}
+
+// GetSELEntryResponse per section 31.5
+type GetSELEntryResponse struct {
+	NextRecordID uint16
+	Data         []byte // Requested part of the record
+}
+
+func (r *GetSELEntryResponse) MarshalBinary() ([]byte, error) {
+	return append(binary.LittleEndian.AppendUint16(nil, r.NextRecordID), r.Data...), nil
+}
+
+func (r *GetSELEntryResponse) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.NextRecordID = d.uint16()
+	r.Data = d.rest()
+
+	return d.err
+}
+
+// SELEntry is a decoded SEL record. System event records per table 32-1 populate the event
+// fields, OEM records per tables 32-2 and 32-3 populate ManufacturerID and OEMData.
+type SELEntry struct {
+	RecordID   uint16
+	RecordType uint8
+	Timestamp  uint32 // Seconds since the epoch, or relative to BMC initialization
+
+	// System event records
+	GeneratorID  uint16
+	EvMRev       uint8
+	SensorType   uint8
+	SensorNumber uint8
+	EventType    uint8 // Event / reading type code
+	Deassertion  bool
+	EventData    [3]uint8
+
+	// OEM records
+	ManufacturerID uint32 // Timestamped OEM records only
+	OEMData        []byte
+}
+
+// Time returns the time of the event, and false if the timestamp is unspecified or relative to
+// initialization of the BMC
+func (e *SELEntry) Time() (time.Time, bool) {
+	return SELTime(e.Timestamp)
+}
+
+// SystemEvent returns true for system event records
+func (e *SELEntry) SystemEvent() bool {
+	return e.RecordType == SELRecordTypeSystemEvent
+}
+
+// Offset returns the event offset, identifying the event within its event / reading type
+func (e *SELEntry) Offset() uint8 {
+	return e.EventData[0] & 0x0f
+}
+
+// Description returns the description of the event offset
+func (e *SELEntry) Description() string {
+	if !e.SystemEvent() {
+		return fmt.Sprintf("OEM record type 0x%02x", e.RecordType)
+	}
+
+	return EventDescription(e.SensorType, e.EventType, e.Offset())
+}
+
+// SensorKey returns the key of the sensor which generated the event, for lookup in the SDR
+// repository. Event generator IDs share the owner ID and LUN format of sensor records.
+func (e *SELEntry) SensorKey() SensorKey {
+	return decodeSensorKey([]byte{uint8(e.GeneratorID), uint8(e.GeneratorID >> 8), e.SensorNumber})
+}
+
+// SELTime converts an SEL timestamp, returning false if the timestamp is unspecified or relative
+// to initialization of the BMC
+func SELTime(ts uint32) (time.Time, bool) {
+	if ts == selTimestampUnspecified || ts <= selTimestampPreInitMax {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(ts), 0), true
+}
+
+// SensorNames maps the keys of sensors in SDR records to their names, for naming the sensors
+// which generated SEL events
+func SensorNames(records []SDRRecord) map[SensorKey]string {
+	names := make(map[SensorKey]string)
+
+	for _, rec := range records {
+		var (
+			key  SensorKey
+			name string
+		)
+
+		switch r := rec.(type) {
+		case *FullSensorRecord:
+			key, name = r.SensorKey, r.IDString
+		case *CompactSensorRecord:
+			key, name = r.SensorKey, r.IDString
+		case *EventOnlyRecord:
+			key, name = r.SensorKey, r.IDString
+		default:
+			continue
+		}
+
+		names[key] = name
+	}
+
+	return names
+}
+
+// decodeSELEntry decodes a 16 byte SEL record
+func decodeSELEntry(b []byte) (*SELEntry, error) {
+	if len(b) < selRecordSize {
+		return nil, fmt.Errorf("SEL record too short: %d bytes", len(b))
+	}
+
+	e := &SELEntry{
+		RecordID:   binary.LittleEndian.Uint16(b[0:2]),
+		RecordType: b[2],
+	}
+
+	switch {
+	case e.RecordType >= SELRecordTypeOEMNoTime:
+		e.Timestamp = selTimestampUnspecified
+		e.OEMData = append([]byte(nil), b[3:16]...)
+	case e.RecordType >= SELRecordTypeOEMMin:
+		e.Timestamp = binary.LittleEndian.Uint32(b[3:7])
+		e.ManufacturerID = uint32(b[7]) | uint32(b[8])<<8 | uint32(b[9])<<16
+		e.OEMData = append([]byte(nil), b[10:16]...)
+	default:
+		// Record types other than 0x02 are reserved, and treated as system events
+		e.Timestamp = binary.LittleEndian.Uint32(b[3:7])
+		e.GeneratorID = binary.LittleEndian.Uint16(b[7:9])
+		e.EvMRev = b[9]
+		e.SensorType = b[10]
+		e.SensorNumber = b[11]
+		e.EventType = b[12] & 0x7f
+		e.Deassertion = b[12]&0x80 != 0
+		copy(e.EventData[:], b[13:16])
+	}
+
+	return e, nil
+}
+
+// getSELInfo returns information about the System Event Log
+func (c *conn) getSELInfo() (*SELInfo, error) {
+	resp := &SELInfo{}
+
+	if err := c.send(NetFnStorage, CmdGetSELInfo, nil, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// reserveSEL obtains a reservation ID, required for partial reads and clearing the SEL
+func (c *conn) reserveSEL() (uint16, error) {
+	resp := ReserveSELResponse{}
+
+	if err := c.send(NetFnStorage, CmdReserveSEL, nil, &resp); err != nil {
+		return 0, err
+	}
+
+	return resp.ReservationID, nil
+}
+
+// getSELEntry reads an entire SEL record, returning the ID of the next record and the decoded
+// record
+func (c *conn) getSELEntry(reservationID, recordID uint16) (uint16, *SELEntry, error) {
+	req := &GetSELEntryRequest{reservationID, recordID, 0, 0xff}
+	resp := GetSELEntryResponse{}
+
+	if err := c.send(NetFnStorage, CmdGetSELEntry, req, &resp); err != nil {
+		return 0, nil, err
+	}
+
+	e, err := decodeSELEntry(resp.Data)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return resp.NextRecordID, e, nil
+}
+
+// readSEL reads all SEL records, oldest first
+func (c *conn) readSEL() ([]*SELEntry, error) {
+	var entries []*SELEntry
+
+	for id := uint16(selRecordIDFirst); id != selRecordIDLast; {
+		next, e, err := c.getSELEntry(0, id)
+		if err != nil {
+			// An empty SEL has no first record
+			if id == selRecordIDFirst && errors.Is(err, ErrNotPresent) {
+				return nil, nil
+			}
+			return entries, fmt.Errorf("read SEL entry %#04x: %w", id, err)
+		}
+
+		if next == id {
+			return entries, fmt.Errorf("read SEL entry %#04x: next record ID unchanged", id)
+		}
+
+		entries = append(entries, e)
+		id = next
+	}
+
+	return entries, nil
+}
+
+// ClearSELRequest per section 31.9
+type ClearSELRequest struct {
+	ReservationID uint16
+	Operation     uint8 // selClearInitiate or selClearGetStatus
+}
+
+func (r *ClearSELRequest) MarshalBinary() ([]byte, error) {
+	return append(binary.LittleEndian.AppendUint16(nil, r.ReservationID), 'C', 'L', 'R', r.Operation), nil
+}
+
+func (r *ClearSELRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.ReservationID = d.uint16()
+
+	var clr [3]byte
+	d.bytes(clr[:])
+	r.Operation = d.uint8()
+
+	if d.err == nil && string(clr[:]) != "CLR" {
+		return ErrInvalidData
+	}
+
+	return d.err
+}
+
+// ClearSELResponse per section 31.9
+type ClearSELResponse struct {
+	Progress uint8 // Erasure progress, selEraseCompleted once done
+}
+
+func (r *ClearSELResponse) MarshalBinary() ([]byte, error) {
+	return []byte{r.Progress & 0x0f}, nil
+}
+
+func (r *ClearSELResponse) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.Progress = d.uint8() & 0x0f
+
+	return d.err
+}
+
+// clearSELRequest sends a Clear SEL request, returning the erasure progress
+func (c *conn) clearSELRequest(reservationID uint16, op uint8) (uint8, error) {
+	var resp ClearSELResponse
+
+	if err := c.send(NetFnStorage, CmdClearSEL, &ClearSELRequest{reservationID, op}, &resp); err != nil {
+		return 0, err
+	}
+
+	return resp.Progress, nil
+}
+
+// clearSEL erases all SEL records, polling until the erasure has completed
+func (c *conn) clearSEL() error {
+	reservationID, err := c.reserveSEL()
+	if err != nil {
+		return err
+	}
+
+	progress, err := c.clearSELRequest(reservationID, selClearInitiate)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(selClearTimeout)
+
+	for progress != selEraseCompleted {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("SEL erase not completed after %v", selClearTimeout)
+		}
+
+		time.Sleep(selClearPollInterval)
+
+		progress, err = c.clearSELRequest(reservationID, selClearGetStatus)
+		if errors.Is(err, ErrReservationCanceled) {
+			// Some BMCs cancel reservations once the erasure has started
+			if reservationID, err = c.reserveSEL(); err != nil {
+				return err
+			}
+			progress, err = c.clearSELRequest(reservationID, selClearGetStatus)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SELTimestamp is the SEL time clock, as read by Get SEL Time and written by Set SEL Time per
+// sections 31.10 and 31.11
+type SELTimestamp struct {
+	Timestamp uint32 // Seconds since the epoch
+}
+
+func (r *SELTimestamp) MarshalBinary() ([]byte, error) {
+	return binary.LittleEndian.AppendUint32(nil, r.Timestamp), nil
+}
+
+func (r *SELTimestamp) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.Timestamp = d.uint32()
+
+	return d.err
+}
+
+// getSELTime reads the SEL time clock
+func (c *conn) getSELTime() (time.Time, error) {
+	var resp SELTimestamp
+
+	if err := c.send(NetFnStorage, CmdGetSELTime, nil, &resp); err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(int64(resp.Timestamp), 0), nil
+}
+
+// setSELTime sets the SEL time clock
+func (c *conn) setSELTime(t time.Time) error {
+	return c.send(NetFnStorage, CmdSetSELTime, &SELTimestamp{uint32(t.Unix())}, nil)
+}
@@ -0,0 +1,119 @@
+package ipmi_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+func TestReadSEL(t *testing.T) {
+	_, addr := newTestSimulator(t, ipmisim.DefaultProfile())
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("user", "user", ipmi.PrivLevelUser); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := c.ReadSDRRepository()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := c.ReadSEL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		sensor      string
+		desc        string
+		deassertion bool
+	}{
+		{"CPU Temp", "Upper Critical going high", false},
+		{"CPU Temp", "Upper Critical going high", true},
+		{"PS1 Status", "Power Supply AC Lost", false},
+		{"Intrusion", "General Chassis Intrusion", false},
+	}
+
+	if len(entries) != len(want) {
+		t.Fatalf("read %d SEL entries, expected %d", len(entries), len(want))
+	}
+
+	names := ipmi.SensorNames(records)
+
+	for i, e := range entries {
+		if name := names[e.SensorKey()]; name != want[i].sensor {
+			t.Errorf("entry %d: sensor %q, expected %q", i, name, want[i].sensor)
+		}
+
+		if e.Description() != want[i].desc || e.Deassertion != want[i].deassertion {
+			t.Errorf("entry %d: unexpected event %q, deassertion %v", i, e.Description(), e.Deassertion)
+		}
+
+		if _, ok := e.Time(); !ok {
+			t.Errorf("entry %d: unexpected timestamp %#x", i, e.Timestamp)
+		}
+	}
+
+	// Trigger reading and threshold of threshold events
+	if d := entries[0].EventData; d[1] != 91 || d[2] != 90 {
+		t.Errorf("unexpected event data % x", d)
+	}
+}
+
+func TestClearSEL(t *testing.T) {
+	_, addr := newTestSimulator(t, ipmisim.DefaultProfile())
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("operator", "operator", ipmi.PrivLevelOperator); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := c.GetSELInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.ClearSEL(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := c.GetSELInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if before.Entries == 0 || info.Entries != 0 || info.LastErase < before.LastErase {
+		t.Errorf("unexpected SEL info before %+v, after %+v", before, info)
+	}
+
+	entries, err := c.ReadSEL()
+	if err != nil || len(entries) != 0 {
+		t.Errorf("read %d entries from cleared SEL: %v", len(entries), err)
+	}
+}
+
+func TestSetSELTime(t *testing.T) {
+	_, addr := newTestSimulator(t, ipmisim.DefaultProfile())
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("admin", "admin", ipmi.PrivLevelAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	want := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := c.SetSELTime(want); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.GetSELTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := got.Sub(want); d < 0 || d > 2*time.Second {
+		t.Errorf("SEL time %v, expected %v", got, want)
+	}
+}
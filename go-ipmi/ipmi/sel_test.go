@@ -0,0 +1,91 @@
+package ipmi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeSELEntry(t *testing.T) {
+	tests := []struct {
+		record []byte
+		want   SELEntry
+		desc   string
+	}{
+		{
+			// Upper critical going high, trigger reading and threshold in event data 2 and 3
+			[]byte{0x01, 0x00, 0x02, 0x00, 0x5e, 0x2e, 0x67, 0x20, 0x00, 0x04, 0x01, 0x07, 0x01, 0x59, 0x5b, 0x5a},
+			SELEntry{
+				RecordID: 1, RecordType: SELRecordTypeSystemEvent, Timestamp: 0x672e5e00,
+				GeneratorID: 0x0020, EvMRev: 0x04, SensorType: 0x01, SensorNumber: 0x07,
+				EventType: EventReadingTypeThreshold, EventData: [3]uint8{0x59, 0x5b, 0x5a},
+			},
+			"Upper Critical going high",
+		},
+		{
+			// Power supply AC lost, deasserted
+			[]byte{0x02, 0x00, 0x02, 0x00, 0x5e, 0x2e, 0x67, 0x20, 0x00, 0x04, 0x08, 0x30, 0xef, 0x03, 0xff, 0xff},
+			SELEntry{
+				RecordID: 2, RecordType: SELRecordTypeSystemEvent, Timestamp: 0x672e5e00,
+				GeneratorID: 0x0020, EvMRev: 0x04, SensorType: 0x08, SensorNumber: 0x30,
+				EventType: EventReadingTypeSensorSpecific, Deassertion: true, EventData: [3]uint8{0x03, 0xff, 0xff},
+			},
+			"Power Supply AC Lost",
+		},
+		{
+			// Timestamped OEM record
+			[]byte{0x03, 0x00, 0xc1, 0x00, 0x5e, 0x2e, 0x67, 0x57, 0x01, 0x00, 1, 2, 3, 4, 5, 6},
+			SELEntry{
+				RecordID: 3, RecordType: 0xc1, Timestamp: 0x672e5e00,
+				ManufacturerID: 343, OEMData: []byte{1, 2, 3, 4, 5, 6},
+			},
+			"OEM record type 0xc1",
+		},
+		{
+			// Non-timestamped OEM record
+			[]byte{0x04, 0x00, 0xe0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13},
+			SELEntry{
+				RecordID: 4, RecordType: 0xe0, Timestamp: selTimestampUnspecified,
+				OEMData: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13},
+			},
+			"OEM record type 0xe0",
+		},
+	}
+
+	for _, tt := range tests {
+		e, err := decodeSELEntry(tt.record)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(*e, tt.want) {
+			t.Errorf("record %d: decoded %+v, expected %+v", tt.want.RecordID, e, tt.want)
+		}
+
+		if desc := e.Description(); desc != tt.desc {
+			t.Errorf("record %d: description %q, expected %q", tt.want.RecordID, desc, tt.desc)
+		}
+	}
+
+	if _, err := decodeSELEntry(make([]byte, 15)); err == nil {
+		t.Error("short SEL record decoded")
+	}
+}
+
+func TestSELTime(t *testing.T) {
+	tests := []struct {
+		ts uint32
+		ok bool
+	}{
+		{0x00000010, false},
+		{selTimestampPreInitMax, false},
+		{selTimestampUnspecified, false},
+		{0x672e5e00, true},
+	}
+
+	for _, tt := range tests {
+		tm, ok := SELTime(tt.ts)
+		if ok != tt.ok || (ok && tm.Unix() != int64(tt.ts)) {
+			t.Errorf("timestamp %#x: got %v, %v", tt.ts, tm, ok)
+		}
+	}
+}
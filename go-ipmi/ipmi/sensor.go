@@ -0,0 +1,459 @@
+package ipmi
+
+// Sensor reading and conversion to engineering units per sections 35 and 36
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ErrSensorOwner is returned when reading a sensor owned by system software, or by a controller
+// other than the target of a bridged client, which cannot be reached to read it
+var ErrSensorOwner = errors.New("sensor owner not reachable")
+
+// Event / reading type codes per table 42-1
+const (
+	EventReadingTypeThreshold      = 0x01
+	EventReadingTypeSensorSpecific = 0x6f
+)
+
+// Analog data formats in sensor units 1 field
+const (
+	analogFormatUnsigned     = 0x00
+	analogFormatOnesCompl    = 0x01
+	analogFormatTwosCompl    = 0x02
+	analogFormatNoAnalog     = 0x03
+	linearizationNonLinear   = 0x70 // 0x70 - 0x7f
+	sensorFlagEventsDisabled = 0x80
+	sensorFlagScanDisabled   = 0x40
+	sensorFlagUnavailable    = 0x20
+)
+
+// Linearization functions per table 43-1, byte 24
+const (
+	LinearizationLinear = iota
+	LinearizationLn
+	LinearizationLog10
+	LinearizationLog2
+	LinearizationE
+	LinearizationExp10
+	LinearizationExp2
+	LinearizationInverse
+	LinearizationSqr
+	LinearizationCube
+	LinearizationSqrt
+	LinearizationCubeRoot
+)
+
+// Threshold comparison status bits per section 35.14
+const (
+	ThresholdLowerNonCritical    = 1 << 0
+	ThresholdLowerCritical       = 1 << 1
+	ThresholdLowerNonRecoverable = 1 << 2
+	ThresholdUpperNonCritical    = 1 << 3
+	ThresholdUpperCritical       = 1 << 4
+	ThresholdUpperNonRecoverable = 1 << 5
+)
+
+// Sensor unit type codes per table 43-15
+var sensorUnits = []string{
+	"unspecified", "degrees C", "degrees F", "degrees K", "Volts", "Amps", "Watts", "Joules",
+	"Coulombs", "VA", "Nits", "lumen", "lux", "Candela", "kPa", "PSI", "Newton", "CFM", "RPM",
+	"Hz", "microsecond", "millisecond", "second", "minute", "hour", "day", "week", "mil",
+	"inches", "feet", "cu in", "cu feet", "mm", "cm", "m", "cu cm", "cu m", "liters",
+	"fluid ounce", "radians", "steradians", "revolutions", "cycles", "gravities", "ounce",
+	"pound", "ft-lb", "oz-in", "gauss", "gilberts", "henry", "millihenry", "farad",
+	"microfarad", "ohms", "siemens", "mole", "becquerel", "PPM", "reserved", "Decibels", "DbA",
+	"DbC", "gray", "sievert", "color temp deg K", "bit", "kilobit", "megabit", "gigabit", "byte",
+	"kilobyte", "megabyte", "gigabyte", "word", "dword", "qword", "line", "hit", "miss", "retry",
+	"reset", "overrun / overflow", "underrun", "collision", "packets", "messages", "characters",
+	"error", "correctable error", "uncorrectable error", "fatal error", "grams",
+}
+
+// Sensor type codes per table 42-3
+var sensorTypes = map[uint8]string{
+	0x01: "Temperature",
+	0x02: "Voltage",
+	0x03: "Current",
+	0x04: "Fan",
+	0x05: "Physical Security",
+	0x06: "Platform Security",
+	0x07: "Processor",
+	0x08: "Power Supply",
+	0x09: "Power Unit",
+	0x0a: "Cooling Device",
+	0x0b: "Other Units-based Sensor",
+	0x0c: "Memory",
+	0x0d: "Drive Slot (Bay)",
+	0x0e: "POST Memory Resize",
+	0x0f: "System Firmware Progress",
+	0x10: "Event Logging Disabled",
+	0x11: "Watchdog 1",
+	0x12: "System Event",
+	0x13: "Critical Interrupt",
+	0x14: "Button / Switch",
+	0x15: "Module / Board",
+	0x16: "Microcontroller / Coprocessor",
+	0x17: "Add-in Card",
+	0x18: "Chassis",
+	0x19: "Chip Set",
+	0x1a: "Other FRU",
+	0x1b: "Cable / Interconnect",
+	0x1c: "Terminator",
+	0x1d: "System Boot / Restart Initiated",
+	0x1e: "Boot Error",
+	0x1f: "Base OS Boot / Installation Status",
+	0x20: "OS Stop / Shutdown",
+	0x21: "Slot / Connector",
+	0x22: "System ACPI Power State",
+	0x23: "Watchdog 2",
+	0x24: "Platform Alert",
+	0x25: "Entity Presence",
+	0x26: "Monitor ASIC / IC",
+	0x27: "LAN",
+	0x28: "Management Subsystem Health",
+	0x29: "Battery",
+	0x2a: "Session Audit",
+	0x2b: "Version Change",
+	0x2c: "FRU State",
+}
+
+// Rate units in sensor units 1 field
+var sensorRateUnits = []string{
+	"", "per microsecond", "per millisecond", "per second", "per minute", "per hour", "per day",
+}
+
+// SensorReading is the response to Get Sensor Reading per section 35.14
+type SensorReading struct {
+	Raw                   uint8
+	EventMessagesDisabled bool
+	ScanningDisabled      bool
+	Unavailable           bool
+	States                uint16 // Threshold comparison status, or asserted discrete state offsets
+}
+
+func (r *SensorReading) MarshalBinary() ([]byte, error) {
+	flags := bit(!r.EventMessagesDisabled, sensorFlagEventsDisabled) |
+		bit(!r.ScanningDisabled, sensorFlagScanDisabled) | bit(r.Unavailable, sensorFlagUnavailable)
+
+	return []byte{r.Raw, flags, uint8(r.States), uint8(r.States>>8) & 0x7f}, nil
+}
+
+func (r *SensorReading) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.Raw = d.uint8()
+	flags := d.uint8()
+	r.EventMessagesDisabled = flags&sensorFlagEventsDisabled == 0
+	r.ScanningDisabled = flags&sensorFlagScanDisabled == 0
+	r.Unavailable = flags&sensorFlagUnavailable != 0
+	r.States = 0
+
+	// State bytes are optional for some sensors
+	if d.more() {
+		r.States = uint16(d.uint8())
+	}
+
+	if d.more() {
+		r.States |= uint16(d.uint8()&0x7f) << 8
+	}
+
+	return d.err
+}
+
+// SensorReadingFactors per section 35.5
+type SensorReadingFactors struct {
+	M    int16
+	B    int16
+	BExp int8 // K1
+	RExp int8 // K2
+}
+
+// GetSensorReadingFactorsResponse per section 35.5. Factors are laid out as per bytes 25 - 30 of
+// the full sensor record, of which tolerance and accuracy are not decoded.
+type GetSensorReadingFactorsResponse struct {
+	NextReading uint8 // Next raw reading for which the factors differ
+	Factors     SensorReadingFactors
+}
+
+func (r *GetSensorReadingFactorsResponse) MarshalBinary() ([]byte, error) {
+	f := &r.Factors
+
+	return []byte{
+		r.NextReading,
+		uint8(f.M), uint8(f.M>>8&0x03) << 6,
+		uint8(f.B), uint8(f.B>>8&0x03) << 6,
+		0,
+		uint8(f.RExp)<<4 | uint8(f.BExp)&0x0f,
+	}, nil
+}
+
+func (r *GetSensorReadingFactorsResponse) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.NextReading = d.uint8()
+
+	var f [6]byte
+	d.bytes(f[:])
+
+	r.Factors = SensorReadingFactors{
+		M:    signExtend(uint16(f[0])|uint16(f[1]&0xc0)<<2, 10),
+		B:    signExtend(uint16(f[2])|uint16(f[3]&0xc0)<<2, 10),
+		RExp: int8(signExtend(uint16(f[5]>>4), 4)),
+		BExp: int8(signExtend(uint16(f[5]&0x0f), 4)),
+	}
+
+	return d.err
+}
+
+// SensorValue is a sensor reading, converted according to the sensor's SDR record
+type SensorValue struct {
+	SensorKey
+	Name             string
+	SensorType       uint8
+	EventReadingType uint8
+	Reading          *SensorReading
+	Analog           bool    // Sensor has an analog reading
+	Value            float64 // Converted reading, for analog sensors
+	Units            string
+}
+
+// Threshold returns whether the sensor is threshold-based, in which case the reading states are
+// threshold comparison status bits.
+func (v *SensorValue) Threshold() bool {
+	return v.EventReadingType == EventReadingTypeThreshold
+}
+
+// ThresholdStatus returns the most severe threshold crossed by the reading, using the
+// abbreviations familiar from ipmitool.
+func (v *SensorValue) ThresholdStatus() string {
+	switch s := v.Reading.States; {
+	case v.Reading.Unavailable || v.Reading.ScanningDisabled:
+		return "na"
+	case s&ThresholdUpperNonRecoverable != 0:
+		return "nr"
+	case s&ThresholdLowerNonRecoverable != 0:
+		return "nr"
+	case s&ThresholdUpperCritical != 0:
+		return "cr"
+	case s&ThresholdLowerCritical != 0:
+		return "cr"
+	case s&ThresholdUpperNonCritical != 0:
+		return "nc"
+	case s&ThresholdLowerNonCritical != 0:
+		return "nc"
+	}
+	return "ok"
+}
+
+// SensorTypeName returns the name of a sensor type code
+func SensorTypeName(t uint8) string {
+	if s, ok := sensorTypes[t]; ok {
+		return s
+	}
+	if t >= 0xc0 {
+		return "OEM reserved"
+	}
+	return fmt.Sprintf("Sensor type 0x%02x", t)
+}
+
+// AssertedStates returns the offsets of the asserted states of a discrete sensor
+func (v *SensorValue) AssertedStates() []uint8 {
+	var offsets []uint8
+	for i := uint8(0); i < 15; i++ {
+		if v.Reading.States&(1<<i) != 0 {
+			offsets = append(offsets, i)
+		}
+	}
+	return offsets
+}
+
+// getSensorReading reads the current value of a sensor of LUN lun
+func (c *conn) getSensorReading(lun, sensorNumber uint8) (*SensorReading, error) {
+	resp := &SensorReading{}
+
+	if err := c.sendLUN(lun, NetFnSensorEvent, CmdGetSensorReading, rawRequest{sensorNumber}, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// getSensorReadingFactors returns the conversion factors of a non-linear sensor of LUN lun for a
+// particular raw reading.
+func (c *conn) getSensorReadingFactors(lun, sensorNumber, raw uint8) (*SensorReadingFactors, error) {
+	resp := &GetSensorReadingFactorsResponse{}
+
+	req := rawRequest{sensorNumber, raw}
+	if err := c.sendLUN(lun, NetFnSensorEvent, CmdGetSensorReadingFactors, req, resp); err != nil {
+		return nil, err
+	}
+
+	return &resp.Factors, nil
+}
+
+// sensorOwner returns a conn to the controller owning a sensor described by the SDR repository of
+// c. Sensors of satellite controllers in the repository of the BMC are read by bridging to them,
+// while a bridged conn can only read the sensors of its own target.
+func (c *conn) sensorOwner(key SensorKey) (*conn, error) {
+	if b, ok := c.Transport.(*bridge); ok {
+		if key.OwnerID != b.target.Addr {
+			return nil, fmt.Errorf("sensor 0x%02x owned by 0x%02x, not bridged target 0x%02x: %w",
+				key.SensorNumber, key.OwnerID, b.target.Addr, ErrSensorOwner)
+		}
+		return c, nil
+	}
+
+	switch {
+	case key.OwnerID&0x01 != 0:
+		return nil, fmt.Errorf("sensor 0x%02x owned by system software ID 0x%02x: %w",
+			key.SensorNumber, key.OwnerID, ErrSensorOwner)
+	case key.OwnerID == bmcSlaveAddr && key.Channel == ChannelIPMB:
+		return c, nil
+	}
+
+	return &conn{newBridge(c.Transport, Target{Channel: key.Channel, Addr: key.OwnerID})}, nil
+}
+
+// readSensor reads a sensor described by a full or compact SDR record, from the LUN of the
+// controller owning it
+func (c *conn) readSensor(rec SDRRecord) (*SensorValue, error) {
+	v := &SensorValue{}
+
+	var full *FullSensorRecord
+
+	switch r := rec.(type) {
+	case *FullSensorRecord:
+		full = r
+		v.SensorKey, v.Name, v.SensorType, v.EventReadingType = r.SensorKey, r.IDString, r.SensorType, r.EventReadingType
+		v.Units = unitString(r.Units1, r.BaseUnit, r.ModifierUnit)
+	case *CompactSensorRecord:
+		v.SensorKey, v.Name, v.SensorType, v.EventReadingType = r.SensorKey, r.IDString, r.SensorType, r.EventReadingType
+		v.Units = unitString(r.Units1, r.BaseUnit, r.ModifierUnit)
+	default:
+		return nil, fmt.Errorf("SDR record type %#x has no sensor reading", rec.Header().Type)
+	}
+
+	owner, err := c.sensorOwner(v.SensorKey)
+	if err != nil {
+		return nil, err
+	}
+
+	reading, err := owner.getSensorReading(v.OwnerLUN, v.SensorNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reserved bits of the threshold comparison status are returned as ones
+	if v.Threshold() {
+		reading.States &= 0x3f
+	}
+
+	v.Reading = reading
+
+	if full == nil || full.Units1>>6 == analogFormatNoAnalog || reading.Unavailable {
+		return v, nil
+	}
+
+	factors := SensorReadingFactors{full.M, full.B, full.BExp, full.RExp}
+
+	if full.Linearization >= linearizationNonLinear {
+		f, err := owner.getSensorReadingFactors(v.OwnerLUN, v.SensorNumber, reading.Raw)
+		if err != nil {
+			return nil, err
+		}
+		factors = *f
+	}
+
+	v.Analog = true
+	v.Value = convertReading(reading.Raw, full.Units1>>6, factors, full.Linearization)
+
+	return v, nil
+}
+
+// ConvertReading converts a raw reading to engineering units using the record's conversion
+// factors. Readings of non-linear sensors require factors from Get Sensor Reading Factors.
+func (r *FullSensorRecord) ConvertReading(raw uint8) float64 {
+	return convertReading(raw, r.Units1>>6, SensorReadingFactors{r.M, r.B, r.BExp, r.RExp}, r.Linearization)
+}
+
+// Units returns a description of the sensor's units, e.g. "degrees C" or "RPM"
+func (r *FullSensorRecord) Units() string {
+	return unitString(r.Units1, r.BaseUnit, r.ModifierUnit)
+}
+
+// convertReading applies the formula y = L[(M*x + B * 10^K1) * 10^K2] per section 36.3
+func convertReading(raw, format uint8, f SensorReadingFactors, linearization uint8) float64 {
+	var x float64
+
+	switch format {
+	case analogFormatOnesCompl:
+		v := int8(raw)
+		if v < 0 {
+			v++
+		}
+		x = float64(v)
+	case analogFormatTwosCompl:
+		x = float64(int8(raw))
+	default:
+		x = float64(raw)
+	}
+
+	y := (float64(f.M)*x + float64(f.B)*math.Pow10(int(f.BExp))) * math.Pow10(int(f.RExp))
+
+	switch linearization {
+	case LinearizationLn:
+		return math.Log(y)
+	case LinearizationLog10:
+		return math.Log10(y)
+	case LinearizationLog2:
+		return math.Log2(y)
+	case LinearizationE:
+		return math.Exp(y)
+	case LinearizationExp10:
+		return math.Pow(10, y)
+	case LinearizationExp2:
+		return math.Exp2(y)
+	case LinearizationInverse:
+		return 1 / y
+	case LinearizationSqr:
+		return y * y
+	case LinearizationCube:
+		return y * y * y
+	case LinearizationSqrt:
+		return math.Sqrt(y)
+	case LinearizationCubeRoot:
+		return math.Cbrt(y)
+	}
+
+	return y
+}
+
+// unitString describes sensor units from the three units fields of an SDR record
+func unitString(units1, base, modifier uint8) string {
+	s := sensorUnitName(base)
+
+	switch (units1 >> 1) & 0x03 {
+	case 0x01:
+		s += "/" + sensorUnitName(modifier)
+	case 0x02:
+		s += "*" + sensorUnitName(modifier)
+	}
+
+	if rate := (units1 >> 3) & 0x07; int(rate) < len(sensorRateUnits) && rate != 0 {
+		s += " " + sensorRateUnits[rate]
+	}
+
+	if units1&0x01 != 0 {
+		s = strings.TrimSpace("% " + s)
+	}
+
+	return s
+}
+
+func sensorUnitName(u uint8) string {
+	if int(u) < len(sensorUnits) {
+		return sensorUnits[u]
+	}
+	return fmt.Sprintf("unit %#x", u)
+}
@@ -0,0 +1,82 @@
+package ipmi_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+// Sensors sharing the number of the default profile's CPU Temp, owned by LUN 1 of the BMC and by
+// the node manager
+func newSensorOwnerProfile() *ipmisim.Profile {
+	profile := ipmisim.DefaultProfile()
+
+	temp := ipmisim.Sensor{
+		Number: 0x01, Type: 0x01, EventReadingType: ipmi.EventReadingTypeThreshold, Unit: 1, M: 1,
+	}
+
+	lun1 := temp
+	lun1.Name, lun1.LUN, lun1.Readings = "LUN1 Temp", 1, []uint8{70}
+	profile.Sensors = append(profile.Sensors, lun1)
+
+	nm := temp
+	nm.Name, nm.LUN, nm.Readings = "NM Inlet Temp", 2, []uint8{25}
+	profile.Satellites[0].Sensors = []ipmisim.Sensor{nm}
+
+	return profile
+}
+
+func TestSensorOwners(t *testing.T) {
+	_, addr := newTestSimulator(t, newSensorOwnerProfile())
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("user", "user", ipmi.PrivLevelUser); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := c.ReadSDRRepository()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]struct {
+		key   ipmi.SensorKey
+		value float64
+	}{
+		"CPU Temp":      {ipmi.SensorKey{OwnerID: 0x20, SensorNumber: 0x01}, 45},
+		"LUN1 Temp":     {ipmi.SensorKey{OwnerID: 0x20, OwnerLUN: 1, SensorNumber: 0x01}, 70},
+		"NM Inlet Temp": {ipmi.SensorKey{OwnerID: 0x2c, Channel: ipmi.ChannelSecondaryIPMB, OwnerLUN: 2, SensorNumber: 0x01}, 25},
+	}
+
+	found := 0
+	for _, rec := range records {
+		v, err := c.ReadSensor(rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if w, ok := want[v.Name]; ok {
+			found++
+			if v.SensorKey != w.key || v.Value != w.value {
+				t.Errorf("sensor %q: expected key %+v value %v, got %+v value %v", v.Name, w.key, w.value, v.SensorKey, v.Value)
+			}
+		}
+	}
+
+	if found != len(want) {
+		t.Errorf("found %d of %d sensors", found, len(want))
+	}
+
+	// A bridged client only reads the sensors of its own target
+	nm := c.Bridge(testNodeManager)
+	for _, rec := range records {
+		if r, ok := rec.(*ipmi.FullSensorRecord); ok && r.OwnerID == 0x20 {
+			if _, err := nm.ReadSensor(rec); !errors.Is(err, ipmi.ErrSensorOwner) {
+				t.Errorf("sensor %q: expected sensor owner error, got %v", r.IDString, err)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package ipmi
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvertReading(t *testing.T) {
+	tests := []struct {
+		raw           uint8
+		format        uint8
+		factors       SensorReadingFactors
+		linearization uint8
+		want          float64
+	}{
+		// Temperature, M = 1, B = 0
+		{0x2d, analogFormatUnsigned, SensorReadingFactors{1, 0, 0, 0}, LinearizationLinear, 45},
+		// Fan, M = 75, K2 = 0 -> 75 RPM per count
+		{0x40, analogFormatUnsigned, SensorReadingFactors{75, 0, 0, 0}, LinearizationLinear, 4800},
+		// Voltage, M = 59, K2 = -4
+		{0xc9, analogFormatUnsigned, SensorReadingFactors{59, 0, 0, -4}, LinearizationLinear, 1.1859},
+		// Offset B * 10^K1 = -50
+		{0x64, analogFormatUnsigned, SensorReadingFactors{1, -5, 1, 0}, LinearizationLinear, 50},
+		// Signed readings
+		{0xfe, analogFormatTwosCompl, SensorReadingFactors{1, 0, 0, 0}, LinearizationLinear, -2},
+		{0xfe, analogFormatOnesCompl, SensorReadingFactors{1, 0, 0, 0}, LinearizationLinear, -1},
+		// Non-linear
+		{0x04, analogFormatUnsigned, SensorReadingFactors{1, 0, 0, 0}, LinearizationSqr, 16},
+		{0x04, analogFormatUnsigned, SensorReadingFactors{1, 0, 0, 0}, LinearizationInverse, 0.25},
+	}
+
+	for _, tt := range tests {
+		got := convertReading(tt.raw, tt.format, tt.factors, tt.linearization)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("convertReading(%#x, %+v) = %f, want %f", tt.raw, tt.factors, got, tt.want)
+		}
+	}
+}
+
+func TestUnitString(t *testing.T) {
+	tests := []struct {
+		units1, base, modifier uint8
+		want                   string
+	}{
+		{0x00, 1, 0, "degrees C"},
+		{0x00, 18, 0, "RPM"},
+		{0x18, 41, 0, "revolutions per second"},
+		{0x02, 4, 22, "Volts/second"},
+		{0x01, 0, 0, "% unspecified"},
+	}
+
+	for _, tt := range tests {
+		if got := unitString(tt.units1, tt.base, tt.modifier); got != tt.want {
+			t.Errorf("unitString(%#x, %d, %d) = %q, want %q", tt.units1, tt.base, tt.modifier, got, tt.want)
+		}
+	}
+}
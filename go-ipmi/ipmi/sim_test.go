@@ -0,0 +1,73 @@
+package ipmi_test
+
+import (
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+// newTestSimulator starts a simulator on a local UDP socket, returning its address
+func newTestSimulator(t *testing.T, profile *ipmisim.Profile) (*ipmisim.Simulator, string) {
+	pc, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim := ipmisim.New(profile)
+	go sim.Serve(pc)
+	t.Cleanup(func() { sim.Close() })
+
+	return sim, pc.LocalAddr().String()
+}
+
+// dialSimulator connects a client to the simulator, with short timeouts
+func dialSimulator(t *testing.T, addr string) *ipmi.Client {
+	c, err := ipmi.Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.SetTimeout(50*time.Millisecond, 2)
+	t.Cleanup(func() { c.Close() })
+
+	return c
+}
+
+// checkSensors reads all sensors in the default profile and checks their first readings
+func checkSensors(t *testing.T, c *ipmi.Client) {
+	records, err := c.ReadSDRRepository()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != len(ipmisim.DefaultProfile().Sensors) {
+		t.Fatalf("read %d SDR records", len(records))
+	}
+
+	values := make(map[string]*ipmi.SensorValue)
+
+	for _, rec := range records {
+		v, err := c.ReadSensor(rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values[v.Name] = v
+	}
+
+	for name, want := range map[string]float64{"CPU Temp": 45, "Fan 1": 4800, "12V": 12} {
+		v := values[name]
+		if v == nil || !v.Analog || math.Abs(v.Value-want) > 1e-9 {
+			t.Errorf("sensor %q: unexpected value %+v", name, v)
+		} else if v.ThresholdStatus() != "ok" {
+			t.Errorf("sensor %q: unexpected status %s", name, v.ThresholdStatus())
+		}
+	}
+
+	if v := values["PS1 Status"]; v == nil || v.Analog || v.Reading.States != 0x0001 {
+		t.Errorf("sensor \"PS1 Status\": unexpected reading %+v", v)
+	}
+}
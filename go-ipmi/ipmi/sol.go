@@ -0,0 +1,525 @@
+package ipmi
+
+// Serial over LAN per section 15, carried as a payload of an RMCP+ session. Payloads are activated
+// and deactivated per section 24.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// SOL operation bits sent by the remote console per table 15-2
+const (
+	solNACK            = 0x40 // Packet could not be accepted, in operation or status
+	solOpRingWOR       = 0x20
+	solOpBreak         = 0x10
+	solOpCTSPause      = 0x08 // Deassert CTS to the baseboard serial controller
+	solOpDropDCDDSR    = 0x04
+	solOpFlushInbound  = 0x02
+	solOpFlushOutbound = 0x01
+)
+
+// SOL status bits sent by the BMC per table 15-2
+const (
+	solStatusUnavailable  = 0x20 // Character transfer unavailable
+	solStatusDeactivating = 0x10
+	solStatusOverrun      = 0x08 // Characters dropped by the BMC
+	solStatusBreak        = 0x04 // Break detected on the serial port
+)
+
+// Completion codes specific to Activate Payload and Deactivate Payload
+var (
+	ErrPayloadActive       = commandCode(0x80, NetFnApp, CmdActivatePayload) // Already active on another session
+	ErrPayloadDeactivated  = commandCode(0x80, NetFnApp, CmdDeactivatePayload)
+	ErrPayloadDisabled     = commandCode(0x81, NetFnApp, CmdActivatePayload, CmdDeactivatePayload)
+	ErrPayloadLimit        = commandCode(0x82, NetFnApp, CmdActivatePayload) // Payload activation limit reached
+	ErrPayloadEncryption   = commandCode(0x83, NetFnApp, CmdActivatePayload) // Cannot activate payload with encryption
+	ErrPayloadNoEncryption = commandCode(0x84, NetFnApp, CmdActivatePayload) // Cannot activate payload without encryption
+)
+
+const (
+	solHeaderSize        = 4
+	solMaxSequence       = 0x0f
+	solAuxEncryption     = 0x80
+	solAuxAuthentication = 0x40
+	solAuxAlertsDeferred = 0x04 // Serial alerts are deferred while SOL is active
+	solNACKDelay         = 100 * time.Millisecond
+	solKeepalive         = 30 * time.Second
+	solWriteBuffer       = 4096 // Characters buffered by Write before blocking
+	solRecvQueue         = 64   // Packets received but not yet read, before NACKing the BMC
+
+	// DefaultSOLInstance is the payload instance of the first serial port
+	DefaultSOLInstance = 1
+)
+
+var (
+	ErrSOLDeactivated = errors.New("SOL payload deactivated by BMC")
+	errSOLClosed      = errors.New("SOL payload closed")
+)
+
+// SOL is an active Serial over LAN payload, carrying characters to and from a serial port of the
+// managed system. Characters written are accumulated while a packet is awaiting acknowledgement,
+// and sent in the next packet. Read and Write may be called concurrently with each other.
+type SOL struct {
+	l        *lanConnection
+	instance uint8
+	maxData  int // Maximum characters per packet sent to the BMC
+
+	packets chan []byte   // SOL payloads received from the BMC
+	recv    chan []byte   // Characters received from the BMC, closed when the payload ends
+	rbuf    []byte        // Characters received but not yet read
+	kick    chan struct{} // Signals characters or operations to send
+	done    chan struct{} // Closed when the payload ends
+	stopped chan struct{} // Closed when the run loop exits
+	once    sync.Once
+
+	mu      sync.Mutex
+	cond    *sync.Cond // Signals space in wbuf, or the end of the payload
+	wbuf    []byte     // Characters not yet sent
+	ops     uint8      // One-shot operations for the next packet
+	control uint8      // CTS and DCD/DSR operation bits, sent in every packet
+	err     error      // Error that ended the payload
+
+	// Protocol state, owned by the run loop
+	outSeq      uint8     // Sequence number of packet awaiting acknowledgement, or zero
+	outPayload  []byte    // Packet awaiting acknowledgement
+	outAttempts int       // Number of times outPayload has been sent without a NACK
+	resendAt    time.Time // Time to resend outPayload if not acknowledged
+	controlSent uint8     // Control bits most recently sent
+	lastSeq     uint8     // Sequence number of last packet sent
+	lastInSeq   uint8     // Sequence number of last packet accepted from the BMC
+}
+
+// activateSOL activates the SOL payload on the current RMCP+ session
+func (l *lanConnection) activateSOL(instance uint8) (*SOL, error) {
+	l.mu.Lock()
+	if l.version != IPMIVersion20 || l.sessionID == 0 {
+		l.mu.Unlock()
+		return nil, errors.New("SOL requires an active RMCP+ session")
+	}
+
+	if _, ok := l.payloads[payloadTypeSOL]; ok {
+		l.mu.Unlock()
+		return nil, errors.New("SOL payload already active on this session")
+	}
+
+	s := &SOL{
+		l:        l,
+		instance: instance,
+		packets:  make(chan []byte, solRecvQueue),
+		recv:     make(chan []byte, solRecvQueue),
+		kick:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.mu)
+
+	// Register before activating, since the BMC may send characters before its response
+	l.payloads[payloadTypeSOL] = s.packets
+
+	// Encryption and authentication follow the session's cipher suite
+	aux := uint8(solAuxAlertsDeferred)
+	if l.suite.Confidentiality != confNone {
+		aux |= solAuxEncryption
+	}
+	if l.suite.Integrity != integrityNone {
+		aux |= solAuxAuthentication
+	}
+	l.mu.Unlock()
+
+	if err := s.activate(aux); err != nil {
+		l.mu.Lock()
+		delete(l.payloads, payloadTypeSOL)
+		l.mu.Unlock()
+		return nil, err
+	}
+
+	go s.run()
+	go s.keepalive()
+
+	return s, nil
+}
+
+// activate sends the Activate Payload request, checking the payload size and port
+func (s *SOL) activate(aux uint8) error {
+	req := Request{
+		NetworkFunction: NetFnApp,
+		Command:         CmdActivatePayload,
+		Data:            []byte{payloadTypeSOL, s.instance, aux, 0, 0, 0},
+	}
+
+	data, err := s.l.SendRecv(req)
+	if err != nil {
+		return err
+	}
+
+	// Auxiliary data, inbound and outbound payload sizes, UDP port and VLAN
+	if len(data) < 13 {
+		s.l.deactivatePayload(payloadTypeSOL, s.instance)
+		return ErrShortData
+	}
+
+	s.maxData = int(binary.LittleEndian.Uint16(data[5:7])) - solHeaderSize
+	if s.maxData > 0xff {
+		s.maxData = 0xff
+	} else if s.maxData < 1 {
+		s.maxData = 1
+	}
+
+	// Payloads on a port other than the session's are not supported
+	port := int(binary.LittleEndian.Uint16(data[9:11]))
+	if addr, ok := s.l.conn.RemoteAddr().(*net.UDPAddr); ok && addr.Port != port {
+		s.l.deactivatePayload(payloadTypeSOL, s.instance)
+		return fmt.Errorf("SOL payload on UDP port %d not supported", port)
+	}
+
+	return nil
+}
+
+// deactivatePayload deactivates a payload instance on the current session
+func (l *lanConnection) deactivatePayload(payloadType, instance uint8) error {
+	_, err := l.SendRecv(Request{
+		NetworkFunction: NetFnApp,
+		Command:         CmdDeactivatePayload,
+		Data:            []byte{payloadType, instance, 0, 0, 0, 0},
+	})
+	return err
+}
+
+// Read reads characters sent by the managed system. It returns io.EOF once the payload has been
+// closed, or the error that ended the payload.
+func (s *SOL) Read(p []byte) (int, error) {
+	for len(s.rbuf) == 0 {
+		b, ok := <-s.recv
+		if !ok {
+			s.mu.Lock()
+			err := s.err
+			s.mu.Unlock()
+
+			if err == errSOLClosed {
+				err = io.EOF
+			}
+			return 0, err
+		}
+		s.rbuf = b
+	}
+
+	n := copy(p, s.rbuf)
+	s.rbuf = s.rbuf[n:]
+
+	return n, nil
+}
+
+// Write sends characters to the managed system, blocking while too many are waiting to be sent
+func (s *SOL) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+
+	for n < len(p) {
+		for len(s.wbuf) >= solWriteBuffer && s.err == nil {
+			s.cond.Wait()
+		}
+
+		if s.err != nil {
+			return n, s.err
+		}
+
+		m := len(p) - n
+		if m > solWriteBuffer-len(s.wbuf) {
+			m = solWriteBuffer - len(s.wbuf)
+		}
+
+		s.wbuf = append(s.wbuf, p[n:n+m]...)
+		n += m
+		s.signal()
+	}
+
+	return n, nil
+}
+
+// SendBreak generates a break condition on the serial port
+func (s *SOL) SendBreak() {
+	s.setOps(solOpBreak, 0, 0)
+}
+
+// PauseCTS deasserts CTS to the baseboard serial controller while pause is true, pausing its
+// transmission
+func (s *SOL) PauseCTS(pause bool) {
+	s.setControl(solOpCTSPause, pause)
+}
+
+// DropDCD deasserts DCD and DSR to the baseboard serial controller while drop is true
+func (s *SOL) DropDCD(drop bool) {
+	s.setControl(solOpDropDCDDSR, drop)
+}
+
+// setControl sets or clears a control bit sent in every packet
+func (s *SOL) setControl(bit uint8, set bool) {
+	if set {
+		s.setOps(0, bit, 0)
+	} else {
+		s.setOps(0, 0, bit)
+	}
+}
+
+// setOps adds one-shot operations, and sets and clears control bits for the next packet
+func (s *SOL) setOps(ops, set, clear uint8) {
+	s.mu.Lock()
+	s.ops |= ops
+	s.control = s.control&^clear | set
+	s.mu.Unlock()
+
+	s.signal()
+}
+
+// signal wakes the run loop to send pending characters or operations
+func (s *SOL) signal() {
+	select {
+	case s.kick <- struct{}{}:
+	default:
+	}
+}
+
+// Close deactivates the payload
+func (s *SOL) Close() error {
+	s.stop(errSOLClosed)
+	<-s.stopped
+
+	s.l.mu.Lock()
+	delete(s.l.payloads, payloadTypeSOL)
+	s.l.mu.Unlock()
+
+	// Payload has already been deactivated if the BMC ended it
+	if err := s.l.deactivatePayload(payloadTypeSOL, s.instance); err != nil && !errors.Is(err, ErrPayloadDeactivated) {
+		return err
+	}
+
+	return nil
+}
+
+// stop ends the payload with err, waking any blocked writers
+func (s *SOL) stop(err error) {
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.err = err
+		s.cond.Broadcast()
+		s.mu.Unlock()
+
+		close(s.done)
+	})
+}
+
+// keepalive sends a request periodically while the payload is active, since BMCs may time out
+// sessions in which no IPMI requests are received
+func (s *SOL) keepalive() {
+	ticker := time.NewTicker(solKeepalive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if _, err := s.l.SendRecv(Request{NetworkFunction: NetFnApp, Command: CmdGetDeviceID}); err != nil {
+				s.stop(fmt.Errorf("SOL keepalive: %w", err))
+				return
+			}
+		}
+	}
+}
+
+// run sends and acknowledges SOL packets until the payload ends. Only one packet sent to the BMC
+// is awaiting acknowledgement at any time.
+func (s *SOL) run() {
+	defer close(s.stopped)
+	defer close(s.recv)
+
+	// Retransmissions are checked several times per timeout interval
+	timeout, _ := s.l.timing()
+	interval := timeout / 4
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var err error
+
+		select {
+		case <-s.done:
+			return
+		case p := <-s.packets:
+			err = s.handlePacket(p)
+		case <-s.kick:
+		case <-ticker.C:
+			err = s.retransmit()
+		}
+
+		if err == nil && s.outSeq == 0 {
+			err = s.sendNext()
+		}
+
+		if err != nil {
+			s.stop(err)
+			return
+		}
+	}
+}
+
+// handlePacket processes a SOL packet received from the BMC, acknowledging its characters and
+// any acknowledgement of the packet awaiting it
+func (s *SOL) handlePacket(p []byte) error {
+	if len(p) < solHeaderSize {
+		return nil
+	}
+
+	seq, ackSeq, count, status := p[0]&solMaxSequence, p[1]&solMaxSequence, int(p[2]), p[3]
+	data := p[solHeaderSize:]
+
+	if ackSeq != 0 && ackSeq == s.outSeq {
+		if status&(solNACK|solStatusUnavailable) != 0 {
+			// BMC cannot accept characters at present; resend later
+			s.resendAt = time.Now().Add(solNACKDelay)
+			s.outAttempts = 0
+		} else {
+			s.acknowledged(count)
+		}
+	}
+
+	if status&solStatusDeactivating != 0 {
+		return ErrSOLDeactivated
+	}
+
+	// Packets with sequence number zero are acknowledgements only
+	if seq == 0 {
+		return nil
+	}
+
+	// A repeated sequence number is a retransmission, after our acknowledgement was lost
+	if seq == s.lastInSeq {
+		return s.sendAck(seq, len(data), false)
+	}
+
+	if len(data) > 0 {
+		select {
+		case s.recv <- append([]byte(nil), data...):
+		default:
+			// Reader is not keeping up, so the BMC must retransmit later
+			return s.sendAck(seq, 0, true)
+		}
+	}
+
+	s.lastInSeq = seq
+
+	return s.sendAck(seq, len(data), false)
+}
+
+// acknowledged completes the packet awaiting acknowledgement. Characters not accepted by the BMC
+// are sent again in the next packet.
+func (s *SOL) acknowledged(count int) {
+	if sent := s.outPayload[solHeaderSize:]; count < len(sent) {
+		s.mu.Lock()
+		s.wbuf = append(append([]byte(nil), sent[count:]...), s.wbuf...)
+		s.mu.Unlock()
+	}
+
+	s.outSeq, s.outPayload = 0, nil
+}
+
+// sendAck acknowledges a packet received from the BMC, with the number of characters accepted
+func (s *SOL) sendAck(seq uint8, count int, nack bool) error {
+	s.mu.Lock()
+	ops := s.control
+	s.mu.Unlock()
+
+	if nack {
+		ops |= solNACK
+	}
+
+	return s.send([]byte{0, seq, uint8(count), ops})
+}
+
+// sendNext sends pending characters and operations in a new packet
+func (s *SOL) sendNext() error {
+	s.mu.Lock()
+	n := len(s.wbuf)
+	if n > s.maxData {
+		n = s.maxData
+	}
+
+	if n == 0 && s.ops == 0 && s.control == s.controlSent {
+		s.mu.Unlock()
+		return nil
+	}
+
+	payload := make([]byte, solHeaderSize, solHeaderSize+n)
+	payload[3] = s.ops | s.control
+	payload = append(payload, s.wbuf[:n]...)
+
+	s.wbuf = s.wbuf[n:]
+	s.controlSent = s.control
+	s.ops = 0
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	// Sequence numbers cycle from 1 to 15
+	s.lastSeq = s.lastSeq%solMaxSequence + 1
+	payload[0] = s.lastSeq
+
+	s.outSeq, s.outPayload, s.outAttempts = s.lastSeq, payload, 0
+
+	return s.resend()
+}
+
+// retransmit resends the packet awaiting acknowledgement, if it has not been acknowledged in time
+func (s *SOL) retransmit() error {
+	if s.outSeq == 0 || time.Now().Before(s.resendAt) {
+		return nil
+	}
+
+	if _, retries := s.l.timing(); s.outAttempts > retries {
+		return fmt.Errorf("SOL packet not acknowledged after %d attempts: %w", s.outAttempts, ErrTimeout)
+	}
+
+	return s.resend()
+}
+
+// resend sends the packet awaiting acknowledgement
+func (s *SOL) resend() error {
+	s.outAttempts++
+	timeout, _ := s.l.timing()
+	s.resendAt = time.Now().Add(timeout)
+
+	return s.send(s.outPayload)
+}
+
+// send sends a SOL payload in the session
+func (s *SOL) send(payload []byte) error {
+	s.l.mu.Lock()
+	if s.l.sessionID == 0 {
+		s.l.mu.Unlock()
+		return errors.New("session closed")
+	}
+
+	pkt, err := rmcpPlusFormat{s.l}.encode(payloadTypeSOL, payload)
+	s.l.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.l.conn.Write(pkt); err != nil {
+		return fmt.Errorf("send SOL packet: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package ipmi_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+// End of the banner of the simulated serial console
+const solPrompt = "login: "
+
+// expectSOL reads from the SOL payload until want has been received, returning the characters read
+func expectSOL(t *testing.T, sol *ipmi.SOL, want string) string {
+	t.Helper()
+
+	done := make(chan error, 1)
+	var got []byte
+
+	go func() {
+		buf := make([]byte, 256)
+		for !bytes.Contains(got, []byte(want)) {
+			n, err := sol.Read(buf)
+			if err != nil {
+				done <- err
+				return
+			}
+			got = append(got, buf[:n]...)
+		}
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("read %q, expected %q: %v", got, want, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for %q", want)
+	}
+
+	return string(got)
+}
+
+// activateTestSOL opens an RMCP+ session to the simulator and activates SOL
+func activateTestSOL(t *testing.T, c *ipmi.Client) *ipmi.SOL {
+	if err := c.OpenSession("user", "user", ipmi.PrivLevelUser); err != nil {
+		t.Fatal(err)
+	}
+
+	sol, err := c.ActivateSOL(ipmi.DefaultSOLInstance)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return sol
+}
+
+func TestSOL(t *testing.T) {
+	_, addr := newTestSimulator(t, ipmisim.DefaultProfile())
+	c := dialSimulator(t, addr)
+	sol := activateTestSOL(t, c)
+
+	expectSOL(t, sol, solPrompt)
+
+	if _, err := sol.Write([]byte("root\r")); err != nil {
+		t.Fatal(err)
+	}
+	expectSOL(t, sol, "root\r")
+
+	sol.SendBreak()
+	expectSOL(t, sol, "<break>")
+
+	// Payload is active on another session
+	other := dialSimulator(t, addr)
+	if err := other.OpenSession("admin", "admin", ipmi.PrivLevelAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := other.ActivateSOL(ipmi.DefaultSOLInstance); !errors.Is(err, ipmi.ErrPayloadActive) {
+		t.Errorf("expected payload already active, got %v", err)
+	}
+
+	if err := sol.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := sol.Read(make([]byte, 1)); n != 0 || err == nil {
+		t.Errorf("read %d bytes after close, error %v", n, err)
+	}
+
+	if _, err := sol.Write([]byte("x")); err == nil {
+		t.Error("write succeeded after close")
+	}
+
+	// Payload may be activated again once deactivated
+	sol, err := c.ActivateSOL(ipmi.DefaultSOLInstance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sol.Close()
+
+	expectSOL(t, sol, solPrompt)
+}
+
+func TestSOLIPMIv15(t *testing.T) {
+	profile := ipmisim.DefaultProfile()
+	profile.IPMIv20 = false
+
+	_, addr := newTestSimulator(t, profile)
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("user", "user", ipmi.PrivLevelUser); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.ActivateSOL(ipmi.DefaultSOLInstance); err == nil {
+		t.Error("ipmi.SOL activated in IPMI v1.5 session")
+	}
+}
+
+func TestSOLFaults(t *testing.T) {
+	sim, addr := newTestSimulator(t, ipmisim.DefaultProfile())
+	c := dialSimulator(t, addr)
+	c.SetTimeout(10*time.Millisecond, 10)
+	sol := activateTestSOL(t, c)
+
+	expectSOL(t, sol, solPrompt)
+
+	sim.SetFaults(ipmisim.Faults{Loss: 0.2, Seed: 1})
+
+	// Characters are neither lost nor repeated by retransmissions
+	input := strings.Repeat("0123456789abcdefghijklmnopqrstuvwxyz", 10) + "."
+	if _, err := sol.Write([]byte(input)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := expectSOL(t, sol, "."); got != input {
+		t.Errorf("echoed %q, expected %q", got, input)
+	}
+
+	sim.SetFaults(ipmisim.Faults{})
+	sol.Close()
+}
@@ -0,0 +1,67 @@
+package ipmi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type codes of type/length bytes per section 43.15 and the Platform Management FRU Information
+// Storage Definition, section 13
+const (
+	stringTypeBinary    = 0x00 // Binary or unspecified (FRU), Unicode (SDR)
+	stringTypeBCDPlus   = 0x01
+	stringType6BitASCII = 0x02
+	stringType8BitASCII = 0x03 // 8-bit ASCII + Latin 1
+)
+
+const bcdPlusChars = "0123456789 -.:,_"
+
+// decodeString decodes a string field whose encoding is indicated by the type bits of its
+// type/length byte.
+func decodeString(typ uint8, b []byte) string {
+	switch typ {
+	case stringTypeBCDPlus:
+		var sb strings.Builder
+		for _, c := range b {
+			sb.WriteByte(bcdPlusChars[c>>4])
+			sb.WriteByte(bcdPlusChars[c&0x0f])
+		}
+		return sb.String()
+
+	case stringType6BitASCII:
+		return decode6BitASCII(b)
+
+	case stringType8BitASCII:
+		// Latin 1 code points map directly to Unicode
+		runes := make([]rune, 0, len(b))
+		for _, c := range b {
+			runes = append(runes, rune(c))
+		}
+		return strings.TrimRight(string(runes), "\x00 ")
+	}
+
+	return fmt.Sprintf("% x", b)
+}
+
+// decode6BitASCII unpacks 6-bit ASCII, where every three bytes hold four characters, least
+// significant bits first.
+func decode6BitASCII(b []byte) string {
+	var (
+		sb   strings.Builder
+		acc  uint32
+		bits uint
+	)
+
+	for _, c := range b {
+		acc |= uint32(c) << bits
+		bits += 8
+
+		for bits >= 6 {
+			sb.WriteByte(uint8(acc&0x3f) + 0x20)
+			acc >>= 6
+			bits -= 6
+		}
+	}
+
+	return strings.TrimRight(sb.String(), " ")
+}
@@ -0,0 +1,84 @@
+package ipmi
+
+import (
+	"encoding"
+	"fmt"
+	"time"
+)
+
+// Transport sends requests to a BMC and receives their responses. Commands are implemented once
+// against Transport, and work over any of its implementations: LAN with IPMI v1.5 or IPMI v2.0
+// RMCP+ sessions, or in-band via the local system interface.
+type Transport interface {
+	// SendRecv sends a request and returns the raw response data, starting with the completion
+	// code. Responses with a completion code other than CommandCompleted are returned as a
+	// *CommandError. It is safe to call from multiple goroutines concurrently.
+	SendRecv(req Request) ([]byte, error)
+
+	// SetTimeout sets the time to wait for a response, and the maximum number of retries
+	SetTimeout(timeout time.Duration, retries int) error
+
+	Close() error
+}
+
+// conn implements IPMI commands over a Transport
+type conn struct {
+	Transport
+}
+
+// send sends a request with data encoded by req, and decodes the response data into resp. Either
+// may be nil for commands without request or response data.
+func (c *conn) send(netFn, cmd uint8, req encoding.BinaryMarshaler, resp encoding.BinaryUnmarshaler) error {
+	return c.sendLUN(0, netFn, cmd, req, resp)
+}
+
+// sendLUN sends a request to LUN lun of the controller, as send
+func (c *conn) sendLUN(lun, netFn, cmd uint8, req encoding.BinaryMarshaler, resp encoding.BinaryUnmarshaler) error {
+	var data []byte
+
+	if req != nil {
+		var err error
+		if data, err = req.MarshalBinary(); err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+	}
+
+	data, err := c.SendRecv(Request{NetworkFunction: netFn, Command: cmd, Data: data, LUN: lun})
+	if err != nil {
+		return err
+	}
+
+	return unmarshalResponse(data, resp)
+}
+
+// unmarshalResponse decodes the response data following the completion code into resp, if not nil.
+// Transports return the completion code with the data, so empty data is malformed.
+func unmarshalResponse(data []byte, resp encoding.BinaryUnmarshaler) error {
+	if len(data) == 0 {
+		return fmt.Errorf("decode response: %w", ErrShortData)
+	}
+
+	if resp == nil {
+		return nil
+	}
+
+	if err := resp.UnmarshalBinary(data[1:]); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
+
+// checkCompletionCode returns the response data to a request, or a *CommandError if its completion
+// code is other than CommandCompleted
+func checkCompletionCode(req Request, data []byte) ([]byte, error) {
+	if len(data) < 1 {
+		return nil, ErrShortData
+	}
+
+	if data[0] != uint8(CommandCompleted) {
+		return nil, &CommandError{req.NetworkFunction, req.Command, CompletionCode(data[0])}
+	}
+
+	return data, nil
+}
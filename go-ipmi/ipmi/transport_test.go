@@ -0,0 +1,83 @@
+package ipmi
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// stubTransport answers requests with canned responses, recording the requests sent
+type stubTransport struct {
+	responses map[[2]uint8][]byte
+	requests  []Request
+}
+
+func (t *stubTransport) SendRecv(req Request) ([]byte, error) {
+	t.requests = append(t.requests, req)
+
+	data, ok := t.responses[[2]uint8{req.NetworkFunction, req.Command}]
+	if !ok {
+		data = []byte{uint8(ErrInvalidCommand)}
+	}
+
+	return checkCompletionCode(req, data)
+}
+
+func (t *stubTransport) SetTimeout(timeout time.Duration, retries int) error {
+	return nil
+}
+
+func (t *stubTransport) Close() error {
+	return nil
+}
+
+func TestNewClient(t *testing.T) {
+	stub := &stubTransport{responses: map[[2]uint8][]byte{
+		{NetFnChassis, CmdChassisControl}: {0},
+		{NetFnApp, CmdGetSelfTestResults}: {0, SelfTestPassed, 0},
+	}}
+
+	c := NewClient(stub)
+
+	if err := c.ChassisControl(ChassisPowerCycle); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, err := c.GetSelfTestResults(); err != nil || !result.Passed() {
+		t.Errorf("self test result %v, error %v", result, err)
+	}
+
+	if _, err := c.GetChassisStatus(); !errors.Is(err, ErrInvalidCommand) {
+		t.Errorf("expected invalid command, got %v", err)
+	}
+
+	if len(stub.requests) != 3 || stub.requests[0].Data[0] != ChassisPowerCycle {
+		t.Errorf("unexpected requests %v", stub.requests)
+	}
+
+	if _, err := c.GetChannelCipherSuites(CurrentChannel); !errors.Is(err, ErrLANOnly) {
+		t.Errorf("expected LAN only error, got %v", err)
+	}
+}
+
+// emptyTransport returns empty response data, without a completion code
+type emptyTransport struct {
+	stubTransport
+}
+
+func (t *emptyTransport) SendRecv(req Request) ([]byte, error) {
+	return nil, nil
+}
+
+func TestEmptyResponse(t *testing.T) {
+	c := NewClient(&emptyTransport{})
+
+	if _, err := c.GetDeviceID(); !errors.Is(err, ErrShortData) {
+		t.Errorf("expected short data error, got %v", err)
+	}
+
+	req := Request{NetworkFunction: NetFnChassis, Command: CmdChassisControl, Data: []byte{ChassisPowerCycle}}
+	if err := c.Send(req, nil); !errors.Is(err, ErrShortData) {
+		t.Errorf("expected short data error, got %v", err)
+	}
+}
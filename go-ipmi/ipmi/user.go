@@ -0,0 +1,393 @@
+package ipmi
+
+// User account and channel access commands per sections 22.22 to 22.30, and Get User Payload
+// Access per section 24.7
+
+import (
+	"fmt"
+)
+
+// Channel access settings, which are applied to non-volatile storage, to the active (volatile)
+// settings, or both
+const (
+	ChannelAccessNonVolatile = 0x01
+	ChannelAccessVolatile    = 0x02
+)
+
+// Channel access modes per section 22.22
+const (
+	AccessModeDisabled = 0x00
+	AccessModePreBoot  = 0x01 // Available only while the system is in a pre-boot state
+	AccessModeAlways   = 0x02
+	AccessModeShared   = 0x03
+)
+
+// User ID enable status in UserAccess per section 22.27
+const (
+	UserStatusUnspecified = 0x00
+	UserStatusEnabled     = 0x01
+	UserStatusDisabled    = 0x02
+)
+
+// Set User Password operations per section 22.30
+const (
+	UserDisable      = 0x00
+	UserEnable       = 0x01
+	UserSetPassword  = 0x02
+	UserTestPassword = 0x03
+)
+
+// Completion codes specific to channel and user commands
+var (
+	ErrPasswordMismatch       = commandCode(0x80, NetFnApp, CmdSetUserPassword) // Password test failed
+	ErrPasswordSize           = commandCode(0x81, NetFnApp, CmdSetUserPassword) // Password test failed due to wrong password size
+	ErrChannelNotSupported    = commandCode(0x82, NetFnApp, CmdSetChannelAccess, CmdGetChannelAccess)
+	ErrAccessModeNotSupported = commandCode(0x83, NetFnApp, CmdSetChannelAccess)
+)
+
+const (
+	maxUserID          = 0x3f
+	userAccessChange   = 0x80
+	userCallbackOnly   = 0x40
+	userLinkAuth       = 0x20
+	userIPMIMessaging  = 0x10
+	channelNoAlerting  = 0x20
+	channelNoPerMsg    = 0x10
+	channelNoUserAuth  = 0x08
+	passwordSize20     = 0x80
+	passwordSizeShort  = 16
+	passwordSizeLong   = 20
+	channelAccessShift = 6
+)
+
+// ChannelAccessRequest per section 22.23
+type ChannelAccessRequest struct {
+	ChannelNumber uint8
+	Store         uint8 // ChannelAccessNonVolatile or ChannelAccessVolatile
+}
+
+func (r *ChannelAccessRequest) MarshalBinary() ([]byte, error) {
+	return []byte{r.ChannelNumber & 0x0f, r.Store << channelAccessShift}, nil
+}
+
+func (r *ChannelAccessRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.ChannelNumber = d.uint8() & 0x0f
+	r.Store = d.uint8() >> channelAccessShift
+	return d.err
+}
+
+// ChannelAccess is the response to Get Channel Access per section 22.23
+type ChannelAccess struct {
+	AlertingDisabled   bool // PEF alerting disabled
+	PerMsgAuthDisabled bool
+	UserAuthDisabled   bool // User level authentication disabled
+	AccessMode         uint8
+	PrivLimit          uint8 // Maximum privilege level of the channel
+}
+
+func (a *ChannelAccess) MarshalBinary() ([]byte, error) {
+	return []byte{a.accessBits(), a.PrivLimit & 0x0f}, nil
+}
+
+func (a *ChannelAccess) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	a.decode(d.uint8(), d.uint8())
+	return d.err
+}
+
+func (a *ChannelAccess) decode(access, priv uint8) {
+	a.AlertingDisabled = access&channelNoAlerting != 0
+	a.PerMsgAuthDisabled = access&channelNoPerMsg != 0
+	a.UserAuthDisabled = access&channelNoUserAuth != 0
+	a.AccessMode = access & 0x07
+	a.PrivLimit = priv & 0x0f
+}
+
+func (a *ChannelAccess) accessBits() uint8 {
+	return bit(a.AlertingDisabled, channelNoAlerting) | bit(a.PerMsgAuthDisabled, channelNoPerMsg) |
+		bit(a.UserAuthDisabled, channelNoUserAuth) | a.AccessMode&0x07
+}
+
+// SetChannelAccessRequest per section 22.22. Both the channel access settings and the privilege
+// limit are set in Store.
+type SetChannelAccessRequest struct {
+	ChannelNumber uint8
+	Store         uint8 // ChannelAccessNonVolatile or ChannelAccessVolatile
+	Access        ChannelAccess
+}
+
+func (r *SetChannelAccessRequest) MarshalBinary() ([]byte, error) {
+	store := r.Store << channelAccessShift
+	return []byte{r.ChannelNumber & 0x0f, store | r.Access.accessBits(), store | r.Access.PrivLimit&0x0f}, nil
+}
+
+func (r *SetChannelAccessRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.ChannelNumber = d.uint8() & 0x0f
+	access := d.uint8()
+	r.Store = access >> channelAccessShift
+	r.Access.decode(access, d.uint8())
+	return d.err
+}
+
+// UserAccessRequest per sections 22.27 and 24.7, for Get User Access and Get User Payload Access
+type UserAccessRequest struct {
+	ChannelNumber uint8
+	UserID        uint8
+}
+
+func (r *UserAccessRequest) MarshalBinary() ([]byte, error) {
+	return []byte{r.ChannelNumber & 0x0f, r.UserID & maxUserID}, nil
+}
+
+func (r *UserAccessRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.ChannelNumber = d.uint8() & 0x0f
+	r.UserID = d.uint8() & maxUserID
+	return d.err
+}
+
+// UserAccess is the response to Get User Access per section 22.27
+type UserAccess struct {
+	MaxUsers      uint8
+	EnabledUsers  uint8
+	Status        uint8 // UserStatusEnabled or UserStatusDisabled, if specified
+	FixedNames    uint8 // Number of user IDs with fixed names, starting from user ID 1
+	CallbackOnly  bool
+	LinkAuth      bool // Link authentication enabled
+	IPMIMessaging bool // IPMI messaging enabled, i.e. the user may establish sessions
+	PrivLimit     uint8
+}
+
+func (a *UserAccess) MarshalBinary() ([]byte, error) {
+	return []byte{
+		a.MaxUsers & maxUserID,
+		a.Status<<6 | a.EnabledUsers&maxUserID,
+		a.FixedNames & maxUserID,
+		a.accessBits() | a.PrivLimit&0x0f,
+	}, nil
+}
+
+func (a *UserAccess) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	a.MaxUsers = d.uint8() & maxUserID
+	enabled := d.uint8()
+	a.EnabledUsers = enabled & maxUserID
+	a.Status = enabled >> 6
+	a.FixedNames = d.uint8() & maxUserID
+	access := d.uint8()
+	a.CallbackOnly = access&userCallbackOnly != 0
+	a.LinkAuth = access&userLinkAuth != 0
+	a.IPMIMessaging = access&userIPMIMessaging != 0
+	a.PrivLimit = access & 0x0f
+	return d.err
+}
+
+func (a *UserAccess) accessBits() uint8 {
+	return bit(a.CallbackOnly, userCallbackOnly) | bit(a.LinkAuth, userLinkAuth) |
+		bit(a.IPMIMessaging, userIPMIMessaging)
+}
+
+// SetUserAccessRequest per section 22.26
+type SetUserAccessRequest struct {
+	ChannelNumber uint8
+	UserID        uint8
+	CallbackOnly  bool
+	LinkAuth      bool
+	IPMIMessaging bool
+	PrivLimit     uint8 // Privilege level, or PrivLevelNoAccess
+}
+
+func (r *SetUserAccessRequest) MarshalBinary() ([]byte, error) {
+	access := userAccessChange | bit(r.CallbackOnly, userCallbackOnly) | bit(r.LinkAuth, userLinkAuth) |
+		bit(r.IPMIMessaging, userIPMIMessaging)
+	return []byte{access | r.ChannelNumber&0x0f, r.UserID & maxUserID, r.PrivLimit & 0x0f}, nil
+}
+
+func (r *SetUserAccessRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	access := d.uint8()
+	r.ChannelNumber = access & 0x0f
+	r.CallbackOnly = access&userCallbackOnly != 0
+	r.LinkAuth = access&userLinkAuth != 0
+	r.IPMIMessaging = access&userIPMIMessaging != 0
+	r.UserID = d.uint8() & maxUserID
+	r.PrivLimit = d.uint8() & 0x0f
+	return d.err
+}
+
+// SetUserNameRequest per section 22.28
+type SetUserNameRequest struct {
+	UserID uint8
+	Name   string // Up to 16 bytes
+}
+
+func (r *SetUserNameRequest) MarshalBinary() ([]byte, error) {
+	return appendPadded([]byte{r.UserID & maxUserID}, "user name", r.Name, 16)
+}
+
+func (r *SetUserNameRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.UserID = d.uint8() & maxUserID
+	r.Name = d.padded(16)
+	return d.err
+}
+
+// GetUserNameResponse per section 22.29
+type GetUserNameResponse struct {
+	Name string
+}
+
+func (r *GetUserNameResponse) MarshalBinary() ([]byte, error) {
+	return appendPadded(nil, "user name", r.Name, 16)
+}
+
+func (r *GetUserNameResponse) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	r.Name = d.padded(16)
+	return d.err
+}
+
+// SetUserPasswordRequest per section 22.30
+type SetUserPasswordRequest struct {
+	UserID    uint8
+	Operation uint8 // UserDisable, UserEnable, UserSetPassword or UserTestPassword
+	Password  string
+	Size20    bool // Password is stored or tested as 20 bytes rather than 16
+}
+
+func (r *SetUserPasswordRequest) MarshalBinary() ([]byte, error) {
+	b := []byte{bit(r.Size20, passwordSize20) | r.UserID&maxUserID, r.Operation & 0x03}
+
+	if r.Operation != UserSetPassword && r.Operation != UserTestPassword {
+		return b, nil
+	}
+
+	return appendPadded(b, "password", r.Password, r.passwordSize())
+}
+
+func (r *SetUserPasswordRequest) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	user := d.uint8()
+	r.UserID = user & maxUserID
+	r.Size20 = user&passwordSize20 != 0
+	r.Operation = d.uint8() & 0x03
+
+	if r.Operation == UserSetPassword || r.Operation == UserTestPassword {
+		r.Password = d.padded(r.passwordSize())
+	}
+
+	return d.err
+}
+
+func (r *SetUserPasswordRequest) passwordSize() int {
+	if r.Size20 {
+		return passwordSizeLong
+	}
+	return passwordSizeShort
+}
+
+// UserPayloadAccess is the response to Get User Payload Access per section 24.7
+type UserPayloadAccess struct {
+	Standard uint8 // Bit n enables standard payload type n, e.g. SOL
+	OEM      uint8 // Bit n enables OEM payload type 20h + n
+}
+
+func (a *UserPayloadAccess) MarshalBinary() ([]byte, error) {
+	return []byte{a.Standard &^ 0x01, 0, a.OEM, 0}, nil
+}
+
+func (a *UserPayloadAccess) UnmarshalBinary(b []byte) error {
+	d := decoder{b: b}
+	a.Standard = d.uint8() &^ 0x01
+	d.uint8()
+	a.OEM = d.uint8()
+	d.uint8()
+	return d.err
+}
+
+// SOL reports whether the user may activate Serial Over LAN
+func (a *UserPayloadAccess) SOL() bool {
+	return a.Standard&(1<<payloadTypeSOL) != 0
+}
+
+// getChannelAccess returns the non-volatile or active (volatile) access settings of a channel
+func (c *conn) getChannelAccess(channel, store uint8) (*ChannelAccess, error) {
+	if store != ChannelAccessNonVolatile && store != ChannelAccessVolatile {
+		return nil, fmt.Errorf("invalid channel access store: %#x", store)
+	}
+
+	resp := &ChannelAccess{}
+	if err := c.send(NetFnApp, CmdGetChannelAccess, &ChannelAccessRequest{channel, store}, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// setChannelAccess sets the access settings and privilege limit of a channel in non-volatile
+// storage, the active settings, or both
+func (c *conn) setChannelAccess(channel, store uint8, access *ChannelAccess) error {
+	for _, s := range []uint8{ChannelAccessNonVolatile, ChannelAccessVolatile} {
+		if store&s == 0 {
+			continue
+		}
+
+		req := &SetChannelAccessRequest{channel, s, *access}
+		if err := c.send(NetFnApp, CmdSetChannelAccess, req, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getUserAccess returns the access settings of a user on a channel
+func (c *conn) getUserAccess(channel, userID uint8) (*UserAccess, error) {
+	resp := &UserAccess{}
+	if err := c.send(NetFnApp, CmdGetUserAccess, &UserAccessRequest{channel, userID}, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// setUserAccess sets the access settings and privilege limit of a user on a channel
+func (c *conn) setUserAccess(channel, userID uint8, access *UserAccess) error {
+	req := &SetUserAccessRequest{
+		ChannelNumber: channel,
+		UserID:        userID,
+		CallbackOnly:  access.CallbackOnly,
+		LinkAuth:      access.LinkAuth,
+		IPMIMessaging: access.IPMIMessaging,
+		PrivLimit:     access.PrivLimit,
+	}
+	return c.send(NetFnApp, CmdSetUserAccess, req, nil)
+}
+
+// getUserName returns the user name of a user ID, which is empty if the user ID is not in use
+func (c *conn) getUserName(userID uint8) (string, error) {
+	resp := &GetUserNameResponse{}
+	if err := c.send(NetFnApp, CmdGetUserName, rawRequest{userID & maxUserID}, resp); err != nil {
+		return "", err
+	}
+	return resp.Name, nil
+}
+
+// setUserName sets the user name of a user ID
+func (c *conn) setUserName(userID uint8, name string) error {
+	return c.send(NetFnApp, CmdSetUserName, &SetUserNameRequest{userID, name}, nil)
+}
+
+// setUserPassword performs a Set User Password operation. A failed password test returns
+// ErrPasswordMismatch or ErrPasswordSize.
+func (c *conn) setUserPassword(req *SetUserPasswordRequest) error {
+	return c.send(NetFnApp, CmdSetUserPassword, req, nil)
+}
+
+// getUserPayloadAccess returns the payload types that a user may activate on a channel
+func (c *conn) getUserPayloadAccess(channel, userID uint8) (*UserPayloadAccess, error) {
+	resp := &UserPayloadAccess{}
+	if err := c.send(NetFnApp, CmdGetUserPayloadAccess, &UserAccessRequest{channel, userID}, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
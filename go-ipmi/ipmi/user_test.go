@@ -0,0 +1,151 @@
+package ipmi_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+func TestUserManagement(t *testing.T) {
+	_, addr := newTestSimulator(t, ipmisim.DefaultProfile())
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("admin", "admin", ipmi.PrivLevelAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	if name, err := c.GetUserName(1); err != nil || name != "admin" {
+		t.Errorf("user 1 name %q, error %v", name, err)
+	}
+
+	// Configure an unused user ID as an operator with a 20 byte password
+	const id, password = 4, "a-20-byte-password!!"
+
+	if err := c.SetUserName(id, "rotate"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.SetUserPassword(id, password, true); err != nil {
+		t.Fatal(err)
+	}
+
+	access := &ipmi.UserAccess{LinkAuth: true, IPMIMessaging: true, PrivLimit: ipmi.PrivLevelOperator}
+	if err := c.SetUserAccess(ipmi.CurrentChannel, id, access); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.EnableUser(id, true); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.GetUserAccess(ipmi.CurrentChannel, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ipmi.UserAccess{
+		MaxUsers:      10, // User IDs of the simulator
+		EnabledUsers:  4,
+		Status:        ipmi.UserStatusEnabled,
+		LinkAuth:      true,
+		IPMIMessaging: true,
+		PrivLimit:     ipmi.PrivLevelOperator,
+	}
+	if *got != want {
+		t.Errorf("user access %+v, expected %+v", got, want)
+	}
+
+	if err := c.TestUserPassword(id, password, true); err != nil {
+		t.Errorf("password test failed: %v", err)
+	}
+
+	if err := c.TestUserPassword(id, "wrong", true); !errors.Is(err, ipmi.ErrPasswordMismatch) {
+		t.Errorf("expected password mismatch, got %v", err)
+	}
+
+	if err := c.TestUserPassword(id, "short", false); !errors.Is(err, ipmi.ErrPasswordSize) {
+		t.Errorf("expected wrong password size, got %v", err)
+	}
+
+	if payloads, err := c.GetUserPayloadAccess(ipmi.CurrentChannel, id); err != nil || !payloads.SOL() {
+		t.Errorf("payload access %+v, error %v", payloads, err)
+	}
+
+	// New user may log in up to its privilege limit
+	user := dialSimulator(t, addr)
+	if err := user.OpenSession("rotate", password, ipmi.PrivLevelAdmin); err == nil {
+		t.Error("session established above user privilege limit")
+	}
+
+	user = dialSimulator(t, addr)
+	if err := user.OpenSession("rotate", password, ipmi.PrivLevelOperator); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := user.SetUserName(id, "escalate"); !errors.Is(err, ipmi.ErrInsufficientPriv) {
+		t.Errorf("expected insufficient privilege, got %v", err)
+	}
+
+	// User may no longer log in once IPMI messaging is disabled
+	access.IPMIMessaging = false
+	if err := c.SetUserAccess(ipmi.CurrentChannel, id, access); err != nil {
+		t.Fatal(err)
+	}
+
+	user = dialSimulator(t, addr)
+	if err := user.OpenSession("rotate", password, ipmi.PrivLevelOperator); err == nil {
+		t.Error("session established with IPMI messaging disabled")
+	}
+}
+
+func TestChannelAccess(t *testing.T) {
+	_, addr := newTestSimulator(t, ipmisim.DefaultProfile())
+	c := dialSimulator(t, addr)
+
+	if err := c.OpenSession("admin", "admin", ipmi.PrivLevelAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	access, err := c.GetChannelAccess(ipmi.CurrentChannel, ipmi.ChannelAccessVolatile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if access.AccessMode != ipmi.AccessModeAlways || access.PrivLimit != ipmi.PrivLevelAdmin {
+		t.Errorf("channel access %+v", access)
+	}
+
+	access.AccessMode = ipmi.AccessModeShared
+	if err := c.SetChannelAccess(ipmi.CurrentChannel, ipmi.ChannelAccessVolatile, access); !errors.Is(err, ipmi.ErrAccessModeNotSupported) {
+		t.Errorf("expected access mode not supported, got %v", err)
+	}
+
+	// Lower the channel privilege limit persistently and immediately
+	access.AccessMode = ipmi.AccessModeAlways
+	access.PrivLimit = ipmi.PrivLevelOperator
+	if err := c.SetChannelAccess(ipmi.CurrentChannel, ipmi.ChannelAccessNonVolatile|ipmi.ChannelAccessVolatile, access); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, store := range []uint8{ipmi.ChannelAccessNonVolatile, ipmi.ChannelAccessVolatile} {
+		if got, err := c.GetChannelAccess(ipmi.CurrentChannel, store); err != nil || *got != *access {
+			t.Errorf("store %d: channel access %+v, error %v", store, got, err)
+		}
+	}
+
+	admin := dialSimulator(t, addr)
+	if err := admin.OpenSession("admin", "admin", ipmi.PrivLevelAdmin); err == nil {
+		t.Error("session established above channel privilege limit")
+	}
+
+	admin = dialSimulator(t, addr)
+	if err := admin.OpenSession("admin", "admin", ipmi.PrivLevelOperator); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.GetChannelAccess(ipmi.CurrentChannel, 0); err == nil {
+		t.Error("invalid channel access store accepted")
+	}
+}
@@ -1,179 +1,283 @@
 package main
 
-// IPMI implementation for Go
-//
-// Based on https://www-ssl.intel.com/content/www/us/en/servers/ipmi/ipmi-intelligent-platform-mgt-interface-spec-2nd-gen-v2-0-spec-update.html
+// LAN configuration subcommands
 
 import (
-	"bytes"
-	"context"
-	"encoding/binary"
+	"flag"
 	"fmt"
+	"io"
 	"net"
-	"time"
+	"strconv"
+	"strings"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
 )
 
-const ipmiBufSize = 1024
+// Default LAN channel, as on most BMCs
+const defaultLANChannel = 1
 
-type lanConnection struct {
-	conn      net.Conn // Socket connection
-	priv      uint8    // Privilege level
-	lun       uint8    // LUN
-	sequence  uint32
-	sessionID uint32
-}
+var ipSources = []string{"none", "static", "dhcp", "bios", "other"}
 
-func newLanConnection(host string) (lanConnection, error) {
-	l := lanConnection{
-		priv: PrivLevelAdmin, // TODO
-	}
+var addressingModes = []string{"ipv4", "ipv6", "dual"}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
-	defer cancel()
+var ipv6Sources = []string{"static", "SLAAC", "DHCPv6"}
 
-	dialer := &net.Dialer{}
-	if conn, err := dialer.DialContext(ctx, "udp4", host); err != nil {
-		return l, err
-	} else {
-		l.conn = conn
-	}
+var ipv6Statuses = []string{"active", "disabled", "pending", "failed", "deprecated", "invalid"}
 
-	// FIXME: Move to send / recv functions
-	deadline, _ := ctx.Deadline()
-	if err := l.conn.SetDeadline(deadline); err != nil {
-		panic(err)
-	}
+// Privilege level names, with "off" marking a cipher suite unused
+var privLevels = []string{"off", "callback", "user", "operator", "admin", "oem"}
 
-	return l, nil
-}
+var authTypes = []string{"none", "md2", "md5", "", "password", "oem"}
 
-func (l *lanConnection) close() {
-	l.conn.Close()
+// lanIPv4Params are the LAN set parameters taking an IPv4 address
+var lanIPv4Params = map[string]uint8{
+	"ipaddr":  ipmi.LANParamIPAddress,
+	"netmask": ipmi.LANParamSubnetMask,
+	"defgw":   ipmi.LANParamDefaultGateway,
+	"bakgw":   ipmi.LANParamBackupGateway,
 }
 
-func (l *lanConnection) getAuthCapabilities() {
-	req := Request{
-		NetFnApp,
-		CmdGetChannelAuthCapabilities,
-		AuthCapabilitiesRequest{
-			0x8e, // IPMI v2.0+ extended data, current channel
-			l.priv,
-		},
+const lanSetUsage = `usage: lan set [-channel n] <parameter> <value>
+
+Parameters:
+  ipsrc none|static|dhcp|bios|other
+  ipaddr|netmask|defgw|bakgw <IPv4 address>
+  vlan off|<VLAN ID>
+  vlanprio <0-7>
+  cipher <cipher suite ID> off|callback|user|operator|admin|oem
+  auth callback|user|operator|admin|oem <none,md2,md5,password,oem>
+  ipv6 ipv4|ipv6|dual
+  ipv6addr <set> off|<IPv6 address/prefix length>`
+
+// cmdLAN prints or sets the LAN configuration parameters of a channel
+func cmdLAN(args []string) (hostAction, error) {
+	if len(args) == 0 || (args[0] != "print" && args[0] != "set") {
+		return nil, fmt.Errorf("expected lan command: print or set")
 	}
 
-	resp := AuthCapabilitiesResponse{}
+	fs := flag.NewFlagSet("lan", flag.ExitOnError)
+	channel := fs.Uint("channel", defaultLANChannel, "LAN channel number")
+	fs.Parse(args[1:])
 
-	if err := l.send(req, &resp); err != nil {
-		panic(err)
+	if args[0] == "set" && fs.NArg() < 2 {
+		return nil, fmt.Errorf(lanSetUsage)
 	}
 
-	fmt.Printf("%#v\n", resp)
+	return func(client *ipmi.Client, w io.Writer) error {
+		config, err := client.GetLANConfig(uint8(*channel))
+		if err != nil {
+			return err
+		}
 
-	// Check for supported auth type in order of preference
-	for _, t := range []uint8{AuthTypeMD5, AuthTypePassword, AuthTypeNone} {
-		if (resp.AuthTypeSupport & (1 << t)) != 0 {
-			fmt.Println(t)
-			break
+		if args[0] == "print" {
+			printLANConfig(w, config)
+			return nil
 		}
-	}
-}
 
-func (l *lanConnection) message(req Request) []byte {
-	buf := new(bytes.Buffer)
+		param, err := lanSetParam(config, fs.Arg(0), fs.Args()[1:])
+		if err != nil {
+			return err
+		}
 
-	// Write RMCP header
-	rmcpHeader := rmcpHeader{
-		Version:            rmcpVersion1,
-		RMCPSequenceNumber: 0xff,
-		Class:              rmcpClassIPMI,
-	}
+		return client.SetLANConfig(uint8(*channel), param)
+	}, nil
+}
 
-	ipmiSession := ipmiSession{
-		Sequence:  l.nextSequence(),
-		SessionID: l.sessionID,
+// lanSetParam encodes a LAN configuration parameter from its command line name and values, using
+// the current configuration for parameters that are only partially modified
+func lanSetParam(config *ipmi.LANConfig, name string, values []string) (ipmi.LANConfigParam, error) {
+	if sel, ok := lanIPv4Params[name]; ok {
+		ip := net.ParseIP(values[0])
+		if ip == nil {
+			return ipmi.LANConfigParam{}, fmt.Errorf("invalid IP address: %s", values[0])
+		}
+		return ipmi.IPv4Param(sel, ip)
 	}
 
-	binaryWrite(buf, rmcpHeader)
-	binaryWrite(buf, ipmiSession)
+	switch name {
+	case "ipsrc":
+		source, err := lookupName(ipSources, values[0])
+		return ipmi.IPSourceParam(source), err
 
-	// Marshal request data
-	data := new(bytes.Buffer)
-	binaryWrite(data, req.Data)
+	case "vlan":
+		if values[0] == "off" {
+			return ipmi.VLANParam(config.VLANID, false)
+		}
+		id, err := strconv.ParseUint(values[0], 10, 12)
+		if err != nil {
+			return ipmi.LANConfigParam{}, fmt.Errorf("invalid VLAN ID: %s", values[0])
+		}
+		return ipmi.VLANParam(uint16(id), true)
 
-	// Construct and write IPMI header
-	ipmiHeader := ipmiHeader{
-		MsgLen:     uint8(ipmiHeaderSize + data.Len()),       // Message len
-		RsAddr:     0x20,                                     // BMC slave address
-		NetFnRsLUN: (req.NetworkFunction << 2) | (l.lun & 3), // NetFn, target LUN
-		RqAddr:     0x81,                                     // Source address
-		Command:    req.Command,
-	}
+	case "vlanprio":
+		priority, err := strconv.ParseUint(values[0], 10, 8)
+		if err != nil {
+			return ipmi.LANConfigParam{}, fmt.Errorf("invalid VLAN priority: %s", values[0])
+		}
+		return ipmi.VLANPriorityParam(uint8(priority))
 
-	// Calculate checksums
-	ipmiHeader.Checksum = checksum(ipmiHeader.RsAddr, ipmiHeader.NetFnRsLUN)
-	payloadCsum := checksum(ipmiHeader.RqAddr, ipmiHeader.RqSeq, ipmiHeader.Command) + checksum(data.Bytes()...)
+	case "cipher":
+		if len(values) != 2 {
+			return ipmi.LANConfigParam{}, fmt.Errorf(lanSetUsage)
+		}
+		id, err := strconv.ParseUint(values[0], 10, 8)
+		if err != nil {
+			return ipmi.LANConfigParam{}, fmt.Errorf("invalid cipher suite ID: %s", values[0])
+		}
+		priv, err := lookupName(privLevels, values[1])
+		if err != nil {
+			return ipmi.LANConfigParam{}, err
+		}
+		for i, suite := range config.CipherSuites {
+			if suite == uint8(id) {
+				privs := append([]uint8(nil), config.CipherSuitePrivs...)
+				privs[i] = priv
+				return ipmi.CipherSuitePrivsParam(privs)
+			}
+		}
+		return ipmi.LANConfigParam{}, fmt.Errorf("cipher suite %d not supported", id)
 
-	// Write IPMI header and payload
-	binaryWrite(buf, ipmiHeader)
-	buf.ReadFrom(data)
-	buf.WriteByte(payloadCsum)
+	case "auth":
+		if len(values) != 2 {
+			return ipmi.LANConfigParam{}, fmt.Errorf(lanSetUsage)
+		}
+		level, err := lookupName(privLevels, values[0])
+		if err != nil || level == ipmi.PrivLevelUnspecified {
+			return ipmi.LANConfigParam{}, fmt.Errorf("invalid privilege level: %s", values[0])
+		}
+		var enables uint8
+		for _, s := range strings.Split(values[1], ",") {
+			t, err := lookupName(authTypes, s)
+			if err != nil {
+				return ipmi.LANConfigParam{}, err
+			}
+			enables |= 1 << t
+		}
+		all := config.AuthTypeEnables
+		all[level-1] = enables
+		return ipmi.AuthTypeEnablesParam(all), nil
 
-	return buf.Bytes()
-}
+	case "ipv6":
+		mode, err := lookupName(addressingModes, values[0])
+		return ipmi.IPv6EnablesParam(mode), err
 
-func (l *lanConnection) nextSequence() uint32 {
-	if l.sequence != 0 {
-		l.sequence++
+	case "ipv6addr":
+		if len(values) != 2 {
+			return ipmi.LANConfigParam{}, fmt.Errorf(lanSetUsage)
+		}
+		set, err := strconv.ParseUint(values[0], 10, 8)
+		if err != nil {
+			return ipmi.LANConfigParam{}, fmt.Errorf("invalid address set: %s", values[0])
+		}
+		if values[1] == "off" {
+			return ipmi.IPv6StaticAddressParam(uint8(set), net.IPv6zero, 0, false)
+		}
+		ip, prefix, err := net.ParseCIDR(values[1])
+		if err != nil {
+			return ipmi.LANConfigParam{}, err
+		}
+		ones, _ := prefix.Mask.Size()
+		return ipmi.IPv6StaticAddressParam(uint8(set), ip, uint8(ones), true)
 	}
-	return l.sequence
-}
-
-func (l *lanConnection) recv() []byte {
-	n, inbuf := l.recvPacket()
-	fmt.Printf("%d bytes read: % x\n", n, inbuf[:n])
 
-	hdr := decodeRMCPHeader(inbuf[:n])
-	fmt.Printf("%#v\n", hdr)
+	return ipmi.LANConfigParam{}, fmt.Errorf("unknown LAN parameter %q\n\n%s", name, lanSetUsage)
+}
 
-	if hdr.Class != rmcpClassIPMI {
-		fmt.Printf("Unsupported class: %#x\n", hdr.Class)
+// lookupName returns the index of name in names
+func lookupName(names []string, name string) (uint8, error) {
+	for i, n := range names {
+		if n != "" && strings.EqualFold(n, name) {
+			return uint8(i), nil
+		}
 	}
+	return 0, fmt.Errorf("unknown value %q, expected one of: %s", name, strings.Join(names, ", "))
+}
 
-	m, err := newMessageFromBytes(inbuf[:n])
-	if err != nil {
-		panic(err)
+// indexName returns names[i], or the number if it is out of range
+func indexName(names []string, i uint8) string {
+	if int(i) < len(names) && names[i] != "" {
+		return names[i]
 	}
-
-	return m.data
+	return fmt.Sprintf("0x%02x", i)
 }
 
-func (l *lanConnection) recvPacket() (int, []byte) {
-	buf := make([]byte, ipmiBufSize)
-	n, err := l.conn.Read(buf)
-	if err != nil {
-		panic(err)
+// authTypeNames returns the names of the authentication types set in a bitmask
+func authTypeNames(mask uint8) string {
+	var names []string
+	for i, name := range authTypes {
+		if name != "" && mask&(1<<i) != 0 {
+			names = append(names, name)
+		}
 	}
-
-	return n, buf
+	return strings.Join(names, " ")
 }
 
-func (l *lanConnection) send(req Request, resp interface{}) error {
-	buf := l.message(req)
+func printLANConfig(w io.Writer, c *ipmi.LANConfig) {
+	field := func(name string, value any) {
+		fmt.Fprintf(w, "%-26s: %v\n", name, value)
+	}
+
+	field("IP Address Source", indexName(ipSources, c.IPSource))
+	if c.IPAddress != nil {
+		field("IP Address", c.IPAddress)
+	}
+	if c.SubnetMask != nil {
+		field("Subnet Mask", c.SubnetMask)
+	}
+	if c.MACAddress != nil {
+		field("MAC Address", c.MACAddress)
+	}
+	if c.DefaultGateway != nil {
+		field("Default Gateway IP", c.DefaultGateway)
+	}
+	if c.DefaultGatewayMAC != nil {
+		field("Default Gateway MAC", c.DefaultGatewayMAC)
+	}
+	if c.BackupGateway != nil {
+		field("Backup Gateway IP", c.BackupGateway)
+	}
+	if c.BackupGatewayMAC != nil {
+		field("Backup Gateway MAC", c.BackupGatewayMAC)
+	}
 
-	if _, err := l.sendPacket(buf); err != nil {
-		return err
+	if c.VLANEnabled {
+		field("802.1q VLAN ID", c.VLANID)
+	} else {
+		field("802.1q VLAN ID", "disabled")
 	}
+	field("802.1q VLAN Priority", c.VLANPriority)
 
-	data := l.recv()
+	field("Auth Type Support", authTypeNames(c.AuthTypeSupport))
+	for i, enables := range c.AuthTypeEnables {
+		field(fmt.Sprintf("Auth Type Enable %s", privLevels[i+1]), authTypeNames(enables))
+	}
 
-	r := bytes.NewReader(data)
-	if err := binary.Read(r, binary.LittleEndian, resp); err != nil {
-		panic(err)
+	if len(c.CipherSuites) > 0 {
+		privs := make([]string, len(c.CipherSuites))
+		for i, id := range c.CipherSuites {
+			privs[i] = fmt.Sprintf("%d=%s", id, indexName(privLevels, c.CipherSuitePrivs[i]))
+		}
+		field("Cipher Suite Priv Max", strings.Join(privs, " "))
 	}
 
-	return nil
-}
+	if c.IPv6Support != 0 {
+		var support []string
+		if c.IPv6Support&ipmi.IPv6SupportIPv6Only != 0 {
+			support = append(support, "ipv6")
+		}
+		if c.IPv6Support&ipmi.IPv6SupportDual != 0 {
+			support = append(support, "dual")
+		}
+		field("IPv6/IPv4 Support", strings.Join(support, " "))
+		field("IPv6/IPv4 Addressing", indexName(addressingModes, c.IPv6Enables))
+	}
 
-func (l *lanConnection) sendPacket(b []byte) (int, error) {
-	return l.conn.Write(b)
+	for _, a := range c.IPv6Addresses {
+		kind := "Static"
+		if a.Source != ipmi.IPv6SourceStatic {
+			kind = "Dynamic"
+		}
+		field(fmt.Sprintf("IPv6 %s Address %d", kind, a.Set), fmt.Sprintf("%s/%d (%s, %s)", a.Address,
+			a.PrefixLength, indexName(ipv6Sources, a.Source), indexName(ipv6Statuses, a.Status)))
+	}
 }
@@ -1,33 +1,235 @@
 package main
 
-// IPMI implementation for Go
-//
-// Based on https://www-ssl.intel.com/content/www/us/en/servers/ipmi/ipmi-intelligent-platform-mgt-interface-spec-2nd-gen-v2-0-spec-update.html
+// Command line IPMI client, built on the ipmi package
 
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
 )
 
-func main() {
-	var host = flag.String("host", "", "Target host and port")
+var (
+	host     = flag.String("host", "", "Target host and port")
+	device   = flag.String("device", "", "OpenIPMI device for in-band access instead of -host, e.g. "+ipmi.DefaultDevice)
+	username = flag.String("user", "", "Username")
+	password = flag.String("password", "", "Password, or $"+passwordEnv+" if not specified")
+	priv     = flag.Uint("priv", ipmi.PrivLevelAdmin, "Requested session privilege level")
+	timeout  = flag.Duration("timeout", ipmi.DefaultTimeout, "Time to wait for a response before retransmitting")
+	retries  = flag.Int("retries", ipmi.DefaultRetries, "Maximum number of retransmissions")
+
+	// Running a command across many hosts
+	inventory = flag.String("inventory", "", "File listing hosts to run the command on instead of -host, or - for stdin, printing a JSON line per host")
+	parallel  = flag.Int("parallel", 16, "Maximum number of inventory hosts run concurrently")
+
+	// Bridging to a controller behind the BMC
+	targetAddr     = flag.Uint("target-addr", 0, "IPMB slave address of a controller behind the BMC to bridge commands to, e.g. 0x2c")
+	targetChannel  = flag.Uint("target-channel", ipmi.ChannelIPMB, "Channel of the bridged controller")
+	targetLUN      = flag.Uint("target-lun", 0, "LUN of the bridged controller")
+	transitAddr    = flag.Uint("transit-addr", 0, "IPMB slave address of a transit controller, for dual bridging")
+	transitChannel = flag.Uint("transit-channel", ipmi.ChannelIPMB, "Channel of the transit controller")
+)
+
+// hostAction runs a command against a connected client, printing its output to w
+type hostAction func(client *ipmi.Client, w io.Writer) error
+
+// commands maps subcommand names to their implementations, which receive the remaining arguments
+var commands = map[string]func(args []string) error{
+	"exporter": cmdExporter,
+	"simulate": cmdSimulate,
+	"discover": cmdDiscover,
+	"sol":      cmdSOL,
+}
+
+// hostCommands maps the names of subcommands run against a single host to their implementations,
+// which parse the remaining arguments and return the action to run once connected. Arguments are
+// parsed once, so that the action may also be run across an inventory of hosts.
+var hostCommands = map[string]func(args []string) (hostAction, error){
+	"session": cmdSession,
+	"power":   cmdPower,
+	"chassis": cmdChassis,
+	"bootdev": cmdBootdev,
+	"sel":     cmdSEL,
+	"fru":     cmdFRU,
+	"info":    cmdInfo,
+	"lan":     cmdLAN,
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <command> [args]\n\nCommands:\n", os.Args[0])
+
+	names := make([]string, 0, len(commands)+len(hostCommands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	for name := range hostCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s\n", name)
+	}
+
+	fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
+	flag.PrintDefaults()
+}
 
+func main() {
+	flag.Usage = usage
 	flag.Parse()
 
-	if *host == "" {
-		fmt.Println("Insufficient arguments:")
-		flag.PrintDefaults()
+	if *password == "" {
+		*password = os.Getenv(passwordEnv)
+	}
+
+	name := "session"
+	if flag.NArg() > 0 {
+		name = flag.Arg(0)
+	}
+
+	cmd, ok := commands[name]
+	hostCmd, isHost := hostCommands[name]
+	if !ok && !isHost {
+		fmt.Printf("Unknown command: %s\n\n", name)
+		usage()
 		os.Exit(1)
 	}
 
-	lc, err := newLanConnection(*host)
+	var args []string
+	if flag.NArg() > 1 {
+		args = flag.Args()[1:]
+	}
+
+	var err error
+	switch {
+	case *inventory != "":
+		err = runInventory(name, args)
+	case isHost:
+		err = runHostCommand(hostCmd, args)
+	default:
+		err = cmd(args)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// runHostCommand runs a command on the host or in-band device specified by the global flags,
+// printing its output to stdout
+func runHostCommand(cmd func(args []string) (hostAction, error), args []string) error {
+	action, err := cmd(args)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	defer lc.close()
 
-	fmt.Printf("Connection established: %#v\n", lc)
+	client, err := connect()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return action(client, os.Stdout)
+}
+
+// cmdSession establishes a session, and reports it along with the BMC's authentication capabilities
+func cmdSession(args []string) (hostAction, error) {
+	return func(client *ipmi.Client, w io.Writer) error {
+		caps, err := client.GetChannelAuthCapabilities(ipmi.CurrentChannel, uint8(*priv))
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "Channel authentication capabilities: %#v\n", caps)
+		fmt.Fprintf(w, "Session established: ID %#08x, IPMI version %#x, privilege level %d\n",
+			client.SessionID(), client.Version(), client.PrivLevel())
+
+		return nil
+	}, nil
+}
+
+// connect dials the target host and establishes a session with the global flag settings, or opens
+// the in-band device if specified. Commands are bridged to the target controller, if specified.
+func connect() (*ipmi.Client, error) {
+	if err := checkTarget(); err != nil {
+		return nil, err
+	}
+
+	if *device != "" {
+		client, err := ipmi.OpenDevice(*device)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := client.SetTimeout(*timeout, *retries); err != nil {
+			client.Close()
+			return nil, err
+		}
+
+		return bridgeTarget(client)
+	}
+
+	if *host == "" {
+		return nil, fmt.Errorf("no host specified")
+	}
+
+	return connectHost(*host, *username, *password)
+}
+
+// connectHost dials a host and establishes a session with the given credentials and the global
+// flag settings, bridging commands to the target controller if specified
+func connectHost(host, username, password string) (*ipmi.Client, error) {
+	client, err := ipmi.Dial(host)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.SetTimeout(*timeout, *retries); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	if err := client.OpenSession(username, password, uint8(*priv)); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return bridgeTarget(client)
+}
+
+// checkTarget validates the flags specifying the controller to bridge commands to
+func checkTarget() error {
+	if *targetAddr > 0xff || *targetChannel > 0x0f || *targetLUN > 3 || *transitAddr > 0xff ||
+		*transitChannel > 0x0f {
+		return fmt.Errorf("invalid bridging target")
+	}
+
+	return nil
+}
+
+// bridgeTarget returns a client bridging commands to the controller specified by the -target
+// flags, or client itself if none is specified. Client is closed on error.
+func bridgeTarget(client *ipmi.Client) (*ipmi.Client, error) {
+	if *targetAddr == 0 {
+		return client, nil
+	}
+
+	bridged := client.Bridge(ipmi.Target{
+		Channel:        uint8(*targetChannel),
+		Addr:           uint8(*targetAddr),
+		LUN:            uint8(*targetLUN),
+		TransitChannel: uint8(*transitChannel),
+		TransitAddr:    uint8(*transitAddr),
+	})
+	if err := bridged.SetTimeout(*timeout, *retries); err != nil {
+		client.Close()
+		return nil, err
+	}
 
-	lc.getAuthCapabilities()
+	return bridged, nil
 }
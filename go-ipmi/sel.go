@@ -0,0 +1,147 @@
+package main
+
+// System Event Log subcommands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+)
+
+// cmdSEL lists, clears or reports information about the System Event Log, or reads or sets its
+// time clock
+func cmdSEL(args []string) (hostAction, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("expected sel command: info, list, clear or time")
+	}
+
+	var setTime time.Time
+
+	switch args[0] {
+	case "info", "list", "clear":
+	case "time":
+		if len(args) > 1 {
+			if args[1] != "set" || len(args) != 3 {
+				return nil, fmt.Errorf("usage: sel time [set <RFC 3339 time>|now]")
+			}
+
+			setTime = time.Now()
+			if args[2] != "now" {
+				var err error
+				if setTime, err = time.Parse(time.RFC3339, args[2]); err != nil {
+					return nil, fmt.Errorf("invalid time: %s", args[2])
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown sel command %q, expected info, list, clear or time", args[0])
+	}
+
+	return func(client *ipmi.Client, w io.Writer) error {
+		return runSEL(client, w, args[0], setTime)
+	}, nil
+}
+
+// runSEL runs a sel command, setting the SEL time first if the time command has a setTime
+func runSEL(client *ipmi.Client, w io.Writer, command string, setTime time.Time) error {
+	switch command {
+	case "info":
+		info, err := client.GetSELInfo()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%-21s: %x.%x\n", "Version", info.Version&0x0f, info.Version>>4)
+		fmt.Fprintf(w, "%-21s: %d\n", "Entries", info.Entries)
+		fmt.Fprintf(w, "%-21s: %d bytes\n", "Free space", info.FreeSpace)
+		fmt.Fprintf(w, "%-21s: %s\n", "Last addition", selTimestamp(info.LastAddition))
+		fmt.Fprintf(w, "%-21s: %s\n", "Last erase", selTimestamp(info.LastErase))
+		fmt.Fprintf(w, "%-21s: %s\n", "Overflow", onOff(info.OperationSupport&0x80 != 0))
+
+	case "list":
+		entries, err := client.ReadSEL()
+		if err != nil {
+			return err
+		}
+
+		// Events are still listed if the sensor names cannot be read
+		var names map[ipmi.SensorKey]string
+		if records, err := client.ReadSDRRepository(); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot read sensor names: %s\n", err)
+		} else {
+			names = ipmi.SensorNames(records)
+		}
+
+		for _, e := range entries {
+			printSELEntry(w, e, names)
+		}
+
+	case "clear":
+		if err := client.ClearSEL(); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w, "SEL cleared")
+
+	case "time":
+		if !setTime.IsZero() {
+			if err := client.SetSELTime(setTime); err != nil {
+				return err
+			}
+		}
+
+		t, err := client.GetSELTime()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w, t.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// printSELEntry prints a SEL entry on a single line, naming the sensor if found in names
+func printSELEntry(w io.Writer, e *ipmi.SELEntry, names map[ipmi.SensorKey]string) {
+	ts := selTimestamp(e.Timestamp)
+
+	if !e.SystemEvent() {
+		fmt.Fprintf(w, "%4x | %-19s | %s | Manufacturer %d | % x\n", e.RecordID, ts, e.Description(), e.ManufacturerID, e.OEMData)
+		return
+	}
+
+	name, ok := names[e.SensorKey()]
+	if !ok {
+		name = fmt.Sprintf("#0x%02x", e.SensorNumber)
+	}
+
+	dir := "Asserted"
+	if e.Deassertion {
+		dir = "Deasserted"
+	}
+
+	fmt.Fprintf(w, "%4x | %-19s | %s %s | %s | %s", e.RecordID, ts, ipmi.SensorTypeName(e.SensorType), name, e.Description(), dir)
+
+	// Threshold events carry the trigger reading and threshold in event data 2 and 3
+	if e.EventType == ipmi.EventReadingTypeThreshold && e.EventData[0]&0xf0 == 0x50 {
+		fmt.Fprintf(w, " | Reading %d, threshold %d (raw)", e.EventData[1], e.EventData[2])
+	}
+
+	fmt.Fprintln(w)
+}
+
+// selTimestamp formats a SEL timestamp
+func selTimestamp(ts uint32) string {
+	if t, ok := ipmi.SELTime(ts); ok {
+		return t.Format("2006-01-02 15:04:05")
+	}
+
+	if ts == 0xffffffff {
+		return "Unspecified"
+	}
+
+	return fmt.Sprintf("Pre-init +%ds", ts)
+}
@@ -0,0 +1,45 @@
+package main
+
+// Simulated BMC, for testing IPMI clients without hardware
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi/ipmisim"
+)
+
+func cmdSimulate(args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:6230", "UDP address to listen on for RMCP packets")
+	profileFile := fs.String("profile", "", "Path to JSON device profile, instead of the built-in profile")
+	loss := fs.Float64("loss", 0, "Probability of discarding a request")
+	malformed := fs.Float64("malformed", 0, "Probability of truncating or corrupting a response")
+	fs.Parse(args)
+
+	profile := ipmisim.DefaultProfile()
+
+	if *profileFile != "" {
+		f, err := os.Open(*profileFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		profile = &ipmisim.Profile{}
+		if err := json.NewDecoder(f).Decode(profile); err != nil {
+			return fmt.Errorf("parse %s: %w", *profileFile, err)
+		}
+	}
+
+	sim := ipmisim.New(profile)
+	sim.SetFaults(ipmisim.Faults{Loss: *loss, Malformed: *malformed})
+
+	log.Printf("Simulating BMC with %d users and %d sensors on %s", len(profile.Users),
+		len(profile.Sensors), *listen)
+
+	return sim.ListenAndServe(*listen)
+}
@@ -0,0 +1,142 @@
+package main
+
+// Serial over LAN console subcommand
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dswarbrick/skunkworks/go-ipmi/ipmi"
+)
+
+// cmdSOL connects the terminal to a serial port of the managed system, until detached with an
+// escape sequence or the payload is deactivated by the BMC
+func cmdSOL(args []string) error {
+	fs := flag.NewFlagSet("sol", flag.ExitOnError)
+	instance := fs.Uint("instance", ipmi.DefaultSOLInstance, "SOL payload instance (serial port)")
+	escape := fs.String("escape", "~", "Escape character, recognized at the start of a line")
+	fs.Parse(args)
+
+	if len(*escape) != 1 {
+		return fmt.Errorf("escape must be a single character")
+	}
+	esc := (*escape)[0]
+
+	client, err := connect()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	sol, err := client.ActivateSOL(uint8(*instance))
+	if err != nil {
+		return fmt.Errorf("activate SOL: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "[SOL session active, %c. to detach, %c? for help]\n", esc, esc)
+
+	// Input that is not a terminal, e.g. a pipe, is sent as is
+	if restore, err := makeRaw(os.Stdin); err == nil {
+		defer restore()
+	}
+
+	output := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(os.Stdout, sol)
+		output <- err
+	}()
+
+	input := make(chan error, 1)
+	go func() {
+		input <- solInput(sol, os.Stdin, esc)
+	}()
+
+	select {
+	case err = <-output:
+	case err = <-input:
+	}
+
+	if cerr := sol.Close(); err == nil {
+		err = cerr
+	}
+
+	fmt.Fprint(os.Stderr, "\r\n[SOL session closed]\r\n")
+
+	if errors.Is(err, ipmi.ErrSOLDeactivated) {
+		return nil
+	}
+
+	return err
+}
+
+// solInput copies input to the SOL payload until detached or the input ends, interpreting escape
+// sequences that follow a newline
+func solInput(sol *ipmi.SOL, r io.Reader, esc byte) error {
+	buf := make([]byte, 256)
+	lineStart, escaped := true, false
+
+	for {
+		n, err := r.Read(buf)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var out []byte
+		detach := false
+
+		for _, c := range buf[:n] {
+			switch {
+			case escaped:
+				escaped = false
+
+				switch c {
+				case '.':
+					detach = true
+				case 'B':
+					// Characters preceding the break are sent first
+					if len(out) > 0 {
+						if _, err := sol.Write(out); err != nil {
+							return err
+						}
+						out = nil
+					}
+					sol.SendBreak()
+				case '?':
+					fmt.Fprintf(os.Stderr, "\r\nSupported escape sequences:\r\n"+
+						" %c.  Detach\r\n %cB  Send break\r\n %c?  This help\r\n %c%c  Send the escape character\r\n",
+						esc, esc, esc, esc, esc)
+				case esc:
+					out = append(out, c)
+				default:
+					out = append(out, esc, c)
+				}
+			case lineStart && c == esc:
+				escaped = true
+				continue
+			default:
+				out = append(out, c)
+			}
+
+			if detach {
+				break
+			}
+
+			lineStart = c == '\r' || c == '\n'
+		}
+
+		if len(out) > 0 {
+			if _, err := sol.Write(out); err != nil {
+				return err
+			}
+		}
+
+		if detach {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// makeRaw is not implemented on this platform, so the terminal remains line buffered
+func makeRaw(f *os.File) (func(), error) {
+	return nil, errors.New("raw terminal mode not supported on this platform")
+}